@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// configFileName is the config file looked up in the processed directory.
+const configFileName = ".potranslate.json"
+
+// Config holds the defaults read from a config file. Empty fields leave the
+// flag defaults in place.
+type Config struct {
+	Domain      string            `json:"domain"`
+	SourceLang  string            `json:"source_lang"`
+	Backend     string            `json:"backend"`
+	Endpoint    string            `json:"endpoint"`
+	Delay       string            `json:"delay"`  // Go duration, e.g. "500ms"
+	Delays      map[string]string `json:"delays"` // Per target language, e.g. {"ja": "2s"}
+	Concurrency int               `json:"concurrency"`
+}
+
+// loadConfig reads a JSON config file.
+func loadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %v", path, err)
+	}
+	return &config, nil
+}
+
+// applyConfig loads the config file and uses its values for the flags that
+// were not set on the command line. The precedence is: command line flags,
+// then the config file, then the flag defaults. The config file is the one
+// given by --config, or else .potranslate.json in the directory when present.
+func applyConfig(configPath, directory string, setFlags map[string]bool) error {
+	if configPath == "" {
+		configPath = filepath.Join(directory, configFileName)
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"domain":      config.Domain,
+		"source-lang": config.SourceLang,
+		"backend":     config.Backend,
+		"endpoint":    config.Endpoint,
+		"delay":       config.Delay,
+	}
+	if config.Concurrency != 0 {
+		values["concurrency"] = strconv.Itoa(config.Concurrency)
+	}
+
+	for name, value := range values {
+		if value == "" || setFlags[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s in config file '%s': %v", name, configPath, err)
+		}
+	}
+
+	// Each of the delays is a --lang-delay, unless that was given at all
+	if !setFlags["lang-delay"] {
+		for language, value := range config.Delays {
+			if err := flag.Set("lang-delay", language+"="+value); err != nil {
+				return fmt.Errorf("invalid delay of %s in config file '%s': %v", language, configPath, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	previousDomain, previousSourceLang, previousBackend := domain, options.SourceLang, options.Backend
+	previousConcurrency, previousDelay, previousDelays := options.Concurrency, delay, options.LanguageDelays
+	defer func() {
+		domain, options.SourceLang, options.Backend = previousDomain, previousSourceLang, previousBackend
+		options.Concurrency, delay, options.LanguageDelays = previousConcurrency, previousDelay, previousDelays
+	}()
+
+	tempDir := t.TempDir()
+	configContent := `{
+  "domain": "admin",
+  "source_lang": "fr",
+  "backend": "google",
+  "delay": "250ms",
+  "delays": {"ja": "2s", "pt-BR": "1.5s"},
+  "concurrency": 3
+}`
+	if err := os.WriteFile(filepath.Join(tempDir, configFileName), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	// Config values are used when the flags are absent
+	domain, options.SourceLang, options.Concurrency, delay = "default", "", 1, time.Second
+	if err := applyConfig("", tempDir, map[string]bool{}); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if domain != "admin" || options.SourceLang != "fr" || options.Concurrency != 3 || delay != 250*time.Millisecond {
+		t.Errorf("Config not applied: domain=%q source-lang=%q concurrency=%d delay=%v", domain, options.SourceLang, options.Concurrency, delay)
+	}
+	if options.LanguageDelays["ja"] != 2*time.Second || options.LanguageDelays["pt_BR"] != 1500*time.Millisecond {
+		t.Errorf("Delays not applied: %v", options.LanguageDelays)
+	}
+
+	// Flags given on the command line take precedence
+	domain, options.SourceLang, options.Concurrency, delay = "frontend", "", 8, time.Second
+	if err := applyConfig("", tempDir, map[string]bool{"domain": true, "concurrency": true}); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if domain != "frontend" || options.Concurrency != 8 {
+		t.Errorf("Flags were overridden: domain=%q concurrency=%d", domain, options.Concurrency)
+	}
+	if options.SourceLang != "fr" {
+		t.Errorf("Expected source-lang from config, got %q", options.SourceLang)
+	}
+}
+
+func TestApplyConfigPath(t *testing.T) {
+	previousDomain := domain
+	defer func() { domain = previousDomain }()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "potranslate.json")
+	if err := os.WriteFile(configPath, []byte(`{"domain": "shared"}`), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	// Without a config file in the directory nothing changes
+	domain = "default"
+	if err := applyConfig("", t.TempDir(), map[string]bool{}); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if domain != "default" {
+		t.Errorf("Expected domain to be unchanged, got %q", domain)
+	}
+
+	if err := applyConfig(configPath, t.TempDir(), map[string]bool{}); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if domain != "shared" {
+		t.Errorf("Expected domain from --config file, got %q", domain)
+	}
+
+	if err := applyConfig(filepath.Join(tempDir, "missing.json"), tempDir, map[string]bool{}); err == nil {
+		t.Error("Expected error for a missing --config file")
+	}
+
+	invalidPath := filepath.Join(tempDir, "invalid.json")
+	if err := os.WriteFile(invalidPath, []byte(`{"delay": "soon"}`), 0644); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+	if err := applyConfig(invalidPath, tempDir, map[string]bool{}); err == nil || !strings.Contains(err.Error(), "delay") {
+		t.Errorf("Expected invalid delay error, got %v", err)
+	}
+}
@@ -7,6 +7,8 @@ toolchain go1.24.11
 require (
 	github.com/bregydoc/gtranslate v0.0.0-20200913051839-1bd07f6c1fc5
 	github.com/schollz/progressbar/v3 v3.14.1
+	golang.org/x/term v0.15.0
+	golang.org/x/text v0.32.0
 )
 
 require (
@@ -14,7 +16,5 @@ require (
 	github.com/rivo/uniseg v0.4.4 // indirect
 	github.com/robertkrimen/otto v0.5.1 // indirect
 	golang.org/x/sys v0.15.0 // indirect
-	golang.org/x/term v0.15.0 // indirect
-	golang.org/x/text v0.32.0 // indirect
 	gopkg.in/sourcemap.v1 v1.0.5 // indirect
 )
@@ -1,39 +1,136 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/bregydoc/gtranslate"
-	"github.com/schollz/progressbar/v3"
+	"github.com/mevdschee/potranslate/pkg/catalog"
 )
 
 const version = "1.0.0"
 
 var (
-	fastMode    bool
-	rewriteMode bool
-	sourceLang  string
-	domain      string
-	addLang     string
-	showHelp    bool
-	showVer     bool
-	interrupted bool
+	fastMode   bool
+	statsMode  bool
+	validate   bool
+	reportFmt  string
+	recursive  bool
+	configPath string
+	delay      time.Duration
+	exclude    string
+	domain     string
+	endpoint   string
+	apiKey     string
+	cacheFile  string
+	mergeFrom  string
+	importPath string
+	showHelp   bool
+	showVer    bool
+	listLangs  bool
+	failOnMiss bool
+	exportPath string
+	rps        float64
+	sinceDate  string
+	quiet      bool
+	pseudo     string
+	fallback   string
+	potHeaders headerFlags
+	timing     bool
+	potCopy    string                     // Directory of the downloaded --pot URL, removed on exit
+	options    = catalog.DefaultOptions() // Set by the other flags
+	processor  *catalog.Processor         // Created with the options once they are parsed
 )
 
 func init() {
 	flag.BoolVar(&fastMode, "fast", false, "Use 0.1 second delay between translations (default: 1 second)")
-	flag.BoolVar(&rewriteMode, "rewrite", false, "Rewrite entire PO file from POT, keeping existing translations but removing obsolete entries")
-	flag.StringVar(&sourceLang, "source-lang", "", "Source language code (required if not in POT metadata)")
-	flag.StringVar(&domain, "domain", "default", "Translation domain name (default: \"default\")")
-	flag.StringVar(&addLang, "add-lang", "", "Create a new PO file for the specified language (2-letter code) from POT and translate it")
+	flag.DurationVar(&delay, "delay", time.Second, "Delay between translations")
+	flag.Var((*delayFlags)(&options.LanguageDelays), "lang-delay", "Delay between translations into a target language, e.g. ja=2s, replacing --delay and --fast for it (repeatable)")
+	flag.Float64Var(&rps, "rps", 0, "Maximum translation requests per second across all files and workers, replacing the default delay (0 for no limit)")
+	flag.DurationVar(&options.Timeout, "timeout", options.Timeout, "Maximum duration of a single translation request, 0 to wait indefinitely")
+	flag.StringVar(&configPath, "config", "", "Config file with default option values (default: <directory>/.potranslate.json)")
+	flag.BoolVar(&failOnMiss, "fail-on-missing", false, "Exit with an error listing the PO files that still have untranslated entries after the run")
+	flag.BoolVar(&statsMode, "stats", false, "Show the translation coverage of each PO file, without translating or writing anything")
+	flag.BoolVar(&validate, "validate", false, "Check the PO files like msgfmt --check, listing the problems with their line, without translating or writing anything")
+	flag.StringVar(&exportPath, "export-missing", "", "Write the entries of the PO file that still need a translation to this PO file, or CSV file for a .csv name, without translating anything")
+	flag.BoolVar(&options.Diff, "diff", options.Diff, "Print the changes to the PO files as a unified diff instead of writing them")
+	flag.BoolVar(&options.Rewrite, "rewrite", options.Rewrite, "Rewrite entire PO file from POT, keeping existing translations and marking obsolete entries")
+	flag.BoolVar(&options.Sort, "sort", options.Sort, "Write rewritten and added entries sorted by msgid instead of in POT file order")
+	flag.BoolVar(&options.KeepTranslatorComments, "keep-translator-comments", options.KeepTranslatorComments, "Keep the translator comments of existing entries when rewriting")
+	flag.BoolVar(&options.ClearPrevious, "clear-previous", options.ClearPrevious, "Remove the #| previous msgid of fuzzy entries once they are translated")
+	flag.BoolVar(&options.NormalizeNewlines, "normalize-newlines", options.NormalizeNewlines, "Match POT and PO msgids ignoring a trailing newline, writing the POT msgid")
+	flag.BoolVar(&options.ToUTF8, "to-utf8", options.ToUTF8, "Convert the written PO files declaring another charset to UTF-8, updating their header")
+	flag.BoolVar(&options.Canonical, "canonical", options.Canonical, "Format the written PO files like msgcat, with sorted references")
+	flag.BoolVar(&options.Compact, "compact", options.Compact, "Write the PO files with a single blank line between entries")
+	flag.BoolVar(&options.Dedupe, "dedupe", options.Dedupe, "Collapse duplicate entries of the PO file in rewrite mode, keeping the first translation")
+	flag.BoolVar(&options.PurgeObsolete, "purge-obsolete", options.PurgeObsolete, "Delete obsolete entries when rewriting instead of keeping them as #~ comments")
+	flag.BoolVar(&options.Backup, "backup", options.Backup, "Copy each PO file to a backup file before modifying it")
+	flag.StringVar(&options.BackupSuffix, "backup-suffix", options.BackupSuffix, "Suffix of backup files, {timestamp} is replaced with the current time")
+	flag.BoolVar(&options.Force, "force", options.Force, "Overwrite existing backup files")
+	flag.StringVar(&options.LastTranslator, "translator", options.LastTranslator, "Last-Translator header value written to modified PO files, empty to only update the PO-Revision-Date")
+	flag.BoolVar(&options.Interactive, "interactive", options.Interactive, "Accept, edit or skip each translation before it is written")
+	flag.IntVar(&options.Limit, "limit", options.Limit, "Translate at most this many strings per run, leaving the rest for the next run (0 for no limit)")
+	flag.IntVar(&options.FlushEvery, "flush-every", options.FlushEvery, "Write the PO file every this many translated strings, so a crash doesn't lose them (0 to write it once)")
+	flag.BoolVar(&options.LimitPerFile, "limit-per-file", options.LimitPerFile, "Apply --limit to each PO file instead of the whole run")
+	flag.BoolVar(&options.ForceRetranslate, "force-retranslate", options.ForceRetranslate, "Translate entries again even when they already have a translation, except those flagged manual")
+	flag.StringVar(&options.RetranslateOnly, "retranslate-only", options.RetranslateOnly, "Limit --force-retranslate to entries with this flag or a comment containing this text")
+	flag.StringVar(&options.FilterRef, "filter-ref", options.FilterRef, "Only translate entries with a #: source reference matching this glob (e.g., 'templates/email.html:*')")
+	flag.StringVar(&sinceDate, "since", "", "Only add and translate the POT entries with a '#. date:' comment on or after this date (e.g., 2026-01-15)")
+	flag.BoolVar(&options.SkipUndated, "skip-undated", options.SkipUndated, "Leave out the POT entries without a '#. date:' comment with --since")
+	flag.Var((*patternFlags)(&options.IgnorePatterns), "ignore-pattern", "Regular expression of msgids to copy verbatim instead of translating, e.g. ^https?:// (repeatable)")
+	flag.BoolVar(&options.IgnoreEmpty, "ignore-empty", options.IgnoreEmpty, "Leave the msgids matching --ignore-pattern untranslated instead of copying them")
+	flag.BoolVar(&options.MarkFuzzy, "mark-fuzzy", options.MarkFuzzy, "Mark machine-translated entries as fuzzy so they get reviewed")
+	flag.StringVar(&options.AddedComment, "added-comment", options.AddedComment, "Comment on entries added from the POT file without comments (empty to omit it)")
+	flag.BoolVar(&options.CheckMarkup, "check-markup", options.CheckMarkup, "Mark translations fuzzy when their HTML/XML tags don't match the source")
+	flag.BoolVar(&options.RejectFormatMismatch, "reject-format-mismatch", options.RejectFormatMismatch, "Leave translations untranslated whose format specifiers don't match the msgid, instead of marking them fuzzy")
+	flag.IntVar(&options.MaxLength, "max-length", options.MaxLength, "Warn about translations longer than this many characters, a '#. max-length:' comment sets it per entry (0 for no maximum)")
+	flag.BoolVar(&options.MaxLengthFuzzy, "max-length-fuzzy", options.MaxLengthFuzzy, "Mark the translations longer than their maximum length as fuzzy")
+	flag.StringVar(&options.PlaceholderStyle, "placeholder-style", options.PlaceholderStyle, "Protect placeholders during translation: \"auto\" (by the format flag of each entry, like c-format), \"c\" (%s), \"positional\" (%1$s), \"python\" ({name}) for the entries without a format flag, or \"none\"")
+	flag.BoolVar(&options.NoWrap, "no-wrap", options.NoWrap, "Don't wrap long strings over multiple lines")
+	flag.IntVar(&options.Width, "width", options.Width, "Column at which long strings are wrapped, like the GNU gettext tools")
+	flag.BoolVar(&recursive, "recursive", false, "Process every directory below the given directory containing the POT file")
+	flag.StringVar(&exclude, "exclude", "", "Comma separated globs of directories to skip when scanning recursively (e.g., vendor,node_modules)")
+	flag.StringVar(&options.OnlyLang, "only-lang", options.OnlyLang, "Comma separated target languages to process, skipping all others (e.g., es,fr)")
+	flag.StringVar(&options.SkipLang, "skip-lang", options.SkipLang, "Comma separated target languages to skip (e.g., de)")
+	flag.StringVar(&options.SourceLang, "source-lang", options.SourceLang, "Source language code (required if not in POT metadata)")
+	flag.BoolVar(&options.ForceSource, "force-source", options.ForceSource, "Use --source-lang instead of the language of the POT metadata, and correct the POT file")
+	flag.BoolVar(&options.DetectSource, "detect-source", options.DetectSource, "Detect the source language from the msgids when it is not in the POT metadata")
+	flag.StringVar(&domain, "domain", "default", "Translation domain name, a comma separated list of domains or \"all\" for every POT file (default: \"default\")")
+	flag.StringVar(&options.Pot, "pot", options.Pot, "POT file to use instead of <domain>.pot in the directory, the PO files are still found by domain")
+	flag.Var(&potHeaders, "pot-header", "Header sent when downloading a --pot URL, e.g. 'Authorization: Bearer <token>' (repeatable)")
+	flag.StringVar(&options.Layout, "layout", options.Layout, "PO file layout: \"flat\" (<domain>_<lang>.po) or \"gnu\" (<lang>/LC_MESSAGES/<domain>.po)")
+	flag.StringVar(&options.Trust, "trust", options.Trust, "Where the target language comes from when they disagree: the \"header\" Language or the \"filename\"")
+	flag.StringVar(&options.OutDir, "out-dir", options.OutDir, "Write the translated PO files below this directory instead of modifying them in place")
+	flag.StringVar(&options.Naming, "naming", options.Naming, "PO file naming in the flat layout: \"underscore\" (<domain>_<lang>.po), \"hyphen\" (<domain>-<lang>.po) or \"dot\" (<domain>.<lang>.po)")
+	flag.StringVar(&options.Backend, "backend", options.Backend, "Translation backend: \"google\" or \"libretranslate\"")
+	flag.StringVar(&fallback, "fallback", "", "Comma separated backends to try in order for the strings the --backend fails to translate (e.g., libretranslate)")
+	flag.StringVar(&endpoint, "endpoint", "", "Server URL for the libretranslate backend (e.g., http://localhost:5000)")
+	flag.StringVar(&apiKey, "api-key", "", "Optional API key for the libretranslate backend")
+	flag.StringVar(&cacheFile, "cache-file", ".potranslate-cache.json", "Translation cache file, empty to disable caching")
+	flag.BoolVar(&quiet, "quiet", false, "Only print warnings and errors, to stderr, without the progress and summaries")
+	flag.BoolVar(&options.Verbose, "verbose", options.Verbose, "Print each translated string with the backend that translated it")
+	flag.StringVar(&options.Progress, "progress", options.Progress, "Progress output: \"auto\" (bar on a terminal, plain otherwise), \"bar\", \"plain\" or \"none\"")
+	flag.StringVar(&reportFmt, "report", "text", "Summary format: \"text\" or \"json\" (written to stdout, progress goes to stderr)")
+	flag.IntVar(&options.Concurrency, "concurrency", options.Concurrency, "Number of translation requests to run in parallel")
+	flag.StringVar(&mergeFrom, "merge-from", "", "PO file whose translations are reused for matching untranslated entries before using the backend")
+	flag.StringVar(&importPath, "import", "", "CSV or JSON file of msgid to msgstr filling the matching untranslated or fuzzy entries, without using the backend")
+	flag.StringVar(&options.AddLang, "add-lang", options.AddLang, "Create new PO files for the comma separated languages (e.g., de or es,fr,pt_BR) from POT and translate them")
+	flag.StringVar(&options.SeedFrom, "seed-from", options.SeedFrom, "Copy the translations of this language's PO file into the file created by --add-lang before translating the rest (e.g., pt)")
+	flag.BoolVar(&options.Repair, "repair", options.Repair, "Fix unescaped quotes and missing closing quotes in the strings of PO files, instead of skipping such files")
+	flag.BoolVar(&options.AllowIdentical, "allow-identical", options.AllowIdentical, "Accept translations identical to the source text, which are otherwise retried once and then counted as failed")
+	flag.StringVar(&pseudo, "pseudo", "", "Fill the translations with the msgid transformed by \"double\", \"accent\" and/or \"bracket\" (e.g., accent,bracket) instead of using the backend")
+	flag.BoolVar(&options.NoNetwork, "no-network", options.NoNetwork, "Never use the backend, only add the missing entries and leave them untranslated")
+	flag.BoolVar(&timing, "timing", false, "Print the wall time, the number and average duration of the backend requests and the cache hit rate at the end")
+	flag.BoolVar(&listLangs, "list-languages", false, "List the target languages supported by the backend")
 	flag.BoolVar(&showHelp, "help", false, "Display usage information")
 	flag.BoolVar(&showVer, "version", false, "Display version information")
 }
@@ -43,960 +140,803 @@ func main() {
 
 	if showVer {
 		fmt.Printf("potranslate version %s\n", version)
-		os.Exit(0)
+		os.Exit(exitOK)
 	}
 
 	if showHelp {
 		printHelp()
-		os.Exit(0)
+		os.Exit(exitOK)
 	}
 
+	// Listing languages only needs a directory for its config file
 	args := flag.Args()
+	if listLangs && len(args) == 0 {
+		args = []string{"."}
+	}
 	if len(args) != 1 {
-		fmt.Fprintf(os.Stderr, "Error: Please provide a directory path\n\n")
+		fmt.Fprintf(os.Stderr, "Error: Please provide a directory path, PO file or -\n\n")
 		printHelp()
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 
 	directory := args[0]
 
+	// A PO file, or - for stdin, is translated on its own with --pot
+	var file string
+	if info, err := os.Stat(directory); directory == "-" || (err == nil && info.Mode().IsRegular()) {
+		file = directory
+		directory = filepath.Dir(file)
+	}
+
 	// Verify directory exists
 	if info, err := os.Stat(directory); err != nil || !info.IsDir() {
 		fmt.Fprintf(os.Stderr, "Error: '%s' is not a valid directory\n", directory)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 
-	// Setup signal handling for Ctrl-C
-	setupSignalHandler()
-
-	// Get translation delay
-	delay := time.Second
-	if fastMode {
-		delay = 100 * time.Millisecond
+	// Flags given on the command line override the config file
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if err := applyConfig(configPath, directory, setFlags); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	// Find POT file
-	potFile := filepath.Join(directory, domain+".pot")
-	if _, err := os.Stat(potFile); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: POT file '%s' not found\n", potFile)
-		os.Exit(1)
+	if options.Layout != "flat" && options.Layout != "gnu" {
+		fmt.Fprintf(os.Stderr, "Error: Layout must be 'flat' or 'gnu'\n")
+		os.Exit(exitError)
 	}
 
-	fmt.Printf("Processing domain: %s\n", domain)
-	fmt.Printf("POT file: %s\n", potFile)
-
-	// Parse POT file and get source language
-	potEntries, detectedSourceLang, err := parsePotFile(potFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing POT file: %v\n", err)
-		os.Exit(1)
+	if !catalog.ValidNaming(options.Naming) {
+		fmt.Fprintf(os.Stderr, "Error: Naming must be 'underscore', 'hyphen' or 'dot'\n")
+		os.Exit(exitError)
 	}
 
-	// Determine source language
-	finalSourceLang := detectedSourceLang
-	if finalSourceLang == "" {
-		if sourceLang == "" {
-			fmt.Fprintf(os.Stderr, "Error: Source language not detected in POT file and not provided via --source-lang\n")
-			os.Exit(1)
-		}
-		finalSourceLang = sourceLang
-		// Update POT file with source language
-		if err := updatePotLanguage(potFile, finalSourceLang); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not update POT file metadata: %v\n", err)
-		} else {
-			fmt.Printf("Updated POT file with source language: %s\n", finalSourceLang)
-		}
-	} else if sourceLang != "" && sourceLang != finalSourceLang {
-		fmt.Printf("Warning: Using source language from POT file (%s) instead of provided flag (%s)\n", finalSourceLang, sourceLang)
+	if rps < 0 {
+		fmt.Fprintf(os.Stderr, "Error: Requests per second must be 0 or more\n")
+		os.Exit(exitError)
 	}
 
-	fmt.Printf("Source language: %s\n", finalSourceLang)
-
-	// Handle add-lang flag: create new language file
-	if addLang != "" {
-		if len(addLang) != 2 {
-			fmt.Fprintf(os.Stderr, "Error: Language code must be 2 letters (e.g., 'es', 'fr', 'de')\n")
-			os.Exit(1)
-		}
-
-		newPoFile := filepath.Join(directory, fmt.Sprintf("%s_%s.po", domain, addLang))
-
-		// Check if file already exists
-		if _, err := os.Stat(newPoFile); err == nil {
-			fmt.Fprintf(os.Stderr, "Error: PO file '%s' already exists\n", newPoFile)
-			os.Exit(1)
-		}
-
-		fmt.Printf("\nCreating new language file: %s\n", filepath.Base(newPoFile))
-
-		// Copy POT to new PO file
-		if err := copyPotToPo(potFile, newPoFile, addLang); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating PO file: %v\n", err)
-			os.Exit(1)
-		}
+	if options.Limit < 0 {
+		fmt.Fprintf(os.Stderr, "Error: Limit must be 0 or more\n")
+		os.Exit(exitError)
+	}
 
-		fmt.Printf("Created: %s\n", filepath.Base(newPoFile))
-		fmt.Printf("Translating to: %s\n\n", addLang)
+	if options.FlushEvery < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --flush-every must be 0 or more\n")
+		os.Exit(exitError)
+	}
 
-		// Translate the new file
-		translated, err := translatePoFile(newPoFile, potEntries, finalSourceLang, addLang, delay)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error translating new PO file: %v\n", err)
-			os.Exit(1)
-		}
+	if options.RetranslateOnly != "" && !options.ForceRetranslate {
+		fmt.Fprintf(os.Stderr, "Error: --retranslate-only needs --force-retranslate\n")
+		os.Exit(exitError)
+	}
 
-		fmt.Printf("\nComplete! Translated %d string(s)\n", translated)
-		os.Exit(0)
+	if options.Trust != "header" && options.Trust != "filename" {
+		fmt.Fprintf(os.Stderr, "Error: Trust must be 'header' or 'filename'\n")
+		os.Exit(exitError)
 	}
 
-	// Find all PO files for this domain
-	poFiles, err := findPoFiles(directory, domain)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding PO files: %v\n", err)
-		os.Exit(1)
+	if reportFmt != "text" && reportFmt != "json" {
+		fmt.Fprintf(os.Stderr, "Error: Report format must be 'text' or 'json'\n")
+		os.Exit(exitError)
 	}
 
-	if len(poFiles) == 0 {
-		fmt.Printf("No PO files found for domain '%s'\n", domain)
-		os.Exit(0)
+	if !catalog.ValidProgressMode(options.Progress) {
+		fmt.Fprintf(os.Stderr, "Error: Progress must be 'auto', 'bar', 'plain' or 'none'\n")
+		os.Exit(exitError)
 	}
 
-	fmt.Printf("Found %d PO file(s)\n\n", len(poFiles))
+	var report *catalog.Report
+	if reportFmt == "json" || failOnMiss {
+		// --fail-on-missing uses the missing counts of the report
+		report = &catalog.Report{Domain: domain, Files: []catalog.FileReport{}}
+	}
 
-	// Process each PO file
-	totalTranslated := 0
+	if options.MaxLength < 0 {
+		fmt.Fprintf(os.Stderr, "Error: Maximum length must be 0 or more\n")
+		os.Exit(exitError)
+	}
 
-	for _, poFile := range poFiles {
-		if interrupted {
-			fmt.Println("\nInterrupted by user. Exiting...")
-			break
-		}
+	if options.Width < 1 {
+		fmt.Fprintf(os.Stderr, "Error: Width must be at least 1\n")
+		os.Exit(exitError)
+	}
 
-		targetLang, err := getTargetLanguage(poFile)
+	if sinceDate != "" {
+		date, err := catalog.ParseDate(sinceDate)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not determine target language for %s: %v\n", filepath.Base(poFile), err)
-			continue
+			fmt.Fprintf(os.Stderr, "Error: --since: %v\n", err)
+			os.Exit(exitError)
 		}
+		options.Since = date
+	} else if options.SkipUndated {
+		fmt.Fprintf(os.Stderr, "Error: --skip-undated needs --since\n")
+		os.Exit(exitError)
+	}
 
-		fmt.Printf("Processing: %s (target: %s)\n", filepath.Base(poFile), targetLang)
-
-		var translated int
-		if rewriteMode {
-			translated, err = rewritePoFile(poFile, potEntries, finalSourceLang, targetLang, delay)
-		} else {
-			translated, err = translatePoFile(poFile, potEntries, finalSourceLang, targetLang, delay)
+	if file != "" {
+		if options.Pot == "" {
+			fmt.Fprintf(os.Stderr, "Error: A single PO file needs --pot\n")
+			os.Exit(exitError)
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", filepath.Base(poFile), err)
-			continue
+		if recursive || options.AddLang != "" || statsMode || validate || exportPath != "" {
+			fmt.Fprintf(os.Stderr, "Error: A single PO file can't be combined with --recursive, --add-lang, --stats, --validate or --export-missing\n")
+			os.Exit(exitError)
 		}
-
-		totalTranslated += translated
-		fmt.Printf("Translated %d string(s)\n\n", translated)
 	}
-
-	if interrupted {
-		fmt.Printf("\nPartially completed: %d translation(s) saved\n", totalTranslated)
-		os.Exit(130) // Standard exit code for SIGINT
-	} else {
-		fmt.Printf("Complete! Translated %d string(s) total\n", totalTranslated)
+	if file == "-" {
+		if options.OutDir != "" || options.Diff || options.Backup || reportFmt == "json" {
+			fmt.Fprintf(os.Stderr, "Error: Reading from stdin can't be combined with --out-dir, --diff, --backup or --report json\n")
+			os.Exit(exitError)
+		}
 	}
-}
 
-func setupSignalHandler() {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	if quiet && options.Diff {
+		fmt.Fprintf(os.Stderr, "Error: --quiet and --diff can't be combined\n")
+		os.Exit(exitError)
+	}
+	options.Output = humanOutput(file)
 
-	go func() {
-		<-sigChan
-		interrupted = true
-	}()
-}
+	if options.Pot != "" && file == "" && (recursive || domain == "all" || len(catalog.DomainList(domain)) > 1) {
+		fmt.Fprintf(os.Stderr, "Error: --pot needs a single domain in a single directory, without --recursive\n")
+		os.Exit(exitError)
+	}
 
-func printHelp() {
-	fmt.Println("potranslate - Translate missing strings in PO files in a given directory")
-	fmt.Printf("\nUsage: potranslate [options] <directory>\n\n")
-	fmt.Println("Options:")
-	flag.PrintDefaults()
-	fmt.Println("\nExamples:")
-	fmt.Println("  potranslate ./locales")
-	fmt.Println("  potranslate --fast ./locales")
-	fmt.Println("  potranslate --source-lang en ./locales")
-	fmt.Println("  potranslate --domain admin ./locales")
-	fmt.Println("  potranslate --rewrite ./locales")
-	fmt.Println("  potranslate --fast --source-lang en --domain admin ./locales")
-	fmt.Println("  potranslate --rewrite --fast ./locales")
-	fmt.Println("  potranslate --add-lang de ./locales")
-	fmt.Println("  potranslate --add-lang ja --fast ./locales")
-}
+	if _, err := path.Match(options.FilterRef, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid --filter-ref glob '%s'\n", options.FilterRef)
+		os.Exit(exitError)
+	}
 
-func findPoFiles(directory, domain string) ([]string, error) {
-	// Only support underscore naming: domain_*.po
-	pattern := filepath.Join(directory, domain+"_*.po")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
+	if options.OnlyLang != "" && options.SkipLang != "" {
+		fmt.Fprintf(os.Stderr, "Error: --only-lang and --skip-lang can't be combined\n")
+		os.Exit(exitError)
 	}
 
-	return matches, nil
-}
+	if options.NoNetwork && (listLangs || options.DetectSource) {
+		fmt.Fprintf(os.Stderr, "Error: --no-network can't be combined with --list-languages or --detect-source\n")
+		os.Exit(exitError)
+	}
 
-type POEntry struct {
-	Msgstr   string
-	Comments []string
-}
+	if pseudo != "" && (options.NoNetwork || listLangs || options.DetectSource || importPath != "") {
+		fmt.Fprintf(os.Stderr, "Error: --pseudo can't be combined with --no-network, --list-languages, --detect-source or --import\n")
+		os.Exit(exitError)
+	}
+	if fallback != "" && (pseudo != "" || options.NoNetwork || listLangs) {
+		fmt.Fprintf(os.Stderr, "Error: --fallback can't be combined with --pseudo, --no-network or --list-languages\n")
+		os.Exit(exitError)
+	}
+	if pseudo != "" {
+		// Named in the messages like a backend
+		options.Backend = "pseudo"
+	}
 
-func parsePotFile(potFile string) (map[string]POEntry, string, error) {
-	file, err := os.Open(potFile)
-	if err != nil {
-		return nil, "", err
-	}
-	defer file.Close()
-
-	entries := make(map[string]POEntry)
-	var currentMsgid, currentMsgstr string
-	var currentComments []string
-	var pendingComments []string
-	var inMsgid, inMsgstr bool
-	var sourceLang string
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		// Check for Language header
-		if strings.Contains(line, "\"Language:") {
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				// Remove quotes, \n escape sequence, and whitespace
-				lang := strings.TrimSpace(parts[1])
-				lang = strings.TrimPrefix(lang, "\"")
-				lang = strings.TrimSuffix(lang, "\\n\"")
-				lang = strings.TrimSuffix(lang, "\"")
-				if lang != "" {
-					sourceLang = lang
-				}
-			}
-		}
+	if options.Diff && options.AddLang != "" {
+		fmt.Fprintf(os.Stderr, "Error: --diff and --add-lang can't be combined\n")
+		os.Exit(exitError)
+	}
 
-		if strings.HasPrefix(trimmed, "msgid ") {
-			// Save previous entry
-			if currentMsgid != "" {
-				entries[currentMsgid] = POEntry{
-					Msgstr:   currentMsgstr,
-					Comments: currentComments,
-				}
+	if options.AddLang != "" {
+		var languages []string
+		for _, lang := range strings.Split(options.AddLang, ",") {
+			lang = strings.TrimSpace(lang)
+			if !catalog.ValidLocale(lang) {
+				fmt.Fprintf(os.Stderr, "Error: Language code must be a locale code (e.g., 'es', 'pt_BR', 'zh_Hans')\n")
+				os.Exit(exitError)
 			}
-			currentMsgid = extractString(trimmed[6:])
-			currentMsgstr = ""
-			currentComments = pendingComments
-			pendingComments = []string{}
-			inMsgid = true
-			inMsgstr = false
-		} else if strings.HasPrefix(trimmed, "msgstr ") {
-			currentMsgstr = extractString(trimmed[7:])
-			inMsgid = false
-			inMsgstr = true
-		} else if strings.HasPrefix(trimmed, "\"") && (inMsgid || inMsgstr) {
-			str := extractString(trimmed)
-			if inMsgid {
-				currentMsgid += str
-			} else if inMsgstr {
-				currentMsgstr += str
+			if lang = catalog.NormalizeLocale(lang); !slices.Contains(languages, lang) {
+				languages = append(languages, lang)
 			}
-		} else if strings.HasPrefix(trimmed, "#") {
-			// Collect comments before next msgid
-			if !inMsgid && !inMsgstr {
-				pendingComments = append(pendingComments, line)
-			}
-			inMsgid = false
-			inMsgstr = false
-		} else if trimmed == "" {
-			inMsgid = false
-			inMsgstr = false
 		}
+		options.AddLang = strings.Join(languages, ",")
 	}
 
-	// Save last entry
-	if currentMsgid != "" {
-		entries[currentMsgid] = POEntry{
-			Msgstr:   currentMsgstr,
-			Comments: currentComments,
-		}
+	if options.ForceSource && options.SourceLang == "" {
+		fmt.Fprintf(os.Stderr, "Error: --force-source needs --source-lang\n")
+		os.Exit(exitError)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, "", err
+	if options.SeedFrom != "" {
+		if options.AddLang == "" {
+			fmt.Fprintf(os.Stderr, "Error: --seed-from needs --add-lang\n")
+			os.Exit(exitError)
+		}
+		if !catalog.ValidLocale(options.SeedFrom) {
+			fmt.Fprintf(os.Stderr, "Error: Seed language code must be a locale code (e.g., 'pt')\n")
+			os.Exit(exitError)
+		}
+		options.SeedFrom = catalog.NormalizeLocale(options.SeedFrom)
 	}
 
-	// Remove empty msgid (header)
-	delete(entries, "")
-
-	return entries, sourceLang, nil
-}
-
-func extractString(s string) string {
-	s = strings.TrimSpace(s)
-	if strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") {
-		s = s[1 : len(s)-1]
+	if !catalog.ValidPlaceholderStyle(options.PlaceholderStyle) {
+		fmt.Fprintf(os.Stderr, "Error: Placeholder style must be 'c', 'positional', 'python', 'auto' or 'none'\n")
+		os.Exit(exitError)
 	}
-	// Handle escape sequences
-	s = strings.ReplaceAll(s, "\\n", "\n")
-	s = strings.ReplaceAll(s, "\\t", "\t")
-	s = strings.ReplaceAll(s, "\\\"", "\"")
-	return s
-}
-
-func escapeString(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
-}
 
-func getTargetLanguage(poFile string) (string, error) {
-	file, err := os.Open(poFile)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "\"Language:") {
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				// Remove quotes, \n escape sequence, and whitespace
-				lang := strings.TrimSpace(parts[1])
-				lang = strings.TrimPrefix(lang, "\"")
-				lang = strings.TrimSuffix(lang, "\\n\"")
-				lang = strings.TrimSuffix(lang, "\"")
-				if lang != "" {
-					return lang, nil
-				}
-			}
-		}
+	// A single limiter for the whole run, so file boundaries don't reset it
+	if rps > 0 {
+		options.RateLimiter = catalog.NewRateLimiter(rps)
 	}
 
-	// Fallback: try to extract from filename (e.g., default_es.po -> es)
-	base := filepath.Base(poFile)
-	parts := strings.Split(base, "_")
-	if len(parts) >= 2 {
-		lang := strings.TrimSuffix(parts[len(parts)-1], ".po")
-		if lang != "" {
-			return lang, nil
+	// A POT file behind a URL is downloaded once for the whole run
+	if catalog.IsURL(options.Pot) {
+		dir, err := os.MkdirTemp("", "potranslate-")
+		if err == nil {
+			potCopy = dir
+			options.Pot, err = catalog.DownloadPot(options.Pot, dir, potHeaders, options.Timeout)
 		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error downloading POT file: %v\n", err)
+			exit(exitError)
+		}
+	} else if len(potHeaders) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: --pot-header needs a --pot URL\n")
+		os.Exit(exitError)
 	}
+	defer removePotCopy()
 
-	return "", fmt.Errorf("could not determine target language")
-}
-
-func translatePoFile(poFile string, potEntries map[string]POEntry, sourceLang, targetLang string, delay time.Duration) (int, error) {
-	// Read PO file
-	content, err := os.ReadFile(poFile)
-	if err != nil {
-		return 0, err
+	// Pseudo translations don't reach a backend that needs its delays
+	if pseudo != "" {
+		options.LanguageDelays = nil
 	}
 
-	lines := strings.Split(string(content), "\n")
-
-	// First pass: collect existing msgids in PO file
-	existingMsgids := make(map[string]bool)
-	currentMsgid := ""
-	inMsgid := false
-	inMsgstr := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	processor = catalog.New(options)
 
-		if strings.HasPrefix(trimmed, "msgid ") {
-			currentMsgid = extractString(trimmed[6:])
-			inMsgid = true
-			inMsgstr = false
-		} else if strings.HasPrefix(trimmed, "msgstr ") {
-			if currentMsgid != "" {
-				existingMsgids[currentMsgid] = true
-			}
-			inMsgid = false
-			inMsgstr = true
-		} else if strings.HasPrefix(trimmed, "\"") && inMsgid {
-			currentMsgid += extractString(trimmed)
-		} else if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			inMsgid = false
-			inMsgstr = false
+	// Without the network no translator is created at all
+	var translator catalog.Translator
+	var err error
+	if pseudo != "" {
+		translator, err = catalog.NewPseudoTranslator(pseudo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+	} else if !options.NoNetwork {
+		if fallback != "" {
+			backends := append([]string{options.Backend}, strings.Split(fallback, ",")...)
+			translator, err = catalog.NewFallbackTranslator(backends, endpoint, apiKey)
+		} else {
+			translator, err = catalog.NewTranslator(options.Backend, endpoint, apiKey)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
 		}
 	}
 
-	// Find missing entries that need to be added
-	var missingMsgids []string
-	for msgid := range potEntries {
-		if msgid != "" && !existingMsgids[msgid] {
-			missingMsgids = append(missingMsgids, msgid)
+	if listLangs {
+		if err := processor.WriteLanguages(os.Stdout, translator); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing languages: %v\n", err)
+			os.Exit(exitError)
 		}
+		return
 	}
 
-	// Add missing entries to the end of the file
-	if len(missingMsgids) > 0 {
-		// Ensure file ends with newline
-		if len(lines) > 0 && lines[len(lines)-1] != "" {
-			lines = append(lines, "")
+	// Pseudo translations never end up in the cache of the real ones
+	if cacheFile != "" && !statsMode && !validate && pseudo == "" {
+		if err := processor.LoadCache(cacheFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading cache file '%s': %v\n", cacheFile, err)
+			os.Exit(exitError)
 		}
+	}
 
-		for _, msgid := range missingMsgids {
-			lines = append(lines, "")
-			// Add comments from POT file
-			if entry, exists := potEntries[msgid]; exists && len(entry.Comments) > 0 {
-				for _, comment := range entry.Comments {
-					lines = append(lines, comment)
-				}
-			} else {
-				lines = append(lines, "#: (added from POT)")
-			}
-			if strings.Contains(msgid, "\n") {
-				// Multi-line msgid
-				lines = append(lines, "msgid \"\"")
-				parts := strings.Split(msgid, "\n")
-				for idx, part := range parts {
-					if idx < len(parts)-1 {
-						lines = append(lines, fmt.Sprintf("\"%s\\n\"", escapeString(part)))
-					} else if part != "" {
-						lines = append(lines, fmt.Sprintf("\"%s\"", escapeString(part)))
-					}
-				}
-			} else {
-				lines = append(lines, fmt.Sprintf("msgid \"%s\"", escapeString(msgid)))
-			}
-			lines = append(lines, "msgstr \"\"")
+	if mergeFrom != "" && !statsMode && !validate {
+		if err := processor.LoadMemory(mergeFrom); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading merge file '%s': %v\n", mergeFrom, err)
+			os.Exit(exitError)
 		}
+	}
 
-		// Write updated content back to file
-		newContent := strings.Join(lines, "\n")
-		if err := os.WriteFile(poFile, []byte(newContent), 0644); err != nil {
-			return 0, fmt.Errorf("failed to add missing entries: %v", err)
+	if importPath != "" && !statsMode && !validate {
+		if err := processor.LoadImport(importPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading import file '%s': %v\n", importPath, err)
+			os.Exit(exitError)
 		}
+	}
 
-		fmt.Printf("Added %d missing entry/entries from POT file\n", len(missingMsgids))
+	// Setup signal handling for Ctrl-C
+	setupSignalHandler()
+	start := time.Now()
 
-		// Re-read the file for translation
-		content, err = os.ReadFile(poFile)
-		if err != nil {
-			return 0, err
-		}
-		lines = strings.Split(string(content), "\n")
-	}
-
-	// Second pass: find entries that need translation
-	var needsTranslation []string
-	currentMsgid = ""
-	currentMsgstr := ""
-	inMsgid = false
-	inMsgstr = false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmed, "msgid ") {
-			currentMsgid = extractString(trimmed[6:])
-			inMsgid = true
-			inMsgstr = false
-		} else if strings.HasPrefix(trimmed, "msgstr ") {
-			currentMsgstr = extractString(trimmed[7:])
-			inMsgid = false
-			inMsgstr = true
-
-			// Check if this entry needs translation
-			if currentMsgid != "" && currentMsgstr == "" {
-				if entry, exists := potEntries[currentMsgid]; exists && entry.Msgstr == "" {
-					needsTranslation = append(needsTranslation, currentMsgid)
-				}
-			}
-		} else if strings.HasPrefix(trimmed, "\"") && inMsgid {
-			currentMsgid += extractString(trimmed)
-		} else if strings.HasPrefix(trimmed, "\"") && inMsgstr {
-			currentMsgstr += extractString(trimmed)
-		} else if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			inMsgid = false
-			inMsgstr = false
-		}
+	// Get translation delay
+	if fastMode {
+		delay = 100 * time.Millisecond
+	} else if (rps > 0 || pseudo != "") && !delaySet() {
+		// --rps paces the requests instead of the default delay, and
+		// --pseudo doesn't use the network at all
+		delay = 0
 	}
 
-	if len(needsTranslation) == 0 {
-		// Return count of missing entries that were added
-		if len(missingMsgids) > 0 {
-			return 0, nil
-		}
-		return 0, nil
-	}
-
-	// Create translation map
-	translations := make(map[string]string)
-
-	// Create progress bar
-	bar := progressbar.NewOptions(len(needsTranslation),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionShowCount(),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionSetDescription(fmt.Sprintf("[cyan]%s[reset]", filepath.Base(poFile))),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]=[reset]",
-			SaucerHead:    "[green]>[reset]",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}))
-
-	// Translate each missing string
-	translatedCount := 0
-	for _, msgid := range needsTranslation {
-		if interrupted {
-			break
-		}
-
-		translated, err := gtranslate.TranslateWithParams(
-			msgid,
-			gtranslate.TranslationParams{
-				From: sourceLang,
-				To:   targetLang,
-			},
-		)
+	// Find the directories to process
+	directories := []string{directory}
+	if recursive {
+		directories, err = catalog.FindPotDirectories(directory, domain, exclude)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "\nWarning: Translation failed for '%s': %v\n", msgid, err)
-			bar.Add(1)
-			continue
+			fmt.Fprintf(os.Stderr, "Error scanning directory: %v\n", err)
+			os.Exit(exitError)
 		}
-
-		translations[msgid] = translated
-		translatedCount++
-		bar.Add(1)
-
-		// Rate limiting
-		if !interrupted && translatedCount < len(needsTranslation) {
-			time.Sleep(delay)
+		potFiles := "POT files"
+		if domains := catalog.DomainList(domain); len(domains) == 1 {
+			potFiles = fmt.Sprintf("'%s.pot' files", domains[0])
 		}
+		if len(directories) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: No %s found in '%s'\n", potFiles, directory)
+			os.Exit(exitError)
+		}
+		fmt.Fprintf(options.Output, "Found %d director(y/ies) with %s\n\n", len(directories), potFiles)
 	}
 
-	fmt.Println() // New line after progress bar
-
-	if translatedCount == 0 {
-		return 0, nil
-	}
-
-	// Update PO file with translations
-	var newLines []string
-	currentMsgid = ""
-	inMsgid = false
-	inMsgstr = false
-	skipNextMsgstr := false
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmed, "msgid ") {
-			currentMsgid = extractString(trimmed[6:])
-			inMsgid = true
-			inMsgstr = false
-			newLines = append(newLines, line)
-
-			// Check if we have a translation for this msgid
-			if _, exists := translations[currentMsgid]; exists {
-				skipNextMsgstr = true
-				// Look ahead for msgstr line
-				for j := i + 1; j < len(lines); j++ {
-					nextTrimmed := strings.TrimSpace(lines[j])
-					if strings.HasPrefix(nextTrimmed, "msgstr ") {
-						// Found msgstr, we'll replace it
-						break
-					} else if strings.HasPrefix(nextTrimmed, "\"") && inMsgid {
-						// Continuation of msgid
-						currentMsgid += extractString(nextTrimmed)
-						newLines = append(newLines, lines[j])
-						i = j
-					} else if nextTrimmed != "" && !strings.HasPrefix(nextTrimmed, "#") {
-						break
-					} else {
-						newLines = append(newLines, lines[j])
-						i = j
-					}
-				}
-			}
-		} else if strings.HasPrefix(trimmed, "msgstr ") {
-			inMsgid = false
-			inMsgstr = true
-
-			if skipNextMsgstr {
-				// Replace with translation
-				translation := translations[currentMsgid]
-				if strings.Contains(translation, "\n") {
-					// Multi-line translation
-					newLines = append(newLines, "msgstr \"\"")
-					parts := strings.Split(translation, "\n")
-					for idx, part := range parts {
-						if idx < len(parts)-1 {
-							newLines = append(newLines, fmt.Sprintf("\"%s\\n\"", escapeString(part)))
-						} else if part != "" {
-							newLines = append(newLines, fmt.Sprintf("\"%s\"", escapeString(part)))
-						}
-					}
-				} else {
-					newLines = append(newLines, fmt.Sprintf("msgstr \"%s\"", escapeString(translation)))
-				}
-				skipNextMsgstr = false
-			} else {
-				newLines = append(newLines, line)
-			}
-		} else if strings.HasPrefix(trimmed, "\"") && inMsgid {
-			currentMsgid += extractString(trimmed)
-			newLines = append(newLines, line)
-		} else {
-			if !(skipNextMsgstr && strings.HasPrefix(trimmed, "\"") && i > 0 && strings.Contains(lines[i-1], "msgstr")) {
-				newLines = append(newLines, line)
-			}
-			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-				inMsgid = false
-				inMsgstr = false
-			}
-		}
+	if statsMode {
+		showStats(directories, directory)
+		return
 	}
 
-	// Write updated content back to file
-	newContent := strings.Join(newLines, "\n")
-	err = os.WriteFile(poFile, []byte(newContent), 0644)
-	if err != nil {
-		return 0, err
+	if validate {
+		validatePoFiles(directories, directory)
+		return
 	}
 
-	return translatedCount, nil
-}
+	if exportPath != "" {
+		exportMissing(directories, directory)
+		return
+	}
 
-// rewritePoFile completely rewrites a PO file based on the POT file structure,
-// maintaining existing translations but removing obsolete entries and their comments.
-func rewritePoFile(poFile string, potEntries map[string]POEntry, sourceLang, targetLang string, delay time.Duration) (int, error) {
-	// Read existing PO file to get current translations
-	existingTranslations := make(map[string]string)
+	// Process each directory and domain independently
+	var total catalog.TranslationResult
+	domainTotals := make(map[string]*catalog.TranslationResult)
+	var domainOrder []string
 
-	content, err := os.ReadFile(poFile)
-	if err != nil {
-		return 0, err
-	}
-
-	lines := strings.Split(string(content), "\n")
-	var currentMsgid, currentMsgstr string
-	var inMsgid, inMsgstr bool
-	var headerLines []string
-	inHeader := true
-
-	// Extract existing translations and header
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmed, "msgid ") {
-			if trimmed == "msgid \"\"" && inHeader {
-				// This is the header entry, continue collecting it
-				currentMsgid = ""
-				inMsgid = true
-				inMsgstr = false
-			} else {
-				inHeader = false
-				currentMsgid = extractString(trimmed[6:])
-				currentMsgstr = ""
-				inMsgid = true
-				inMsgstr = false
-			}
-		} else if strings.HasPrefix(trimmed, "msgstr ") {
-			if inHeader && currentMsgid == "" {
-				// Still in header
-				inMsgid = false
-				inMsgstr = true
-			} else {
-				currentMsgstr = extractString(trimmed[7:])
-				inMsgid = false
-				inMsgstr = true
-			}
-		} else if strings.HasPrefix(trimmed, "\"") {
-			if inMsgid && !inHeader {
-				currentMsgid += extractString(trimmed)
-			} else if inMsgstr && !inHeader {
-				currentMsgstr += extractString(trimmed)
-			}
-		} else if trimmed == "" {
-			if inHeader && currentMsgid == "" {
-				// End of header
-				inHeader = false
-			}
-			if !inHeader && currentMsgid != "" {
-				// Save the translation
-				existingTranslations[currentMsgid] = currentMsgstr
-				currentMsgid = ""
-				currentMsgstr = ""
-			}
-			inMsgid = false
-			inMsgstr = false
-		} else if strings.HasPrefix(trimmed, "#") {
-			if inHeader {
-				headerLines = append(headerLines, line)
-			}
-		}
-
-		// Collect header lines
-		if inHeader {
-			if currentMsgid == "" || trimmed == "" || strings.HasPrefix(trimmed, "msgid") || strings.HasPrefix(trimmed, "msgstr") || strings.HasPrefix(trimmed, "\"") {
-				if !strings.HasPrefix(trimmed, "#") || len(headerLines) == 0 || line != headerLines[len(headerLines)-1] {
-					if line != "" || len(headerLines) == 0 || headerLines[len(headerLines)-1] != "" {
-						headerLines = append(headerLines, line)
-					}
-				}
-			}
+	if file != "" {
+		result, err := processFile(file, translator, report)
+		total.Add(result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(exitError)
 		}
+		directories = nil
 	}
 
-	// Save last translation if exists
-	if currentMsgid != "" && !inHeader {
-		existingTranslations[currentMsgid] = currentMsgstr
-	}
-
-	// Count entries that need translation
-	var needsTranslation []string
-	for msgid := range potEntries {
-		if msgid == "" {
+	for _, dir := range directories {
+		domains, err := processor.SelectDomains(dir, domain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if !recursive {
+				exit(exitError)
+			}
 			continue
 		}
-		existingTrans, hasTranslation := existingTranslations[msgid]
-		if !hasTranslation || existingTrans == "" {
-			needsTranslation = append(needsTranslation, msgid)
-		}
-	}
 
-	// Translate missing entries
-	translations := make(map[string]string)
-	translatedCount := 0
-
-	if len(needsTranslation) > 0 {
-		// Create progress bar
-		bar := progressbar.NewOptions(len(needsTranslation),
-			progressbar.OptionEnableColorCodes(true),
-			progressbar.OptionShowCount(),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionSetDescription(fmt.Sprintf("[cyan]%s[reset]", filepath.Base(poFile))),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "[green]=[reset]",
-				SaucerHead:    "[green]>[reset]",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}))
-
-		// Translate each missing string
-		for _, msgid := range needsTranslation {
-			if interrupted {
+		for _, name := range domains {
+			if processor.Interrupted() {
 				break
 			}
 
-			translated, err := gtranslate.TranslateWithParams(
-				msgid,
-				gtranslate.TranslationParams{
-					From: sourceLang,
-					To:   targetLang,
-				},
-			)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\nWarning: Translation failed for '%s': %v\n", msgid, err)
-				bar.Add(1)
-				continue
+			result, err := processor.ProcessDirectory(dir, directory, name, delay, translator, report)
+			total.Add(result)
+			if _, exists := domainTotals[name]; !exists {
+				domainTotals[name] = &catalog.TranslationResult{}
+				domainOrder = append(domainOrder, name)
 			}
-
-			translations[msgid] = translated
-			translatedCount++
-			bar.Add(1)
-
-			// Rate limiting
-			if !interrupted && translatedCount < len(needsTranslation) {
-				time.Sleep(delay)
+			domainTotals[name].Add(result)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				if !recursive {
+					exit(exitError)
+				}
 			}
 		}
-
-		fmt.Println() // New line after progress bar
 	}
 
-	// Build new PO file from POT structure
-	var newLines []string
+	processor.SaveCache()
 
-	// Add header
-	newLines = append(newLines, headerLines...)
-	if len(headerLines) > 0 && headerLines[len(headerLines)-1] != "" {
-		newLines = append(newLines, "")
+	if reportFmt == "json" {
+		report.Interrupted = processor.Interrupted()
+		emitReport(report)
 	}
 
-	// Add all entries from POT in order
-	for msgid, potEntry := range potEntries {
-		if msgid == "" {
-			continue
+	if len(domainOrder) > 1 {
+		for _, name := range domainOrder {
+			fmt.Fprintf(options.Output, "Domain %s: %s\n", name, domainTotals[name].Summary())
 		}
+	}
 
-		newLines = append(newLines, "")
+	if processor.Interrupted() {
+		fmt.Fprintf(options.Output, "\nPartially completed: %d translation(s) saved%s\n", total.Translated, total.Details())
+	} else {
+		fmt.Fprintf(options.Output, "Complete! Translated %d string(s) total%s\n", total.Translated, total.Details())
+	}
+	if processor.LimitHit() {
+		fmt.Fprintf(options.Output, "Limit of %d translation(s) reached, %d string(s) left for the next run\n", options.Limit, total.Skipped)
+	}
+	if timing {
+		fmt.Fprintln(options.Output, total.Timing(time.Since(start)))
+	}
+	missing := 0
+	if failOnMiss {
+		missing = reportMissing(os.Stderr, report)
+	}
+	if code := exitCode(total, processor.Interrupted(), missing); code != exitOK {
+		removePotCopy()
+		os.Exit(code)
+	}
+}
 
-		// Add comments from POT (without translator comments from old PO)
-		for _, comment := range potEntry.Comments {
-			newLines = append(newLines, comment)
-		}
+// humanOutput returns where the human readable progress and summaries go:
+// nowhere with --quiet, and stderr when stdout has the JSON report or the PO
+// file translated from stdin.
+func humanOutput(file string) io.Writer {
+	if quiet {
+		return io.Discard
+	}
+	if reportFmt == "json" || file == "-" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
 
-		// Add msgid
-		if strings.Contains(msgid, "\n") {
-			// Multi-line msgid
-			newLines = append(newLines, "msgid \"\"")
-			parts := strings.Split(msgid, "\n")
-			for idx, part := range parts {
-				if idx < len(parts)-1 {
-					newLines = append(newLines, fmt.Sprintf("\"%s\\n\"", escapeString(part)))
-				} else if part != "" {
-					newLines = append(newLines, fmt.Sprintf("\"%s\"", escapeString(part)))
-				}
-			}
-		} else {
-			newLines = append(newLines, fmt.Sprintf("msgid \"%s\"", escapeString(msgid)))
-		}
+// processFile translates the single PO file, or for - the PO file read from
+// stdin, which is then written to stdout.
+func processFile(file string, translator catalog.Translator, report *catalog.Report) (catalog.TranslationResult, error) {
+	if file != "-" {
+		return processor.ProcessFile(file, options.Pot, delay, translator, report)
+	}
 
-		// Add msgstr (from existing translation, new translation, or empty)
-		var msgstr string
-		if trans, exists := translations[msgid]; exists {
-			msgstr = trans
-		} else if existingTrans, exists := existingTranslations[msgid]; exists {
-			msgstr = existingTrans
-		}
+	temp, err := os.CreateTemp("", "potranslate-*.po")
+	if err != nil {
+		return catalog.TranslationResult{}, err
+	}
+	defer os.Remove(temp.Name())
+	_, err = io.Copy(temp, os.Stdin)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return catalog.TranslationResult{}, fmt.Errorf("reading stdin: %v", err)
+	}
 
-		if strings.Contains(msgstr, "\n") {
-			// Multi-line msgstr
-			newLines = append(newLines, "msgstr \"\"")
-			parts := strings.Split(msgstr, "\n")
-			for idx, part := range parts {
-				if idx < len(parts)-1 {
-					newLines = append(newLines, fmt.Sprintf("\"%s\\n\"", escapeString(part)))
-				} else if part != "" {
-					newLines = append(newLines, fmt.Sprintf("\"%s\"", escapeString(part)))
-				}
-			}
-		} else {
-			newLines = append(newLines, fmt.Sprintf("msgstr \"%s\"", escapeString(msgstr)))
-		}
+	result, err := processor.ProcessFile(temp.Name(), options.Pot, delay, translator, report)
+	if err != nil {
+		return result, err
+	}
+	content, err := os.ReadFile(temp.Name())
+	if err != nil {
+		return result, err
 	}
+	_, err = os.Stdout.Write(content)
+	return result, err
+}
+
+// patternFlags collects the regular expressions of a repeatable flag,
+// compiling each of them once when it is parsed.
+type patternFlags []*regexp.Regexp
 
-	// Write the new PO file
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(poFile, []byte(newContent), 0644); err != nil {
-		return 0, fmt.Errorf("failed to write rewritten PO file: %v", err)
+func (p *patternFlags) String() string {
+	if p == nil {
+		return ""
 	}
+	var patterns []string
+	for _, pattern := range *p {
+		patterns = append(patterns, pattern.String())
+	}
+	return strings.Join(patterns, " ")
+}
 
-	// Count removed entries
-	removedCount := 0
-	for msgid := range existingTranslations {
-		if _, exists := potEntries[msgid]; !exists && msgid != "" {
-			removedCount++
-		}
+func (p *patternFlags) Set(value string) error {
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		return err
 	}
+	*p = append(*p, pattern)
+	return nil
+}
+
+// headerFlags collects the "Name: value" headers of a repeatable flag.
+type headerFlags []string
 
-	if removedCount > 0 {
-		fmt.Printf("Removed %d obsolete entry/entries\n", removedCount)
+func (h *headerFlags) String() string {
+	if h == nil {
+		return ""
 	}
+	return strings.Join(*h, ", ")
+}
 
-	return translatedCount, nil
+func (h *headerFlags) Set(value string) error {
+	if name, _, found := strings.Cut(value, ":"); !found || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("use 'Name: value'")
+	}
+	*h = append(*h, value)
+	return nil
 }
 
-func updatePotLanguage(potFile, language string) error {
-	content, err := os.ReadFile(potFile)
+// delayFlags collects the "language=duration" delays of a repeatable flag,
+// keyed by the normalized language.
+type delayFlags map[string]time.Duration
+
+func (d *delayFlags) String() string {
+	if d == nil {
+		return ""
+	}
+	var delays []string
+	for language, delay := range *d {
+		delays = append(delays, language+"="+delay.String())
+	}
+	slices.Sort(delays)
+	return strings.Join(delays, ",")
+}
+
+func (d *delayFlags) Set(value string) error {
+	language, duration, found := strings.Cut(value, "=")
+	if !found || strings.TrimSpace(language) == "" {
+		return fmt.Errorf("use 'language=duration'")
+	}
+	delay, err := time.ParseDuration(strings.TrimSpace(duration))
 	if err != nil {
 		return err
 	}
+	if delay < 0 {
+		return fmt.Errorf("the delay can't be negative")
+	}
+	if *d == nil {
+		*d = make(delayFlags)
+	}
+	(*d)[catalog.NormalizeLocale(strings.TrimSpace(language))] = delay
+	return nil
+}
 
-	lines := strings.Split(string(content), "\n")
-	updated := false
+// delaySet reports whether --delay was given, on the command line or in the
+// config file.
+func delaySet() bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == "delay" })
+	return set
+}
 
-	for i, line := range lines {
-		if strings.Contains(line, "\"Language:") {
-			// Update existing Language header
-			lines[i] = fmt.Sprintf("\"Language: %s\\n\"", language)
-			updated = true
-			break
-		}
+// exitCode returns the exit code for the outcome of a run, with the number
+// of translations still missing for --fail-on-missing.
+func exitCode(result catalog.TranslationResult, interrupted bool, missing int) int {
+	switch {
+	case interrupted:
+		return exitInterrupted
+	case result.Failed > 0 && result.Translated == 0:
+		return exitUnreachable
+	case result.Failed > 0:
+		return exitFailed
+	case missing > 0:
+		return exitMissing
+	default:
+		return exitOK
 	}
+}
 
-	if !updated {
-		// Add Language header after Content-Type if not found
-		for i, line := range lines {
-			if strings.Contains(line, "\"Content-Type:") {
-				// Insert after Content-Type line
-				newLines := make([]string, 0, len(lines)+1)
-				newLines = append(newLines, lines[:i+1]...)
-				newLines = append(newLines, fmt.Sprintf("\"Language: %s\\n\"", language))
-				newLines = append(newLines, lines[i+1:]...)
-				lines = newLines
-				updated = true
-				break
-			}
+// Exit codes, documented in printHelp.
+const (
+	exitOK          = 0
+	exitError       = 1   // Invalid options or a directory that can't be processed
+	exitFailed      = 2   // Some translations failed, the files were written
+	exitUnreachable = 3   // Every translation failed, the backend can't be reached
+	exitMissing     = 4   // Translations are missing after the run, for --fail-on-missing
+	exitInvalid     = 5   // Problems were found in the PO files, for --validate
+	exitInterrupted = 130 // Standard exit code for SIGINT
+)
+
+// reportMissing lists the PO files of the report that still have entries
+// without a translation, and returns the number of missing translations.
+// Fuzzy entries have a translation, and obsolete entries aren't counted.
+func reportMissing(w io.Writer, report *catalog.Report) int {
+	missing := 0
+	for _, file := range report.Files {
+		if file.Missing > 0 {
+			fmt.Fprintf(w, "Error: %d translation(s) missing in %s\n", file.Missing, file.File)
+			missing += file.Missing
 		}
 	}
+	return missing
+}
 
-	if !updated {
-		return fmt.Errorf("could not find appropriate place to insert Language header")
+// emitReport writes the JSON report to stdout.
+func emitReport(report *catalog.Report) {
+	if err := catalog.WriteReport(os.Stdout, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(exitError)
 	}
+}
 
-	newContent := strings.Join(lines, "\n")
-	return os.WriteFile(potFile, []byte(newContent), 0644)
+// exit flushes the translation cache before exiting with the code, so that
+// the translations done before an error aren't lost.
+func exit(code int) {
+	if processor != nil {
+		processor.SaveCache()
+	}
+	removePotCopy()
+	os.Exit(code)
 }
 
-// copyPotToPo creates a new PO file from the POT template with the specified language
-func copyPotToPo(potFile, newPoFile, targetLang string) error {
-	// Read POT file
-	content, err := os.ReadFile(potFile)
-	if err != nil {
-		return fmt.Errorf("failed to read POT file: %v", err)
+// removePotCopy removes the downloaded copy of a --pot URL.
+func removePotCopy() {
+	if potCopy != "" {
+		os.RemoveAll(potCopy)
 	}
+}
 
-	lines := strings.Split(string(content), "\n")
-	var newLines []string
-	inHeader := true
+func setupSignalHandler() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// Process each line
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	go func() {
+		<-sigChan
+		processor.Interrupt() // Also aborts the translation requests in flight
+	}()
+}
 
-		// Update Language header in the metadata section
-		if inHeader && strings.Contains(line, "\"Language:") {
-			newLines = append(newLines, fmt.Sprintf("\"Language: %s\\n\"", targetLang))
+func printHelp() {
+	fmt.Println("potranslate - Translate missing strings in PO files in a given directory")
+	fmt.Printf("\nUsage: potranslate [options] <directory>\n")
+	fmt.Printf("       potranslate [options] --pot <file.pot> <file.po|->\n\n")
+	fmt.Println("Options:")
+	flag.PrintDefaults()
+	fmt.Println("\nExamples:")
+	fmt.Println("  potranslate ./locales")
+	fmt.Println("  potranslate --fast ./locales")
+	fmt.Println("  potranslate --source-lang en ./locales")
+	fmt.Println("  potranslate --source-lang de --force-source ./locales")
+	fmt.Println("  potranslate --detect-source ./locales")
+	fmt.Println("  potranslate --domain admin ./locales")
+	fmt.Println("  potranslate --domain all ./locales")
+	fmt.Println("  potranslate --pot ./locales/template.pot --domain messages ./locales")
+	fmt.Println("  potranslate --pot ./locales/messages.pot ./locales/messages_es.po")
+	fmt.Println("  potranslate --pot https://example.com/messages.pot --pot-header 'Authorization: Bearer <token>' --domain messages ./locales")
+	fmt.Println("  potranslate --pot messages.pot - < messages_es.po > translated_es.po")
+	fmt.Println("  potranslate --only-lang es,fr ./locales")
+	fmt.Println("  potranslate --stats ./locales")
+	fmt.Println("  potranslate --validate ./locales")
+	fmt.Println("  potranslate --only-lang es --export-missing todo_es.po ./locales")
+	fmt.Println("  potranslate --diff ./locales")
+	fmt.Println("  potranslate --only-lang es --import done_es.csv ./locales")
+	fmt.Println("  potranslate --no-network ./locales")
+	fmt.Println("  potranslate --pseudo accent,bracket --out-dir ./pseudo ./locales")
+	fmt.Println("  potranslate --fail-on-missing ./locales")
+	fmt.Println("  potranslate --rewrite ./locales")
+	fmt.Println("  potranslate --fast --source-lang en --domain admin ./locales")
+	fmt.Println("  potranslate --rps 5 --concurrency 4 ./locales")
+	fmt.Println("  potranslate --lang-delay ja=2s --lang-delay zh=2s ./locales")
+	fmt.Println("  potranslate --timing --concurrency 4 ./locales")
+	fmt.Println("  potranslate --rewrite --fast ./locales")
+	fmt.Println("  potranslate --mark-fuzzy ./locales")
+	fmt.Println("  potranslate --max-length 30 --max-length-fuzzy ./locales")
+	fmt.Println("  potranslate --filter-ref 'templates/email.html:*' ./locales")
+	fmt.Println("  potranslate --since 2026-01-15 ./locales")
+	fmt.Println("  potranslate --force-retranslate --backend libretranslate --endpoint http://localhost:5000 ./locales")
+	fmt.Println("  potranslate --interactive --only-lang de ./locales")
+	fmt.Println("  potranslate --backup --backup-suffix .{timestamp}.bak ./locales")
+	fmt.Println("  potranslate --out-dir ./staging ./locales")
+	fmt.Println("  potranslate --placeholder-style python ./locales")
+	fmt.Println("  potranslate --placeholder-style c ./locales")
+	fmt.Println("  potranslate --reject-format-mismatch ./locales")
+	fmt.Println("  potranslate --ignore-pattern '^https?://' --ignore-pattern '^\\{\\{.*\\}\\}$' ./locales")
+	fmt.Println("  potranslate --add-lang de ./locales")
+	fmt.Println("  potranslate --add-lang es,fr,de ./locales")
+	fmt.Println("  potranslate --add-lang ja --fast ./locales")
+	fmt.Println("  potranslate --add-lang pt_BR ./locales")
+	fmt.Println("  potranslate --add-lang pt_BR --seed-from pt ./locales")
+	fmt.Println("  potranslate --add-lang pt_BR --merge-from old/default_pt_BR.po ./locales")
+	fmt.Println("  potranslate --layout gnu ./locales")
+	fmt.Println("  potranslate --naming dot --domain messages ./translations")
+	fmt.Println("  potranslate --recursive --exclude vendor,node_modules .")
+	fmt.Println("  potranslate --concurrency 4 ./locales")
+	fmt.Println("  potranslate --limit 500 ./locales")
+	fmt.Println("  potranslate --width 100 ./locales")
+	fmt.Println("  potranslate --canonical ./locales")
+	fmt.Println("  potranslate --compact ./locales")
+	fmt.Println("  potranslate --to-utf8 ./locales")
+	fmt.Println("  potranslate --report json ./locales > report.json")
+	fmt.Println("  potranslate --progress plain ./locales > translate.log")
+	fmt.Println("  potranslate --quiet --report json ./locales > report.json")
+	fmt.Println("  potranslate --backend libretranslate --endpoint http://localhost:5000 ./locales")
+	fmt.Println("  potranslate --backend libretranslate --endpoint http://localhost:5000 --fallback google ./locales")
+	fmt.Println("  potranslate --list-languages --backend libretranslate --endpoint http://localhost:5000")
+	fmt.Println("\nExit codes:")
+	fmt.Println("  0    All strings translated")
+	fmt.Println("  1    Invalid options or a directory that can't be processed")
+	fmt.Println("  2    Some translations failed, the PO files were written")
+	fmt.Println("  3    Every translation failed, the backend can't be reached")
+	fmt.Println("  4    Translations are still missing, with --fail-on-missing")
+	fmt.Println("  5    Problems were found in the PO files, with --validate")
+	fmt.Println("  130  Interrupted, the translations done so far were saved")
+}
+
+// validatePoFiles lists the problems in the PO files of the selected domains
+// in the directories to stdout, for --validate, exiting with exitInvalid when
+// there are any.
+func validatePoFiles(directories []string, root string) {
+	var problems []string
+	for _, dir := range directories {
+		domains, err := processor.SelectDomains(dir, domain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if !recursive {
+				os.Exit(exitError)
+			}
 			continue
 		}
+		for _, name := range domains {
+			found, err := processor.ValidateDirectory(dir, root, name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				if !recursive {
+					os.Exit(exitError)
+				}
+				continue
+			}
+			problems = append(problems, found...)
+		}
+	}
 
-		// Update Language-Team header if present
-		if inHeader && strings.Contains(line, "\"Language-Team:") {
-			newLines = append(newLines, fmt.Sprintf("\"Language-Team: %s\\n\"", strings.ToUpper(targetLang)))
-			continue
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: %d problem(s) found in the PO files\n", len(problems))
+		os.Exit(exitInvalid)
+	}
+	fmt.Fprintf(options.Output, "No problems found in the PO files\n")
+}
+
+// exportMissing writes the untranslated entries of the single selected PO
+// file to the --export-missing file.
+func exportMissing(directories []string, root string) {
+	type source struct{ dir, domain string }
+	var sources []source
+	for _, dir := range directories {
+		domains, err := processor.SelectDomains(dir, domain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		for _, name := range domains {
+			sources = append(sources, source{dir, name})
 		}
+	}
+	if len(sources) != 1 {
+		fmt.Fprintf(os.Stderr, "Error: --export-missing needs a single domain in a single directory, found %d\n", len(sources))
+		os.Exit(exitError)
+	}
 
-		// Update PO-Revision-Date with current timestamp
-		if inHeader && strings.Contains(line, "\"PO-Revision-Date:") {
-			currentTime := time.Now().Format("2006-01-02 15:04-0700")
-			newLines = append(newLines, fmt.Sprintf("\"PO-Revision-Date: %s\\n\"", currentTime))
+	count, err := processor.ExportMissing(exportPath, sources[0].dir, sources[0].domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	fmt.Fprintf(options.Output, "Exported %d untranslated entry/entries to %s\n", count, exportPath)
+}
+
+// showStats writes the stats table of the selected domains in the
+// directories to stdout, for --stats.
+func showStats(directories []string, root string) {
+	var files []catalog.FileStats
+	for _, dir := range directories {
+		domains, err := processor.SelectDomains(dir, domain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if !recursive {
+				os.Exit(exitError)
+			}
 			continue
 		}
-
-		// Check if we're leaving the header section
-		if inHeader && i > 0 && trimmed == "" && i+1 < len(lines) {
-			nextTrimmed := strings.TrimSpace(lines[i+1])
-			if !strings.HasPrefix(nextTrimmed, "\"") && nextTrimmed != "" {
-				inHeader = false
+		for _, name := range domains {
+			stats, err := processor.DirectoryStats(dir, root, name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				if !recursive {
+					os.Exit(exitError)
+				}
+				continue
 			}
+			files = append(files, stats...)
 		}
-
-		// Add the line as-is
-		newLines = append(newLines, line)
 	}
 
-	// Write to new PO file
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(newPoFile, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write PO file: %v", err)
+	if err := catalog.WriteStats(os.Stdout, files); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing stats: %v\n", err)
+		os.Exit(exitError)
 	}
-
-	return nil
 }
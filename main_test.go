@@ -1,733 +1,162 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
-)
-
-func TestExtractString(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "simple quoted string",
-			input:    `"Hello, World!"`,
-			expected: "Hello, World!",
-		},
-		{
-			name:     "string with newline escape",
-			input:    `"Hello\nWorld"`,
-			expected: "Hello\nWorld",
-		},
-		{
-			name:     "string with tab escape",
-			input:    `"Hello\tWorld"`,
-			expected: "Hello\tWorld",
-		},
-		{
-			name:     "string with escaped quote",
-			input:    `"Hello \"World\""`,
-			expected: `Hello "World"`,
-		},
-		{
-			name:     "unquoted string",
-			input:    "Hello",
-			expected: "Hello",
-		},
-		{
-			name:     "empty string",
-			input:    `""`,
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractString(tt.input)
-			if result != tt.expected {
-				t.Errorf("extractString(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestEscapeString(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "simple string",
-			input:    "Hello, World!",
-			expected: "Hello, World!",
-		},
-		{
-			name:     "string with newline",
-			input:    "Hello\nWorld",
-			expected: "Hello\\nWorld",
-		},
-		{
-			name:     "string with tab",
-			input:    "Hello\tWorld",
-			expected: "Hello\\tWorld",
-		},
-		{
-			name:     "string with quote",
-			input:    `Hello "World"`,
-			expected: `Hello \"World\"`,
-		},
-		{
-			name:     "string with backslash",
-			input:    `C:\path\to\file`,
-			expected: `C:\\path\\to\\file`,
-		},
-		{
-			name:     "empty string",
-			input:    "",
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := escapeString(tt.input)
-			if result != tt.expected {
-				t.Errorf("escapeString(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestParsePotFile(t *testing.T) {
-	tempDir := t.TempDir()
-	potFile := filepath.Join(tempDir, "test.pot")
-
-	content := `# Test POT file
-msgid ""
-msgstr ""
-"Project-Id-Version: Test 1.0\n"
-"Language: en\n"
-"Content-Type: text/plain; charset=UTF-8\n"
-
-#: test.py:10
-msgid "Hello, World!"
-msgstr ""
-
-#: test.py:15
-msgid "Goodbye"
-msgstr ""
-
-#: test.py:20
-msgid "Multi-line"
-" string"
-msgstr ""
-`
-
-	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
-		t.Fatalf("Failed to create test POT file: %v", err)
-	}
-
-	entries, sourceLang, err := parsePotFile(potFile)
-	if err != nil {
-		t.Fatalf("parsePotFile() error = %v", err)
-	}
-
-	if sourceLang != "en" {
-		t.Errorf("Expected source language 'en', got %q", sourceLang)
-	}
-
-	expectedEntries := map[string]struct{}{
-		"Hello, World!":     {},
-		"Goodbye":           {},
-		"Multi-line string": {},
-	}
-
-	if len(entries) != len(expectedEntries) {
-		t.Errorf("Expected %d entries, got %d", len(expectedEntries), len(entries))
-	}
-
-	for msgid := range expectedEntries {
-		if _, exists := entries[msgid]; !exists {
-			t.Errorf("Expected entry %q not found in parsed entries", msgid)
-		}
-	}
-}
-
-func TestParsePotFileNoLanguage(t *testing.T) {
-	tempDir := t.TempDir()
-	potFile := filepath.Join(tempDir, "test.pot")
-
-	content := `# Test POT file
-msgid ""
-msgstr ""
-"Project-Id-Version: Test 1.0\n"
-"Content-Type: text/plain; charset=UTF-8\n"
 
-msgid "Hello"
-msgstr ""
-`
-
-	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
-		t.Fatalf("Failed to create test POT file: %v", err)
-	}
+	"github.com/mevdschee/potranslate/pkg/catalog"
+)
 
-	_, sourceLang, err := parsePotFile(potFile)
-	if err != nil {
-		t.Fatalf("parsePotFile() error = %v", err)
-	}
+// unreachableTranslator fails every translation, like a backend that can't be
+// reached.
+type unreachableTranslator struct{}
 
-	if sourceLang != "" {
-		t.Errorf("Expected empty source language, got %q", sourceLang)
-	}
+func (unreachableTranslator) Translate(text, from, to string) (string, error) {
+	return "", fmt.Errorf("could not connect")
 }
 
-func TestGetTargetLanguage(t *testing.T) {
-	tempDir := t.TempDir()
-
-	tests := []struct {
-		name         string
-		filename     string
-		content      string
-		expectedLang string
-		expectError  bool
-	}{
-		{
-			name:     "language from metadata",
-			filename: "test_es.po",
-			content: `msgid ""
-msgstr ""
-"Language: es\n"
-
-msgid "Hello"
-msgstr ""
-`,
-			expectedLang: "es",
-			expectError:  false,
-		},
-		{
-			name:     "language from filename with underscore",
-			filename: "default_de.po",
-			content: `msgid ""
-msgstr ""
-
-msgid "Hello"
-msgstr ""
-`,
-			expectedLang: "de",
-			expectError:  false,
-		},
-		{
-			name:     "no language detectable",
-			filename: "test.po",
-			content: `msgid ""
-msgstr ""
-
-msgid "Hello"
-msgstr ""
-`,
-			expectedLang: "",
-			expectError:  true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			poFile := filepath.Join(tempDir, tt.filename)
-			if err := os.WriteFile(poFile, []byte(tt.content), 0644); err != nil {
-				t.Fatalf("Failed to create test PO file: %v", err)
-			}
-
-			lang, err := getTargetLanguage(poFile)
-			if tt.expectError {
-				if err == nil {
-					t.Error("Expected error, got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("Unexpected error: %v", err)
-				}
-				if lang != tt.expectedLang {
-					t.Errorf("Expected language %q, got %q", tt.expectedLang, lang)
-				}
-			}
-		})
-	}
+// brokenTranslator fails the translation of a single text.
+type brokenTranslator struct {
+	broken string
 }
 
-func TestFindPoFiles(t *testing.T) {
-	tempDir := t.TempDir()
-
-	testFiles := []string{
-		"default_es.po",
-		"default_fr.po",
-		"default_de.po",
-		"admin_es.po",
-		"other.po",
-		"default.pot",
-	}
-
-	for _, filename := range testFiles {
-		path := filepath.Join(tempDir, filename)
-		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
-			t.Fatalf("Failed to create test file: %v", err)
-		}
-	}
-
-	tests := []struct {
-		name          string
-		domain        string
-		expectedCount int
-		expectedFiles []string
-	}{
-		{
-			name:          "default domain",
-			domain:        "default",
-			expectedCount: 3,
-			expectedFiles: []string{"default_es.po", "default_fr.po", "default_de.po"},
-		},
-		{
-			name:          "admin domain",
-			domain:        "admin",
-			expectedCount: 1,
-			expectedFiles: []string{"admin_es.po"},
-		},
-		{
-			name:          "nonexistent domain",
-			domain:        "nonexistent",
-			expectedCount: 0,
-			expectedFiles: []string{},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			files, err := findPoFiles(tempDir, tt.domain)
-			if err != nil {
-				t.Fatalf("findPoFiles() error = %v", err)
-			}
-
-			if len(files) != tt.expectedCount {
-				t.Errorf("Expected %d files, got %d", tt.expectedCount, len(files))
-			}
-
-			for _, expectedFile := range tt.expectedFiles {
-				found := false
-				for _, file := range files {
-					if filepath.Base(file) == expectedFile {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("Expected file %q not found in results", expectedFile)
-				}
-			}
-		})
+func (b brokenTranslator) Translate(text, from, to string) (string, error) {
+	if text == b.broken {
+		return "", fmt.Errorf("could not translate")
 	}
+	return to + ":" + text, nil
 }
 
-func TestUpdatePotLanguage(t *testing.T) {
+func TestExitCode(t *testing.T) {
 	tests := []struct {
 		name        string
-		content     string
-		language    string
-		expectError bool
+		result      catalog.TranslationResult
+		interrupted bool
+		expected    int
 	}{
-		{
-			name: "update existing language",
-			content: `msgid ""
-msgstr ""
-"Language: \n"
-"Content-Type: text/plain; charset=UTF-8\n"
-`,
-			language:    "en",
-			expectError: false,
-		},
-		{
-			name: "add language after Content-Type",
-			content: `msgid ""
-msgstr ""
-"Content-Type: text/plain; charset=UTF-8\n"
-`,
-			language:    "en",
-			expectError: false,
-		},
+		{"all good", catalog.TranslationResult{Translated: 3, Added: 1}, false, exitOK},
+		{"nothing to do", catalog.TranslationResult{}, false, exitOK},
+		{"some failed", catalog.TranslationResult{Translated: 2, Failed: 1}, false, exitFailed},
+		{"all failed", catalog.TranslationResult{Failed: 3}, false, exitUnreachable},
+		{"interrupted", catalog.TranslationResult{Translated: 1, Failed: 1, Skipped: 2}, true, exitInterrupted},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tempDir := t.TempDir()
-			potFile := filepath.Join(tempDir, "test.pot")
-
-			if err := os.WriteFile(potFile, []byte(tt.content), 0644); err != nil {
-				t.Fatalf("Failed to create test POT file: %v", err)
-			}
-
-			err := updatePotLanguage(potFile, tt.language)
-			if tt.expectError {
-				if err == nil {
-					t.Error("Expected error, got nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("Unexpected error: %v", err)
-				}
-
-				_, sourceLang, err := parsePotFile(potFile)
-				if err != nil {
-					t.Fatalf("Failed to parse updated POT file: %v", err)
-				}
-				if sourceLang != tt.language {
-					t.Errorf("Expected language %q, got %q", tt.language, sourceLang)
-				}
-			}
-		})
+		if code := exitCode(tt.result, tt.interrupted, 0); code != tt.expected {
+			t.Errorf("%s: exitCode() = %d, want %d", tt.name, code, tt.expected)
+		}
 	}
-}
 
-func TestAddMissingEntriesToPO(t *testing.T) {
+	// The result of a file with an unreachable backend
 	tempDir := t.TempDir()
-
-	// Create a POT file with multiple entries
 	potFile := filepath.Join(tempDir, "test.pot")
-	potContent := `msgid ""
-msgstr ""
-"Language: en\n"
-
-msgid "Hello"
-msgstr ""
-
-msgid "World"
-msgstr ""
-
-msgid "Goodbye"
-msgstr ""
-`
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"World\"\nmsgstr \"\"\n"
 	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
 		t.Fatalf("Failed to create POT file: %v", err)
 	}
-
-	// Create a PO file with only some entries
-	poFile := filepath.Join(tempDir, "test-es.po")
-	poContent := `msgid ""
-msgstr ""
-"Language: es\n"
-
-msgid "Hello"
-msgstr "Hola"
-`
-	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
 		t.Fatalf("Failed to create PO file: %v", err)
 	}
-
-	// Parse POT file
-	potEntries, _, err := parsePotFile(potFile)
+	processor := catalog.New(catalog.DefaultOptions())
+	potEntries, _, err := processor.ParsePotFile(potFile)
 	if err != nil {
 		t.Fatalf("Failed to parse POT file: %v", err)
 	}
-
-	// Call translatePoFile (which should add missing entries)
-	// We use a very short delay and will interrupt to avoid actual translation
-	_, err = translatePoFile(poFile, potEntries, "en", "es", 0)
+	translator := unreachableTranslator{}
+	result, err := processor.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
 	if err != nil {
-		t.Fatalf("translatePoFile failed: %v", err)
-	}
-
-	// Read the updated PO file
-	updatedContent, err := os.ReadFile(poFile)
-	if err != nil {
-		t.Fatalf("Failed to read updated PO file: %v", err)
-	}
-
-	updatedStr := string(updatedContent)
-
-	// Check that missing entries were added
-	if !strings.Contains(updatedStr, `msgid "World"`) {
-		t.Error("Missing entry 'World' was not added to PO file")
+		t.Fatalf("TranslatePoFile() error = %v", err)
 	}
-	if !strings.Contains(updatedStr, `msgid "Goodbye"`) {
-		t.Error("Missing entry 'Goodbye' was not added to PO file")
-	}
-
-	// Check that existing entry is still there
-	if !strings.Contains(updatedStr, `msgid "Hello"`) {
-		t.Error("Existing entry 'Hello' was removed")
-	}
-	if !strings.Contains(updatedStr, `msgstr "Hola"`) {
-		t.Error("Existing translation 'Hola' was removed")
+	if code := exitCode(result, false, 0); code != exitUnreachable {
+		t.Errorf("Expected exit code %d for %+v, got %d", exitUnreachable, result, code)
 	}
 }
 
-func TestCommentsAreCopiedFromPOT(t *testing.T) {
-	tempDir := t.TempDir()
-
-	// Create a POT file with comments
-	potFile := filepath.Join(tempDir, "test.pot")
-	potContent := `msgid ""
-msgstr ""
-"Language: en\n"
-
-#: source.py:10
-msgid "First"
-msgstr ""
-
-#: source.py:20
-#: source.py:30
-msgid "Second"
-msgstr ""
-
-#. Translator comment
-#: source.py:40
-msgid "Third"
-msgstr ""
-`
-	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
-		t.Fatalf("Failed to create POT file: %v", err)
-	}
-
-	// Create a PO file missing some entries
-	poFile := filepath.Join(tempDir, "test-es.po")
-	poContent := `msgid ""
-msgstr ""
-"Language: es\n"
-
-#: source.py:10
-msgid "First"
-msgstr "Primero"
-`
-	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
-		t.Fatalf("Failed to create PO file: %v", err)
+func TestFailOnMissing(t *testing.T) {
+	testOptions := catalog.DefaultOptions()
+	testOptions.Progress, testOptions.Output = "none", io.Discard
+	processor := catalog.New(testOptions)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"default.pot":   "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"Broken\"\nmsgstr \"\"\n",
+		"default_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n",
+		// Fuzzy and obsolete entries don't count as missing
+		"default_fr.po": "msgid \"\"\nmsgstr \"\"\n\"Language: fr\\n\"\n\n#, fuzzy\nmsgid \"Broken\"\nmsgstr \"Cass\u00e9\"\n\n#~ msgid \"Old\"\n#~ msgstr \"\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
 	}
 
-	// Parse POT file
-	potEntries, _, err := parsePotFile(potFile)
+	report := &catalog.Report{Domain: "default", Files: []catalog.FileReport{}}
+	result, err := processor.ProcessDirectory(dir, dir, "default", 0, brokenTranslator{broken: "Broken"}, report)
 	if err != nil {
-		t.Fatalf("Failed to parse POT file: %v", err)
+		t.Fatalf("ProcessDirectory() error = %v", err)
 	}
 
-	// Call translatePoFile to add missing entries
-	_, err = translatePoFile(poFile, potEntries, "en", "es", 0)
-	if err != nil {
-		t.Fatalf("translatePoFile failed: %v", err)
+	var buf bytes.Buffer
+	missing := reportMissing(&buf, report)
+	if missing != 1 {
+		t.Errorf("Expected 1 missing translation, got %d", missing)
 	}
-
-	// Read the updated PO file
-	updatedContent, err := os.ReadFile(poFile)
-	if err != nil {
-		t.Fatalf("Failed to read updated PO file: %v", err)
+	if !strings.Contains(buf.String(), "1 translation(s) missing in default_es.po") {
+		t.Errorf("Expected default_es.po to be reported, got:\n%s", buf.String())
 	}
-
-	updatedStr := string(updatedContent)
-
-	// Check that comments were copied for "Second"
-	if !strings.Contains(updatedStr, "#: source.py:20") {
-		t.Error("Comment '#: source.py:20' was not copied from POT file")
+	if strings.Contains(buf.String(), "default_fr.po") {
+		t.Errorf("Expected default_fr.po not to be reported, got:\n%s", buf.String())
 	}
-	if !strings.Contains(updatedStr, "#: source.py:30") {
-		t.Error("Comment '#: source.py:30' was not copied from POT file")
+	if code := exitCode(result, false, missing); code == exitOK {
+		t.Errorf("Expected a non-zero exit code for %+v", result)
 	}
 
-	// Check that comments were copied for "Third"
-	if !strings.Contains(updatedStr, "#. Translator comment") {
-		t.Error("Translator comment was not copied from POT file")
-	}
-	if !strings.Contains(updatedStr, "#: source.py:40") {
-		t.Error("Comment '#: source.py:40' was not copied from POT file")
+	// Missing translations fail the run even when nothing failed in it
+	if code := exitCode(catalog.TranslationResult{}, false, missing); code != exitMissing {
+		t.Errorf("Expected exit code %d, got %d", exitMissing, code)
 	}
 }
 
-func TestCopyPotToPo(t *testing.T) {
-	tempDir := t.TempDir()
-
-	tests := []struct {
-		name       string
-		potContent string
-		targetLang string
-		wantError  bool
-	}{
-		{
-			name: "basic POT to PO conversion",
-			potContent: `# Translation Template
-msgid ""
-msgstr ""
-"Project-Id-Version: Test 1.0\n"
-"Language: en\n"
-"Language-Team: English\n"
-"PO-Revision-Date: 2024-01-01 12:00+0000\n"
-"Content-Type: text/plain; charset=UTF-8\n"
-
-#: test.py:10
-msgid "Hello"
-msgstr ""
-
-#: test.py:20
-msgid "World"
-msgstr ""
-`,
-			targetLang: "es",
-			wantError:  false,
-		},
-		{
-			name: "POT with multi-line strings",
-			potContent: `msgid ""
-msgstr ""
-"Language: en\n"
-"Content-Type: text/plain; charset=UTF-8\n"
+func TestQuiet(t *testing.T) {
+	previousQuiet, previousStdout := quiet, os.Stdout
+	defer func() { quiet, os.Stdout = previousQuiet, previousStdout }()
 
-msgid "This is a "
-"multi-line string"
-msgstr ""
-`,
-			targetLang: "fr",
-			wantError:  false,
-		},
-		{
-			name: "POT without language metadata",
-			potContent: `msgid ""
-msgstr ""
-"Content-Type: text/plain; charset=UTF-8\n"
-
-msgid "Test"
-msgstr ""
-`,
-			targetLang: "de",
-			wantError:  false,
-		},
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
 	}
+	os.Stdout = writer
+	quiet = true
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			potFile := filepath.Join(tempDir, tt.name+".pot")
-			if err := os.WriteFile(potFile, []byte(tt.potContent), 0644); err != nil {
-				t.Fatalf("Failed to create POT file: %v", err)
-			}
-
-			newPoFile := filepath.Join(tempDir, tt.name+"-"+tt.targetLang+".po")
-
-			err := copyPotToPo(potFile, newPoFile, tt.targetLang)
-			if (err != nil) != tt.wantError {
-				t.Errorf("copyPotToPo() error = %v, wantError %v", err, tt.wantError)
-				return
-			}
-
-			if tt.wantError {
-				return
-			}
-
-			// Verify the new PO file was created
-			if _, err := os.Stat(newPoFile); os.IsNotExist(err) {
-				t.Error("New PO file was not created")
-				return
-			}
-
-			// Read and verify content
-			content, err := os.ReadFile(newPoFile)
-			if err != nil {
-				t.Fatalf("Failed to read new PO file: %v", err)
-			}
-
-			contentStr := string(content)
+	testOptions := catalog.DefaultOptions()
+	testOptions.Output = humanOutput("")
+	processor := catalog.New(testOptions)
 
-			// Check that Language header was updated
-			if strings.Contains(tt.potContent, "\"Language:") {
-				expectedLangHeader := fmt.Sprintf("\"Language: %s\\n\"", tt.targetLang)
-				if !strings.Contains(contentStr, expectedLangHeader) {
-					t.Errorf("Expected Language header %q not found in PO file", expectedLangHeader)
-				}
-			}
-
-			// Check that Language-Team header was updated
-			if strings.Contains(tt.potContent, "\"Language-Team:") {
-				expectedTeamHeader := fmt.Sprintf("\"Language-Team: %s\\n\"", strings.ToUpper(tt.targetLang))
-				if !strings.Contains(contentStr, expectedTeamHeader) {
-					t.Errorf("Expected Language-Team header %q not found in PO file", expectedTeamHeader)
-				}
-			}
-
-			// Check that PO-Revision-Date was updated
-			if strings.Contains(tt.potContent, "\"PO-Revision-Date:") {
-				if !strings.Contains(contentStr, "\"PO-Revision-Date:") {
-					t.Error("PO-Revision-Date header not found in PO file")
-				}
-				// Verify date format is reasonable (contains year 20xx)
-				if !strings.Contains(contentStr, "202") {
-					t.Error("PO-Revision-Date does not appear to be current")
-				}
-			}
-
-			// Check that msgid entries are preserved
-			if strings.Contains(tt.potContent, `msgid "Hello"`) {
-				if !strings.Contains(contentStr, `msgid "Hello"`) {
-					t.Error("msgid 'Hello' was not preserved in PO file")
-				}
-			}
-
-			if strings.Contains(tt.potContent, `msgid "World"`) {
-				if !strings.Contains(contentStr, `msgid "World"`) {
-					t.Error("msgid 'World' was not preserved in PO file")
-				}
-			}
-
-			// Check that comments are preserved
-			if strings.Contains(tt.potContent, "#: test.py:10") {
-				if !strings.Contains(contentStr, "#: test.py:10") {
-					t.Error("Comment '#: test.py:10' was not preserved in PO file")
-				}
-			}
-
-			// Verify msgstr entries remain empty
-			if strings.Contains(tt.potContent, `msgid "Hello"`) {
-				// Find Hello's msgstr
-				if !strings.Contains(contentStr, `msgid "Hello"`+"\nmsgstr \"\"") &&
-					!strings.Contains(contentStr, `msgid "Hello"`+"\r\nmsgstr \"\"") {
-					t.Error("msgstr for 'Hello' should be empty in new PO file")
-				}
-			}
-		})
+	dir := t.TempDir()
+	files := map[string]string{
+		"default.pot":   "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n",
+		"default_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n",
 	}
-}
-
-func TestCopyPotToPoFileErrors(t *testing.T) {
-	tempDir := t.TempDir()
-
-	tests := []struct {
-		name      string
-		setupFunc func() (potFile, newPoFile string)
-		wantError bool
-	}{
-		{
-			name: "nonexistent POT file",
-			setupFunc: func() (string, string) {
-				return filepath.Join(tempDir, "nonexistent.pot"),
-					filepath.Join(tempDir, "output.po")
-			},
-			wantError: true,
-		},
-		{
-			name: "invalid output directory",
-			setupFunc: func() (string, string) {
-				potFile := filepath.Join(tempDir, "test.pot")
-				os.WriteFile(potFile, []byte("msgid \"\"\nmsgstr \"\""), 0644)
-				return potFile, "/invalid/path/output.po"
-			},
-			wantError: true,
-		},
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			potFile, newPoFile := tt.setupFunc()
-
-			err := copyPotToPo(potFile, newPoFile, "es")
-			if (err != nil) != tt.wantError {
-				t.Errorf("copyPotToPo() error = %v, wantError %v", err, tt.wantError)
-			}
-		})
+	result, err := processor.ProcessDirectory(dir, dir, "default", 0, brokenTranslator{}, nil)
+	writer.Close()
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if result.Translated != 1 {
+		t.Errorf("Expected 1 translation, got %+v", result)
+	}
+	if stdout, _ := io.ReadAll(reader); len(stdout) > 0 {
+		t.Errorf("Expected no output with --quiet, got:\n%s", stdout)
 	}
 }
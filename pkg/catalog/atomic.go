@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeTemp writes the content to the temporary file of writeFileAtomic.
+// Tests replace it to simulate a write failing halfway.
+var writeTemp = func(file *os.File, content []byte) error {
+	_, err := file.Write(content)
+	return err
+}
+
+// writeFileAtomic replaces the file with the content, like os.WriteFile, but
+// writes a temporary file in the same directory first and renames it into
+// place. A write that fails or is interrupted leaves the file untouched.
+// Unlike os.WriteFile the mode isn't reduced by the umask, and an existing
+// file keeps its owner where permitted. A symlink is followed, so the file it
+// points to is replaced instead of the link.
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Does nothing once the file is renamed
+	defer os.Remove(temp.Name())
+
+	if err := writeTemp(temp, content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+	copyOwner(temp.Name(), path)
+	return os.Rename(temp.Name(), path)
+}
+
+// fileMode returns the permissions of an existing file, so that rewriting a
+// group-writable catalog keeps it that way, or 0644 for a new file.
+func fileMode(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0644
+}
@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	p := New(DefaultOptions())
+	previousWriteTemp := writeTemp
+	defer func() { writeTemp = previousWriteTemp }()
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// The write stops halfway, like a process that is killed or a full disk
+	writeTemp = func(file *os.File, content []byte) error {
+		file.Write(content[:len(content)/2])
+		return fmt.Errorf("no space left on device")
+	}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err == nil {
+		t.Fatal("Expected the write error")
+	}
+
+	content, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read PO file: %v", err)
+	}
+	if string(content) != poContent {
+		t.Errorf("Expected the PO file to be intact, got:\n%s", content)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected the temporary file to be removed, got %d files", len(entries))
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "target.po")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	link := filepath.Join(tempDir, "link.po")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	// The file the link points to is replaced, keeping the link
+	if err := writeFileAtomic(link, []byte("new"), 0640); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the symlink to be kept")
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "new" {
+		t.Errorf("Expected the new content, got %q", content)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0640 {
+		t.Errorf("Expected mode 0640, got %#o", mode)
+	}
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// poWriter overwrites a PO file, first copying the original content to a
+// backup file when --backup is set. The backup is written once, before the
+// first overwrite.
+type poWriter struct {
+	p          *Processor // Formats and writes the content
+	path       string
+	original   []byte
+	backupPath string // Empty when backups are disabled
+	backedUp   bool
+}
+
+// newPoWriter prepares writing the PO file whose current content is original.
+// It fails early when the backup file already exists, unless --force is set.
+// With --out-dir and --diff nothing is backed up, as the PO files are never
+// overwritten.
+func (p *Processor) newPoWriter(path string, original []byte) (*poWriter, error) {
+	w := &poWriter{p: p, path: path, original: original}
+	if !p.Backup || p.OutDir != "" || p.Diff {
+		return w, nil
+	}
+
+	w.backupPath = path + strings.ReplaceAll(p.BackupSuffix, "{timestamp}", time.Now().Format("20060102-150405"))
+	if _, err := os.Stat(w.backupPath); err == nil && !p.Force {
+		return nil, fmt.Errorf("backup file '%s' already exists (use --force to overwrite it)", w.backupPath)
+	}
+	return w, nil
+}
+
+// write replaces the content of the PO file, formatted like msgcat with
+// --canonical and converted to UTF-8 with --to-utf8. Otherwise the file ends
+// with a newline only when the original did, and with --compact it gets
+// single blank lines between the entries. With --diff the changes are
+// printed instead.
+func (w *poWriter) write(content string) error {
+	if w.p.Canonical {
+		content = w.p.canonicalContent(content)
+	} else {
+		content = keepFinalNewline(content, string(w.original))
+	}
+	if w.p.Compact {
+		content = compactContent(content)
+	}
+	if w.p.ToUTF8 {
+		content = withUTF8Charset(content)
+	}
+	if w.p.Diff {
+		fmt.Fprint(w.p.Output, unifiedDiff(w.path, string(w.original), content))
+		return nil
+	}
+	if w.backupPath != "" && !w.backedUp {
+		// The backup of a .gz catalog is compressed like the original,
+		// and it gets its mode
+		original, err := w.p.encodeCatalog(w.path, w.original)
+		if err == nil {
+			err = writeFileAtomic(w.backupPath, original, fileMode(w.path))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write backup file: %v", err)
+		}
+		w.backedUp = true
+	}
+	return w.p.writeCatalog(w.path, []byte(content))
+}
@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupBeforeModifying(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Backup = true
+	p.BackupSuffix = ".bak"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+`
+
+	for _, rewrite := range []bool{false, true} {
+		p.Force = false
+
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		process := func() error {
+			if rewrite {
+				_, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+				return err
+			}
+			_, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			return err
+		}
+
+		if err := process(); err != nil {
+			t.Fatalf("Processing failed (rewrite=%v): %v", rewrite, err)
+		}
+
+		backupContent, err := os.ReadFile(poFile + ".bak")
+		if err != nil {
+			t.Fatalf("Failed to read backup file (rewrite=%v): %v", rewrite, err)
+		}
+		if string(backupContent) != poContent {
+			t.Errorf("Backup doesn't match the original (rewrite=%v):\n%s", rewrite, backupContent)
+		}
+		updatedContent, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read updated PO file: %v", err)
+		}
+		if !strings.Contains(string(updatedContent), `msgstr "es:World"`) {
+			t.Errorf("PO file was not updated (rewrite=%v):\n%s", rewrite, updatedContent)
+		}
+
+		// An existing backup is only overwritten with --force
+		if err := process(); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected existing backup error (rewrite=%v), got %v", rewrite, err)
+		}
+		p.Force = true
+		if err := process(); err != nil {
+			t.Fatalf("Processing with --force failed (rewrite=%v): %v", rewrite, err)
+		}
+	}
+}
+
+func TestBackupTimestampSuffix(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Backup = true
+	p.BackupSuffix = ".{timestamp}.bak"
+
+	poFile := filepath.Join(t.TempDir(), "test_es.po")
+	writer, err := p.newPoWriter(poFile, []byte("original"))
+	if err != nil {
+		t.Fatalf("newPoWriter() error = %v", err)
+	}
+	if err := writer.write("updated"); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	matches, err := filepath.Glob(poFile + ".*.bak")
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("Expected one timestamped backup, got %v (%v)", matches, err)
+	}
+	if strings.Contains(matches[0], "{timestamp}") {
+		t.Errorf("Timestamp was not substituted in %q", matches[0])
+	}
+}
+
+func TestFileModeIsPreserved(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Backup, p.BackupSuffix = true, ".bak"
+
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+	poContent := "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		// Group-writable for the translation team, set explicitly to bypass
+		// the umask
+		if err := os.Chmod(poFile, 0660); err != nil {
+			t.Fatalf("Failed to change the mode of the PO file: %v", err)
+		}
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+		if result.Translated != 1 {
+			t.Fatalf("rewrite=%v: expected 1 translated string, got %d", rewrite, result.Translated)
+		}
+
+		for _, file := range []string{poFile, poFile + ".bak"} {
+			info, err := os.Stat(file)
+			if err != nil {
+				t.Fatalf("rewrite=%v: %v", rewrite, err)
+			}
+			if mode := info.Mode().Perm(); mode != 0660 {
+				t.Errorf("rewrite=%v: expected mode 0660 for %s, got %#o", rewrite, filepath.Base(file), mode)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// translationCache stores translations on disk so identical strings are not
+// translated again, grouped by "<source>:<target>" language pair.
+type translationCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]map[string]string
+	dirty   bool
+}
+
+// loadCache reads the cache file, starting empty when it doesn't exist yet.
+func loadCache(path string) (*translationCache, error) {
+	c := &translationCache{
+		path:    path,
+		entries: make(map[string]map[string]string),
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *translationCache) get(from, to, text string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	translation, exists := c.entries[from+":"+to][text]
+	return translation, exists
+}
+
+func (c *translationCache) set(from, to, text, translation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pair := from + ":" + to
+	if c.entries[pair] == nil {
+		c.entries[pair] = make(map[string]string)
+	}
+	c.entries[pair][text] = translation
+	c.dirty = true
+}
+
+// save writes the cache file if it has changed since it was loaded.
+func (c *translationCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, content, fileMode(c.path)); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// cachedTranslate translates text, serving it from the cache when possible.
+// It reports whether the translation came from the cache, so callers can skip
+// the rate limiting delay. Texts of entries with a msgctxt are cached under
+// their entry key, so each context keeps its own translation, while only the
+// text is sent to the backend.
+func (p *Processor) cachedTranslate(translator Translator, msgctxt, text, from, to string) (string, bool, error) {
+	if p.cache != nil {
+		translation, exists := p.cache.get(from, to, entryKey(msgctxt, text))
+		p.recordCacheLookup(exists)
+		if exists {
+			return translation, true, nil
+		}
+	}
+
+	translation, err := p.translateChecked(translator, text, from, to)
+	if err != nil {
+		return "", false, err
+	}
+
+	if p.cache != nil {
+		p.cache.set(from, to, entryKey(msgctxt, text), translation)
+	}
+	return translation, false, nil
+}
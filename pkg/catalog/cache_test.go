@@ -0,0 +1,177 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranslationCacheSaveAndLoad(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	c, err := loadCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadCache() error = %v", err)
+	}
+	if _, exists := c.get("en", "es", "Hello"); exists {
+		t.Error("Expected empty cache")
+	}
+
+	c.set("en", "es", "Hello", "Hola")
+	if err := c.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	reloaded, err := loadCache(cachePath)
+	if err != nil {
+		t.Fatalf("loadCache() error = %v", err)
+	}
+	if translation, exists := reloaded.get("en", "es", "Hello"); !exists || translation != "Hola" {
+		t.Errorf("Expected cached 'Hola', got %q (exists %v)", translation, exists)
+	}
+	if _, exists := reloaded.get("en", "fr", "Hello"); exists {
+		t.Error("Cache should be keyed by target language")
+	}
+}
+
+func TestWarmCacheSkipsBackend(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, "cache.json")
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	run := func(name string) *fakeTranslator {
+		var err error
+		if p.cache, err = loadCache(cachePath); err != nil {
+			t.Fatalf("loadCache() error = %v", err)
+		}
+		defer func() { p.cache = nil }()
+
+		poFile := filepath.Join(tempDir, name)
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		translator := &fakeTranslator{}
+		result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		if err != nil {
+			t.Fatalf("TranslatePoFile failed: %v", err)
+		}
+		if result.Translated != 2 {
+			t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+		}
+		if err := p.cache.save(); err != nil {
+			t.Fatalf("save() error = %v", err)
+		}
+
+		content, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read PO file: %v", err)
+		}
+		if !strings.Contains(string(content), `msgstr "es:Hello"`) || !strings.Contains(string(content), `msgstr[1] "es:%d files"`) {
+			t.Errorf("Translations missing from %s:\n%s", name, content)
+		}
+		return translator
+	}
+
+	if calls := run("first_es.po").calls(); calls != 3 {
+		t.Errorf("Expected 3 backend calls on cold cache, got %d", calls)
+	}
+	if calls := run("second_es.po").calls(); calls != 0 {
+		t.Errorf("Expected 0 backend calls on warm cache, got %d", calls)
+	}
+}
+
+func TestCacheKeepsContextsApart(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Open"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgctxt "door"
+msgid "Open"
+msgstr ""
+`
+	cacheContent := `{"en:es": {"Open": "Abierto", "menu\u0004Open": "Abrir"}}`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		cachePath := filepath.Join(tempDir, "cache.json")
+		files := map[string]string{
+			potFile:   potContent,
+			poFile:    "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n",
+			cachePath: cacheContent,
+		}
+		for path, content := range files {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", path, err)
+			}
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+		if p.cache, err = loadCache(cachePath); err != nil {
+			t.Fatalf("loadCache() error = %v", err)
+		}
+
+		translator := &fakeTranslator{}
+		if rewrite {
+			_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+		} else {
+			_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+
+		// Only the msgid of the uncached context reaches the backend
+		if len(translator.texts) != 1 || translator.texts[0] != "Open" {
+			t.Errorf("rewrite=%v: expected only \"Open\" to be sent, got %q", rewrite, translator.texts)
+		}
+		content, _ := os.ReadFile(poFile)
+		for _, expected := range []string{
+			"\nmsgid \"Open\"\nmsgstr \"Abierto\"\n",
+			"msgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"Abrir\"\n",
+			"msgctxt \"door\"\nmsgid \"Open\"\nmsgstr \"es:Open\"\n",
+		} {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("rewrite=%v: expected %q in:\n%s", rewrite, expected, content)
+			}
+		}
+		if translation, _ := p.cache.get("en", "es", entryKey("door", "Open")); translation != "es:Open" {
+			t.Errorf("rewrite=%v: expected the translation cached with its context, got %q", rewrite, translation)
+		}
+		p.cache = nil
+	}
+}
@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// canonicalContent formats the content of a PO file like GNU msgcat with its
+// default options, for --canonical: every entry is written from its parsed
+// strings wrapped at the --width, with its comments in gettext order, sorted
+// references and a single blank line between entries. Obsolete entries are
+// moved to the end of the file.
+func (p *Processor) canonicalContent(content string) string {
+	lines, lineEnding := splitLines(content)
+	blocks, _ := parsePoLines(lines)
+
+	var result, obsolete []string
+	addChunk := func(target *[]string, chunk []string) {
+		if len(*target) > 0 {
+			*target = append(*target, "")
+		}
+		*target = append(*target, chunk...)
+	}
+
+	for _, block := range blocks {
+		if block.isEntry {
+			addChunk(&result, p.canonicalEntry(block))
+			continue
+		}
+		// Comments and obsolete entries separated by blank lines
+		var chunk []string
+		for _, line := range append(block.other, "") {
+			if strings.TrimSpace(line) != "" {
+				chunk = append(chunk, line)
+				continue
+			}
+			if len(chunk) == 0 {
+				continue
+			}
+			if isObsoleteChunk(chunk) {
+				addChunk(&obsolete, chunk)
+			} else {
+				addChunk(&result, chunk)
+			}
+			chunk = nil
+		}
+	}
+	if len(obsolete) > 0 {
+		addChunk(&result, obsolete)
+	}
+
+	// msgcat ends the file with a newline
+	return joinLines(append(result, ""), lineEnding)
+}
+
+// isObsoleteChunk reports whether the lines hold an obsolete entry, with or
+// without the comments before its "#~" lines.
+func isObsoleteChunk(chunk []string) bool {
+	for _, line := range chunk {
+		if strings.HasPrefix(strings.TrimSpace(line), "#~") {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalEntry writes an entry the way msgcat does.
+func (p *Processor) canonicalEntry(block *catalogEntry) []string {
+	var comments entryComments
+	var references []string
+	for _, line := range block.comments {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#,"), strings.HasPrefix(trimmed, "#|"):
+			// Written from the parsed flags and previous msgid
+		case strings.HasPrefix(trimmed, "#:"):
+			references = append(references, strings.Fields(trimmed[2:])...)
+		default:
+			comments.add(line)
+		}
+	}
+	comments.References = formatReferences(references, p.Width)
+
+	width := p.entryWidth(block.Flags)
+	lines := p.formatEntryComments(comments, block.Flags, block.PrevMsgid)
+	for _, line := range block.keyLines {
+		if strings.HasPrefix(strings.TrimSpace(line), "msgctxt ") {
+			lines = append(lines, formatPoString("msgctxt", block.Msgctxt, width)...)
+			break
+		}
+	}
+	lines = append(lines, formatPoString("msgid", block.Msgid, width)...)
+	if !block.isPlural() {
+		return append(lines, formatPoString("msgstr", block.Msgstr, width)...)
+	}
+	lines = append(lines, formatPoString("msgid_plural", block.MsgidPlural, width)...)
+	for n, form := range block.Msgstrs {
+		lines = append(lines, formatPoString("msgstr["+strconv.Itoa(n)+"]", form, width)...)
+	}
+	return lines
+}
+
+// formatReferences writes the source references sorted by file and line, on
+// "#:" lines that are filled up to the width like msgcat does.
+func formatReferences(references []string, width int) []string {
+	if len(references) == 0 {
+		return nil
+	}
+	sort.SliceStable(references, func(i, j int) bool {
+		return referenceLess(references[i], references[j])
+	})
+
+	var lines []string
+	line := "#:"
+	for n, reference := range references {
+		if n > 0 && reference == references[n-1] {
+			continue
+		}
+		if line != "#:" && width > 0 && len(line)+1+len(reference) > width {
+			lines = append(lines, line)
+			line = "#:"
+		}
+		line += " " + reference
+	}
+	return append(lines, line)
+}
+
+// referenceLess orders "file:line" references by file and then numerically
+// by line.
+func referenceLess(a, b string) bool {
+	fileA, lineA := splitReference(a)
+	fileB, lineB := splitReference(b)
+	if fileA != fileB {
+		return fileA < fileB
+	}
+	return lineA < lineB
+}
+
+// splitReference splits a "file:line" reference, using line 0 for references
+// without a line number.
+func splitReference(reference string) (string, int) {
+	if i := strings.LastIndex(reference, ":"); i >= 0 {
+		if line, err := strconv.Atoi(reference[i+1:]); err == nil {
+			return reference[:i], line
+		}
+	}
+	return reference, 0
+}
+
+// writeUnchanged writes the otherwise unchanged content of the PO file in
+// the --canonical or --compact format, when it isn't formatted that way
+// already, or in UTF-8 with --to-utf8, when it isn't in UTF-8 already.
+func (w *poWriter) writeUnchanged(content string) error {
+	if w.p.needsUTF8([]byte(content)) {
+		return w.write(content)
+	}
+	formatted := content
+	if w.p.Canonical {
+		formatted = w.p.canonicalContent(formatted)
+	}
+	if w.p.Compact {
+		formatted = compactContent(formatted)
+	}
+	if formatted != content {
+		return w.write(formatted)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalGolden(t *testing.T) {
+	p := New(DefaultOptions())
+	input, err := os.ReadFile(filepath.Join("testdata", "canonical_input.po"))
+	if err != nil {
+		t.Fatalf("Failed to read input: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", "canonical_expected.po"))
+	if err != nil {
+		t.Fatalf("Failed to read expected output: %v", err)
+	}
+
+	formatted := p.canonicalContent(string(input))
+	if formatted != string(expected) {
+		t.Errorf("canonicalContent() mismatch\ngot:\n%s\nwant:\n%s", formatted, expected)
+	}
+	if again := p.canonicalContent(formatted); again != formatted {
+		t.Errorf("Expected canonical output to be stable, got:\n%s", again)
+	}
+
+	// A fully translated file is still formatted with --canonical
+	p.Canonical = true
+	tempDir := t.TempDir()
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, input, 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	potEntries := map[string]POEntry{
+		"Hello %s": {},
+		"This is a rather long message that is written on a single line by some editors": {},
+		entryKey("menu", "File"): {MsgidPlural: "Files"},
+	}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile() error = %v", err)
+	}
+	if result.Translated != 0 {
+		t.Errorf("Expected nothing to translate, got %+v", result)
+	}
+	if written, _ := os.ReadFile(poFile); string(written) != string(expected) {
+		t.Errorf("Expected the PO file in canonical format, got:\n%s", written)
+	}
+}
+
+func TestFormatReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		references []string
+		width      int
+		expected   []string
+	}{
+		{"none", nil, 79, nil},
+		{"sorted by file and line", []string{"b.c:2", "a.c:10", "a.c:9", "a.c"}, 79, []string{"#: a.c a.c:9 a.c:10 b.c:2"}},
+		{"duplicates", []string{"a.c:1", "a.c:1"}, 79, []string{"#: a.c:1"}},
+		{"wrapped", []string{"one.c:1", "two.c:2", "three.c:3"}, 20, []string{"#: one.c:1 three.c:3", "#: two.c:2"}},
+		{"long reference", []string{"a/very/long/path.c:1", "b.c:2"}, 10, []string{"#: a/very/long/path.c:1", "#: b.c:2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := formatReferences(tt.references, tt.width)
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("formatReferences() = %q, want %q", lines, tt.expected)
+			}
+			for i := range lines {
+				if lines[i] != tt.expected[i] {
+					t.Errorf("formatReferences() = %q, want %q", lines, tt.expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,2416 @@
+// Package catalog parses gettext POT and PO files and fills in the missing
+// translations using a Translator. It holds the logic of the potranslate
+// command, which is a thin wrapper around it.
+//
+// The behavior is set by the Options of a Processor created with New, using
+// the same defaults as the command line. A typical program parses a POT file
+// with ParsePotFile and updates its PO files with TranslatePoFile, or
+// RewritePoFile to also drop entries that are no longer in the template.
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var npluralsRegexp = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// detectPotLanguage detects the source language of the POT entries for
+// --detect-source. An ambiguous result is only used when the user confirms.
+func (p *Processor) detectPotLanguage(potEntries map[string]POEntry, delay time.Duration, translator Translator) (string, error) {
+	detector, ok := translator.(Detector)
+	if !ok {
+		return "", fmt.Errorf("the %s backend can't detect languages, use --source-lang", p.Backend)
+	}
+
+	fmt.Fprintf(p.Output, "Detecting source language...\n")
+	language, ambiguous, err := p.detectSourceLanguage(potEntries, detector, delay)
+	if err != nil {
+		return "", fmt.Errorf("detecting source language: %v", err)
+	}
+	if ambiguous && !confirm(p.ctx, fmt.Sprintf("Source language is probably '%s', is that correct?", language)) {
+		return "", fmt.Errorf("source language detection was ambiguous, use --source-lang")
+	}
+
+	fmt.Fprintf(p.Output, "Detected source language: %s\n", language)
+	return language, nil
+}
+
+// resolveSourceLanguage returns the source language of the POT entries: the
+// language of the POT file, or else --source-lang or the --detect-source
+// result, which is then written to the POT file. With --force-source the
+// --source-lang always wins.
+func (p *Processor) resolveSourceLanguage(potFile string, potEntries map[string]POEntry, detected string, delay time.Duration, translator Translator) (string, error) {
+	language := detected
+	var err error
+	if len(potEntries) == 0 {
+		// Nothing gets translated, so no source language is needed
+		fmt.Fprintln(p.Output, "POT contains no translatable strings")
+		if language == "" {
+			language = p.SourceLang
+		}
+	} else if language == "" {
+		language = p.SourceLang
+		if language == "" && p.DetectSource {
+			if language, err = p.detectPotLanguage(potEntries, delay, translator); err != nil {
+				return "", err
+			}
+		}
+		if language == "" {
+			return "", fmt.Errorf("source language not detected in POT file '%s' and not provided via --source-lang", potFile)
+		}
+		p.writePotLanguage(potFile, language)
+	} else if p.SourceLang != "" && p.SourceLang != language {
+		if p.ForceSource {
+			// The language of the POT file is wrong, --force-source corrects it
+			language = p.SourceLang
+			p.writePotLanguage(potFile, language)
+		} else {
+			fmt.Fprintf(p.Output, "Warning: Using source language from POT file (%s) instead of provided flag (%s), use --force-source to overwrite it\n", language, p.SourceLang)
+		}
+	}
+
+	if language != "" {
+		fmt.Fprintf(p.Output, "Source language: %s\n", language)
+	}
+	return language, nil
+}
+
+// ProcessDirectory translates the PO files of the domain in a directory
+// containing its POT file, or creates the --add-lang file. File names are
+// shown relative to root. It returns the counts of all processed files.
+func (p *Processor) ProcessDirectory(directory, root, domain string, delay time.Duration, translator Translator, report *Report) (TranslationResult, error) {
+	// Find POT file
+	potFile := p.potFilePath(directory, domain)
+	if _, err := os.Stat(potFile); os.IsNotExist(err) {
+		return TranslationResult{}, fmt.Errorf("POT file '%s' not found", potFile)
+	}
+
+	fmt.Fprintf(p.Output, "Processing domain: %s\n", domain)
+	fmt.Fprintf(p.Output, "POT file: %s\n", potFile)
+
+	// Parse POT file and get source language
+	potEntries, detectedSourceLang, err := p.ParsePotFile(potFile)
+	if err != nil {
+		return TranslationResult{}, fmt.Errorf("parsing POT file: %v", err)
+	}
+
+	finalSourceLang, err := p.resolveSourceLanguage(potFile, potEntries, detectedSourceLang, delay, translator)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	if report != nil && report.SourceLanguage == "" {
+		report.SourceLanguage = finalSourceLang
+	}
+
+	// Handle add-lang flag: create the new language files
+	if p.AddLang != "" {
+		languages := strings.Split(p.AddLang, ",")
+		for _, lang := range languages {
+			if p.usesBackend() && !p.supportsLanguage(translator, translatorLanguage(lang)) {
+				return TranslationResult{}, fmt.Errorf("target language '%s' is not supported by the %s backend (see --list-languages)", lang, p.Backend)
+			}
+		}
+
+		var seedFile string
+		if p.SeedFrom != "" {
+			if seedFile, err = p.seedPoFilePath(directory, domain, p.SeedFrom); err != nil {
+				return TranslationResult{}, err
+			}
+		}
+
+		var total TranslationResult
+		for _, lang := range languages {
+			if p.interrupted.Load() {
+				fmt.Fprintln(p.Output, "\nInterrupted by user. Exiting...")
+				break
+			}
+			p.startLimitFile()
+			result, err := p.addLanguage(directory, root, domain, potFile, lang, seedFile, potEntries, finalSourceLang, delay, translator, report)
+			if err != nil {
+				return total, err
+			}
+			total.Add(result)
+		}
+		return total, nil
+	}
+
+	// Without strings the PO files are left alone, except when rewriting
+	// them makes their entries obsolete
+	if len(potEntries) == 0 && !p.Rewrite {
+		fmt.Fprintln(p.Output)
+		return TranslationResult{}, nil
+	}
+
+	// Find all PO files for this domain
+	poFiles, err := p.FindPoFiles(directory, domain, p.Layout)
+	if err != nil {
+		return TranslationResult{}, fmt.Errorf("finding PO files: %v", err)
+	}
+
+	poFiles = p.filterByLanguage(poFiles, domain)
+
+	if len(poFiles) == 0 {
+		fmt.Fprintf(p.Output, "No PO files found for domain '%s'\n\n", domain)
+		return TranslationResult{}, nil
+	}
+
+	fmt.Fprintf(p.Output, "Found %d PO file(s)\n\n", len(poFiles))
+
+	// Process each PO file
+	var total TranslationResult
+
+	for _, poFile := range poFiles {
+		if p.interrupted.Load() {
+			fmt.Fprintln(p.Output, "\nInterrupted by user. Exiting...")
+			break
+		}
+
+		result, processed := p.processPoFile(root, poFile, domain, potEntries, finalSourceLang, delay, translator, report)
+		if processed {
+			total.Add(result)
+		}
+	}
+
+	return total, nil
+}
+
+// ProcessFile translates a single PO file from the entries of the POT file,
+// instead of all the PO files of its domain. The domain is taken from the
+// name of the POT file, for detecting the target language from the name of
+// the PO file when its header doesn't have one.
+func (p *Processor) ProcessFile(poFile, potFile string, delay time.Duration, translator Translator, report *Report) (TranslationResult, error) {
+	if _, err := os.Stat(potFile); os.IsNotExist(err) {
+		return TranslationResult{}, fmt.Errorf("POT file '%s' not found", potFile)
+	}
+	if _, err := os.Stat(poFile); err != nil {
+		return TranslationResult{}, err
+	}
+
+	fmt.Fprintf(p.Output, "POT file: %s\n", potFile)
+
+	potEntries, detectedSourceLang, err := p.ParsePotFile(potFile)
+	if err != nil {
+		return TranslationResult{}, fmt.Errorf("parsing POT file: %v", err)
+	}
+
+	finalSourceLang, err := p.resolveSourceLanguage(potFile, potEntries, detectedSourceLang, delay, translator)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	if report != nil && report.SourceLanguage == "" {
+		report.SourceLanguage = finalSourceLang
+	}
+
+	if len(potEntries) == 0 && !p.Rewrite {
+		fmt.Fprintln(p.Output)
+		return TranslationResult{}, nil
+	}
+	fmt.Fprintln(p.Output)
+
+	domain := strings.TrimSuffix(catalogName(filepath.Base(potFile)), ".pot")
+	result, processed := p.processPoFile(filepath.Dir(poFile), poFile, domain, potEntries, finalSourceLang, delay, translator, report)
+	if !processed {
+		return TranslationResult{}, fmt.Errorf("%s was not processed", poFile)
+	}
+	return result, nil
+}
+
+// processPoFile translates or rewrites a single PO file of the domain,
+// printing its summary. It reports false when the file is skipped, like for
+// a language that can't be determined or an error that was printed.
+func (p *Processor) processPoFile(root, poFile, domain string, potEntries map[string]POEntry, sourceLang string, delay time.Duration, translator Translator, report *Report) (TranslationResult, bool) {
+	targetLang, err := p.GetTargetLanguage(poFile, domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not determine target language for %s: %v\n", relativePath(root, poFile), err)
+		return TranslationResult{}, false
+	}
+
+	// Checked up front, so that an unsupported language doesn't fail
+	// every string of the file. With --import the backend isn't used.
+	if p.usesBackend() && !p.supportsLanguage(translator, translatorLanguage(targetLang)) {
+		fmt.Fprintf(os.Stderr, "Error: Target language '%s' of %s is not supported by the %s backend (see --list-languages), skipping it\n", targetLang, relativePath(root, poFile), p.Backend)
+		return TranslationResult{}, false
+	}
+
+	fmt.Fprintf(p.Output, "Processing: %s (target: %s)\n", relativePath(root, poFile), targetLang)
+
+	var previous map[string]POEntry
+	if report != nil {
+		previous, _, _ = p.ParsePotFile(poFile)
+	}
+
+	// With --out-dir a copy is translated, leaving the PO file unchanged
+	outFile, err := p.stagePoFile(root, poFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", relativePath(root, poFile), err)
+		return TranslationResult{}, false
+	}
+
+	p.startLimitFile()
+	var result TranslationResult
+	if p.Rewrite {
+		result, err = p.RewritePoFile(outFile, potEntries, translatorLanguage(sourceLang), translatorLanguage(targetLang), delay, translator)
+	} else {
+		result, err = p.TranslatePoFile(outFile, potEntries, translatorLanguage(sourceLang), translatorLanguage(targetLang), delay, translator)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", relativePath(root, poFile), err)
+		return TranslationResult{}, false
+	}
+
+	if p.OutDir != "" && !p.Diff {
+		fmt.Fprintf(p.Output, "Written to: %s\n", outFile)
+	}
+	fmt.Fprintf(p.Output, "%s\n\n", result.Summary())
+	if report != nil {
+		p.addFileReport(report, root, poFile, domain, targetLang, potEntries, previous, result.Translated)
+	}
+	return result, true
+}
+
+// filterByLanguage applies --only-lang and --skip-lang to the PO files,
+// comparing their detected target languages. Files without a detectable
+// language are kept, so they get reported while processing.
+func (p *Processor) filterByLanguage(poFiles []string, domain string) []string {
+	if p.OnlyLang == "" && p.SkipLang == "" {
+		return poFiles
+	}
+
+	languages := make(map[string]bool)
+	for _, lang := range strings.Split(p.OnlyLang+","+p.SkipLang, ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			languages[NormalizeLocale(lang)] = true
+		}
+	}
+
+	var filtered []string
+	for _, poFile := range poFiles {
+		targetLang, err := p.GetTargetLanguage(poFile, domain)
+		if err != nil {
+			filtered = append(filtered, poFile)
+			continue
+		}
+		if languages[NormalizeLocale(targetLang)] == (p.OnlyLang != "") {
+			filtered = append(filtered, poFile)
+		}
+	}
+	return filtered
+}
+
+// FindPotDirectories walks root and returns every directory containing a
+// POT file of the domains selected by --domain, skipping directories matching
+// the comma separated exclude globs.
+func FindPotDirectories(root, domain, exclude string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(exclude, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	var directories []string
+	found := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && excluded(relativePath(root, path), patterns) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name, isPot := strings.CutSuffix(catalogName(d.Name()), ".pot")
+		if isPot && selectsDomain(domain, name) {
+			// Subdirectories are walked in between the files of a directory,
+			// so its POT files aren't necessarily adjacent
+			if dir := filepath.Dir(path); !found[dir] {
+				found[dir] = true
+				directories = append(directories, dir)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return directories, nil
+}
+
+// DomainList returns the domains of a comma separated --domain value, or nil
+// for "all".
+func DomainList(spec string) []string {
+	if spec == "all" {
+		return nil
+	}
+	var domains []string
+	for _, name := range strings.Split(spec, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			domains = append(domains, name)
+		}
+	}
+	return domains
+}
+
+// selectsDomain reports whether the --domain value selects the domain.
+func selectsDomain(spec, name string) bool {
+	if spec == "all" {
+		return true
+	}
+	return slices.Contains(DomainList(spec), name)
+}
+
+// SelectDomains returns the domains selected by --domain that have a POT file
+// in the directory, sorted for "all". Listed domains without a POT file are
+// skipped with a warning, so it only fails when none of them has one. A
+// single domain is returned as is, for ProcessDirectory to report.
+func (p *Processor) SelectDomains(directory, spec string) ([]string, error) {
+	listed := DomainList(spec)
+	if spec != "all" && len(listed) == 1 {
+		return listed, nil
+	}
+
+	var domains []string
+	if spec == "all" {
+		potFiles, err := globCatalogs(filepath.Join(directory, "*.pot"))
+		if err != nil {
+			return nil, err
+		}
+		for _, potFile := range potFiles {
+			name := strings.TrimSuffix(catalogName(filepath.Base(potFile)), ".pot")
+			if !slices.Contains(domains, name) {
+				domains = append(domains, name)
+			}
+		}
+		sort.Strings(domains)
+	} else {
+		for _, name := range listed {
+			potFile := p.potFilePath(directory, name)
+			if _, err := os.Stat(potFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: POT file '%s' not found, skipping domain '%s'\n", potFile, name)
+				continue
+			}
+			domains = append(domains, name)
+		}
+	}
+
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no POT files found in '%s'", directory)
+	}
+	return domains, nil
+}
+
+// excluded reports whether a relative directory path matches any of the
+// patterns, either by its name or by its full path.
+func excluded(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.ToSlash(path)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// addFileReport summarizes a processed PO file into the report, warning when
+// the file can't be read back. With --out-dir the written copy is read.
+func (p *Processor) addFileReport(report *Report, directory, poFile, domain, targetLang string, potEntries, previous map[string]POEntry, translated int) {
+	fileReport, err := p.summarizePoFile(p.outputFile(directory, poFile), potEntries, previous, translated)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not summarize %s: %v\n", relativePath(directory, poFile), err)
+		return
+	}
+	fileReport.File = relativePath(directory, poFile)
+	fileReport.Domain = domain
+	fileReport.Language = targetLang
+	report.Files = append(report.Files, fileReport)
+}
+
+// SaveCache flushes the translation cache to disk, if caching is enabled.
+func (p *Processor) SaveCache() {
+	if p.cache == nil {
+		return
+	}
+	if err := p.cache.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not save cache file '%s': %v\n", p.cache.path, err)
+	}
+}
+
+// FindPoFiles returns the PO files of the domain in the directory, following
+// the layout: "flat" for PO files next to the POT file, or "gnu" for
+// <lang>/LC_MESSAGES/<domain>.po. Files matching the .potranslateignore file
+// of the directory are left out.
+func (p *Processor) FindPoFiles(directory, domain, layout string) ([]string, error) {
+	// Flat layout uses the --naming separator: domain_*.po by default
+	pattern := filepath.Join(directory, domain+p.namingSeparator()+"*.po")
+	if layout == "gnu" {
+		// GNU layout uses locale directories: */LC_MESSAGES/domain.po
+		pattern = filepath.Join(directory, "*", "LC_MESSAGES", domain+".po")
+	}
+	matches, err := globCatalogs(pattern)
+	if err != nil {
+		return nil, err
+	}
+	ignoreGlobs, err := readIgnoreFile(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pattern of admin also matches admin_panel_es.po of admin_panel
+	var files []string
+	for _, match := range matches {
+		if layout != "gnu" && p.ownedByLongerDomain(match, domain) {
+			continue
+		}
+		if relative, err := filepath.Rel(directory, match); err == nil && ignoredCatalog(relative, ignoreGlobs) {
+			continue
+		}
+		files = append(files, match)
+	}
+	return files, nil
+}
+
+// ownedByLongerDomain reports whether a flat layout PO file of the domain is
+// the file of a longer domain with its own POT file in the same directory,
+// like admin_panel_es.po for the domain admin next to admin_panel.pot.
+func (p *Processor) ownedByLongerDomain(poFile, domain string) bool {
+	separator := p.namingSeparator()
+	parts := strings.Split(p.pathLanguage(poFile, domain), separator)
+	for n := 1; n < len(parts); n++ {
+		longer := domain + separator + strings.Join(parts[:n], separator)
+		potFile := filepath.Join(filepath.Dir(poFile), longer+".pot")
+		for _, candidate := range []string{potFile, potFile + gzipExt} {
+			if _, err := os.Stat(candidate); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// poFilePath returns the path of the PO file for a language in the layout.
+func (p *Processor) poFilePath(directory, domain, lang, layout string) string {
+	if layout == "gnu" {
+		return filepath.Join(directory, lang, "LC_MESSAGES", domain+".po")
+	}
+	return filepath.Join(directory, domain+p.namingSeparator()+lang+".po")
+}
+
+// namingSeparators maps the --naming presets to the separator between the
+// domain and the language in flat layout file names.
+var namingSeparators = map[string]string{
+	"underscore": "_",
+	"hyphen":     "-",
+	"dot":        ".",
+}
+
+// namingSeparator returns the separator of the --naming preset.
+func (p *Processor) namingSeparator() string {
+	if separator, exists := namingSeparators[p.Naming]; exists {
+		return separator
+	}
+	return "_"
+}
+
+// relativePath returns the path of a file relative to the directory, for
+// display purposes.
+func relativePath(directory, file string) string {
+	if rel, err := filepath.Rel(directory, file); err == nil {
+		return rel
+	}
+	return file
+}
+
+// POEntry is a single entry of a POT or PO file, keyed by its msgctxt and
+// msgid.
+type POEntry struct {
+	Msgctxt     string
+	Msgstr      string
+	MsgidPlural string
+	Msgstrs     []string      // Indexed msgstr[N] values of plural entries
+	Comments    entryComments // Comments by type, without the flags and previous msgid
+	Flags       []string      // Flags from the "#," comment line, e.g. fuzzy
+	PrevMsgid   string        // Previous msgid from the "#|" comments of a fuzzy entry
+	Index       int           // Position of the entry in the POT file
+}
+
+// entryKey builds the map key identifying an entry by its context and msgid,
+// using the EOT separator that gettext uses in compiled catalogs.
+func entryKey(msgctxt, msgid string) string {
+	if msgctxt == "" {
+		return msgid
+	}
+	return msgctxt + "\x04" + msgid
+}
+
+// splitEntryKey returns the context and msgid of an entry key.
+func splitEntryKey(key string) (string, string) {
+	if msgctxt, msgid, found := strings.Cut(key, "\x04"); found {
+		return msgctxt, msgid
+	}
+	return "", key
+}
+
+// normalizedKey returns the key used to match POT entries against PO entries.
+// With --normalize-newlines a trailing newline of the msgid is ignored, so
+// "Some text\n" and "Some text" are the same entry.
+func (p *Processor) normalizedKey(key string) string {
+	if !p.NormalizeNewlines {
+		return key
+	}
+	return strings.TrimSuffix(key, "\n")
+}
+
+// canonicalKeys maps the normalized keys of the POT entries to their keys,
+// the form that is written to PO files. Of POT entries that only differ in a
+// trailing newline, the first one wins.
+func (p *Processor) canonicalKeys(potEntries map[string]POEntry) map[string]string {
+	canonical := make(map[string]string)
+	for _, key := range orderedMsgids(potEntries) {
+		if _, exists := canonical[p.normalizedKey(key)]; !exists {
+			canonical[p.normalizedKey(key)] = key
+		}
+	}
+	return canonical
+}
+
+// matchTrailingNewline adds or removes the trailing newline of a translation
+// to match its msgid, as msgfmt requires. Empty translations are kept empty.
+func matchTrailingNewline(msgid, msgstr string) string {
+	if msgstr == "" {
+		return msgstr
+	}
+	if strings.HasSuffix(msgid, "\n") {
+		if !strings.HasSuffix(msgstr, "\n") {
+			return msgstr + "\n"
+		}
+		return msgstr
+	}
+	return strings.TrimSuffix(msgstr, "\n")
+}
+
+// entryOrder returns the keys of the POT entries in the order they are
+// written: the POT file order, or sorted by msgid with --sort.
+func (p *Processor) entryOrder(entries map[string]POEntry) []string {
+	keys := orderedMsgids(entries)
+	if p.Sort {
+		sortKeys(keys)
+	}
+	return keys
+}
+
+// sortKeys sorts entry keys by msgid and then msgctxt, comparing bytes so the
+// order doesn't depend on the locale.
+func sortKeys(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		contextI, msgidI := splitEntryKey(keys[i])
+		contextJ, msgidJ := splitEntryKey(keys[j])
+		if msgidI != msgidJ {
+			return msgidI < msgidJ
+		}
+		return contextI < contextJ
+	})
+}
+
+// orderedMsgids returns the msgids of the given entries in their original
+// POT file order, so output stays stable between runs.
+func orderedMsgids(entries map[string]POEntry) []string {
+	msgids := make([]string, 0, len(entries))
+	for msgid := range entries {
+		msgids = append(msgids, msgid)
+	}
+	sort.Slice(msgids, func(i, j int) bool {
+		return entries[msgids[i]].Index < entries[msgids[j]].Index
+	})
+	return msgids
+}
+
+// ParsePotFile parses a POT or PO file into its entries, keyed by msgctxt
+// and msgid. It also returns the language of the Language header.
+func (p *Processor) ParsePotFile(potFile string) (map[string]POEntry, string, error) {
+	file, err := p.openCatalog(potFile)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	entries := make(map[string]POEntry)
+	var currentMsgctxt, currentMsgid, currentMsgstr, currentMsgidPlural string
+	var currentMsgstrs []string
+	var currentComments, pendingComments entryComments
+	var currentFlags, pendingFlags []string
+	var currentPrevMsgid string
+	var pendingPrevious previousMsgid
+	var inMsgctxt, inMsgid, inMsgstr, inMsgidPlural bool
+	var hasEntry, hasMsgctxt bool
+	var sourceLang string
+	index := 0
+
+	saveEntry := func() {
+		if hasEntry && currentMsgid == "" && currentMsgctxt == "" && sourceLang == "" {
+			sourceLang = headerFields(currentMsgstr).Get("Language")
+		}
+		if hasEntry && currentMsgid != "" {
+			entries[entryKey(currentMsgctxt, currentMsgid)] = POEntry{
+				Msgctxt:     currentMsgctxt,
+				Msgstr:      currentMsgstr,
+				MsgidPlural: currentMsgidPlural,
+				Msgstrs:     currentMsgstrs,
+				Comments:    currentComments,
+				Flags:       currentFlags,
+				PrevMsgid:   currentPrevMsgid,
+				Index:       index,
+			}
+			index++
+		}
+		hasEntry = false
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimPrefix(scanner.Text(), utf8BOM)
+		trimmed := strings.TrimSpace(line)
+		if err := checkString(trimmed); err != nil {
+			return nil, "", fmt.Errorf("%s:%d: %v", filepath.Base(potFile), lineNumber, err)
+		}
+
+		if strings.HasPrefix(trimmed, "msgctxt ") {
+			// Save previous entry, the context starts a new one
+			saveEntry()
+			currentMsgctxt = extractString(trimmed[8:])
+			hasMsgctxt = true
+			inMsgctxt = true
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "msgid ") {
+			// Save previous entry
+			saveEntry()
+			if !hasMsgctxt {
+				currentMsgctxt = ""
+			}
+			hasMsgctxt = false
+			hasEntry = true
+			currentMsgid = extractString(trimmed[6:])
+			currentMsgstr = ""
+			currentMsgidPlural = ""
+			currentMsgstrs = nil
+			currentComments = pendingComments
+			currentFlags = pendingFlags
+			currentPrevMsgid = pendingPrevious.value
+			pendingComments = entryComments{}
+			pendingFlags = nil
+			pendingPrevious = previousMsgid{}
+			inMsgctxt = false
+			inMsgid = true
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "msgid_plural ") {
+			currentMsgidPlural = extractString(trimmed[13:])
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = true
+		} else if strings.HasPrefix(trimmed, "msgstr ") {
+			currentMsgstr = extractString(trimmed[7:])
+			inMsgid = false
+			inMsgstr = true
+			inMsgidPlural = false
+		} else if _, str, ok := parsePluralMsgstr(trimmed); ok {
+			currentMsgstrs = append(currentMsgstrs, str)
+			inMsgid = false
+			inMsgstr = true
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "\"") && (inMsgctxt || inMsgid || inMsgstr || inMsgidPlural) {
+			str := extractString(trimmed)
+			if inMsgctxt {
+				currentMsgctxt += str
+			} else if inMsgid {
+				currentMsgid += str
+			} else if inMsgidPlural {
+				currentMsgidPlural += str
+			} else if inMsgstr && len(currentMsgstrs) > 0 {
+				currentMsgstrs[len(currentMsgstrs)-1] += str
+			} else if inMsgstr {
+				currentMsgstr += str
+			}
+		} else if strings.HasPrefix(trimmed, "\"") {
+			fmt.Fprintf(os.Stderr, "Warning: %s:%d: unexpected continuation line, it doesn't follow a keyword\n", filepath.Base(potFile), lineNumber)
+		} else if strings.HasPrefix(trimmed, "#~") {
+			// Obsolete entries aren't active, end the current entry
+			saveEntry()
+			pendingComments = entryComments{}
+			pendingFlags = nil
+			pendingPrevious = previousMsgid{}
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "#,") {
+			pendingFlags = mergeFlags(pendingFlags, parseFlags(trimmed))
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "#|") {
+			// The previous msgid is kept apart from the comments
+			pendingPrevious.add(trimmed)
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if strings.HasPrefix(trimmed, "#") {
+			// Collect comments before next msgid
+			if !inMsgid && !inMsgstr {
+				pendingComments.add(line)
+			}
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		} else if trimmed == "" {
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+		}
+	}
+
+	// Save last entry
+	saveEntry()
+
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// Remove empty msgid (header)
+	delete(entries, "")
+
+	return entries, sourceLang, nil
+}
+
+// checkString checks the quoted string of a keyword or continuation line,
+// which must start and end with a quote that isn't escaped.
+func checkString(trimmed string) error {
+	value := trimmed
+	if !strings.HasPrefix(trimmed, "\"") {
+		keyword, rest, found := strings.Cut(trimmed, " ")
+		switch {
+		case !found:
+			return nil
+		case isStringKeyword(keyword):
+			value = strings.TrimSpace(rest)
+		default:
+			return nil
+		}
+		if !strings.HasPrefix(value, "\"") {
+			return fmt.Errorf("expected a quoted string after %s", keyword)
+		}
+	}
+
+	escaped := false
+	for i := 1; i < len(value); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case value[i] == '\\':
+			escaped = true
+		case value[i] == '"':
+			if strings.TrimSpace(value[i+1:]) != "" {
+				return fmt.Errorf("unexpected text after the end of the string")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unterminated string")
+}
+
+// unescapes maps the characters after a backslash in a PO string to the
+// characters they stand for, like in C.
+var unescapes = map[byte]byte{
+	'n': '\n', 't': '\t', 'r': '\r', 'a': '\a', 'b': '\b', 'f': '\f', 'v': '\v',
+	'\\': '\\', '"': '"', '\'': '\'', '?': '?',
+}
+
+// extractString returns the value of a quoted PO string, resolving the C
+// escape sequences, including octal (\101) and hex (\x41) ones, in a single
+// pass so that "\\n" stays a backslash followed by n. Unknown escapes are
+// kept as is.
+func extractString(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") {
+		s = s[1 : len(s)-1]
+	}
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if c, known := unescapes[s[i]]; known {
+			b.WriteByte(c)
+			continue
+		}
+		// Up to three octal digits, or up to two hex digits after \x
+		base, digits, start := 8, 3, i
+		if s[i] == 'x' {
+			base, digits, start = 16, 2, i+1
+		}
+		end := start
+		for end < len(s) && end-start < digits && isDigit(s[end], base) {
+			end++
+		}
+		value, err := strconv.ParseUint(s[start:end], base, 8)
+		if end == start || err != nil {
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+			continue
+		}
+		b.WriteByte(byte(value))
+		i = end - 1
+	}
+	return b.String()
+}
+
+// isDigit reports whether c is a digit in base 8 or 16.
+func isDigit(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '7':
+		return true
+	case base == 16:
+		return c == '8' || c == '9' || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	}
+	return false
+}
+
+// utf8BOM is the byte order mark some Windows editors put at the start of
+// UTF-8 files. It is skipped when reading, so it is never written back.
+const utf8BOM = "\uFEFF"
+
+// splitLines splits file content into lines without carriage returns and
+// reports the dominant line ending, so it can be restored on write. A leading
+// byte order mark is dropped.
+func splitLines(content string) ([]string, string) {
+	content = strings.TrimPrefix(content, utf8BOM)
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, dominantLineEnding(content)
+}
+
+// dominantLineEnding returns "\r\n" when at least half of the lines of the
+// content end that way, and "\n" otherwise.
+func dominantLineEnding(content string) string {
+	if crlf := strings.Count(content, "\r\n"); crlf > 0 && crlf*2 >= strings.Count(content, "\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// keepFinalNewline makes the content end with a line ending only when the
+// original content did, so writing a file back never adds or drops the
+// newline at its end.
+func keepFinalNewline(content, original string) string {
+	lineEnding := dominantLineEnding(content)
+	content = strings.TrimSuffix(content, lineEnding)
+	if strings.HasSuffix(original, "\n") {
+		content += lineEnding
+	}
+	return content
+}
+
+// joinLines joins lines using the given line ending.
+func joinLines(lines []string, lineEnding string) string {
+	return strings.Join(lines, lineEnding)
+}
+
+// parsePluralMsgstr parses an indexed "msgstr[N] ..." line of a plural entry.
+func parsePluralMsgstr(trimmed string) (int, string, bool) {
+	if !strings.HasPrefix(trimmed, "msgstr[") {
+		return 0, "", false
+	}
+	end := strings.Index(trimmed, "]")
+	if end < 0 {
+		return 0, "", false
+	}
+	index, err := strconv.Atoi(trimmed[7:end])
+	if err != nil {
+		return 0, "", false
+	}
+	return index, extractString(trimmed[end+1:]), true
+}
+
+// parsePluralCount reads the number of plural forms from the Plural-Forms
+// header in the given lines, defaulting to 2 when the header is absent.
+func parsePluralCount(lines []string) int {
+	match := npluralsRegexp.FindStringSubmatch(linesHeader(lines).Get("Plural-Forms"))
+	if match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 2
+}
+
+// parseFlags returns the flags of a "#, flag, flag" comment line.
+func parseFlags(trimmed string) []string {
+	var flags []string
+	for _, flag := range strings.Split(strings.TrimPrefix(trimmed, "#,"), ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// formatFlags renders flags as a "#, flag, flag" comment line.
+func formatFlags(flags []string) string {
+	return "#, " + strings.Join(flags, ", ")
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFlags returns the union of both flag lists, keeping their order.
+func mergeFlags(flags, other []string) []string {
+	merged := append([]string{}, flags...)
+	for _, flag := range other {
+		if !hasFlag(merged, flag) {
+			merged = append(merged, flag)
+		}
+	}
+	return merged
+}
+
+// translatedFlags returns the flags for a freshly machine-translated entry:
+// any existing fuzzy flag is resolved, unless --mark-fuzzy is set or the
+// translation needs review.
+func (p *Processor) translatedFlags(flags []string, needsReview bool) []string {
+	var result []string
+	for _, flag := range flags {
+		if flag != "fuzzy" {
+			result = append(result, flag)
+		}
+	}
+	if p.MarkFuzzy || needsReview {
+		result = append([]string{"fuzzy"}, result...)
+	}
+	return result
+}
+
+// warnDuplicate warns about an entry that appears more than once in a PO
+// file, as only --rewrite --dedupe collapses the duplicates.
+func warnDuplicate(poFile, key string) {
+	msgctxt, msgid := splitEntryKey(key)
+	entry := fmt.Sprintf("'%s'", msgid)
+	if msgctxt != "" {
+		entry += fmt.Sprintf(" (context '%s')", msgctxt)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s: %s appears more than once, use --rewrite --dedupe to collapse the duplicates\n", filepath.Base(poFile), entry)
+}
+
+// warnMissingMsgstr warns about a msgid on the line that isn't followed by a
+// msgstr, which is read as an empty msgstr.
+func warnMissingMsgstr(poFile string, line int) {
+	fmt.Fprintf(os.Stderr, "Warning: %s:%d: msgid without msgstr, using an empty msgstr\n", filepath.Base(poFile), line)
+}
+
+// formatPoString renders a keyword (msgid, msgstr, msgstr[N], ...) and its
+// value as PO lines. Values containing newlines are split after each newline,
+// and lines longer than width are wrapped at spaces, leaving width 0 unwrapped.
+func formatPoString(keyword, value string, width int) []string {
+	if !strings.Contains(value, "\n") {
+		line := fmt.Sprintf("%s \"%s\"", keyword, escapeString(value))
+		if width <= 0 || utf8.RuneCountInString(line) <= width {
+			return []string{line}
+		}
+	}
+	lines := []string{keyword + " \"\""}
+	parts := strings.Split(value, "\n")
+	for idx, part := range parts {
+		if idx < len(parts)-1 {
+			lines = append(lines, wrapPoString(escapeString(part)+"\\n", width)...)
+		} else if part != "" {
+			lines = append(lines, wrapPoString(escapeString(part), width)...)
+		}
+	}
+	return lines
+}
+
+// formatKeptString renders a keyword and its value like formatPoString, but
+// returns the lines of the keyword in the original lines of the entry when
+// they hold the same value. A string that is read and written unchanged keeps
+// its continuation lines, also where they don't end with a newline.
+func formatKeptString(original []string, keyword, value string, width int) []string {
+	for i, line := range original {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, keyword+" ") {
+			continue
+		}
+		kept := []string{line}
+		text := extractString(trimmed[len(keyword)+1:])
+		for _, next := range original[i+1:] {
+			if !strings.HasPrefix(strings.TrimSpace(next), "\"") {
+				break
+			}
+			kept = append(kept, next)
+			text += extractString(strings.TrimSpace(next))
+		}
+		if text == value {
+			return kept
+		}
+		break
+	}
+	return formatPoString(keyword, value, width)
+}
+
+// wrapPoString splits an escaped string into quoted continuation lines of at
+// most width columns, breaking after spaces. Words longer than the width are
+// kept whole.
+func wrapPoString(escaped string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(escaped)+2 <= width {
+		return []string{"\"" + escaped + "\""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range strings.SplitAfter(escaped, " ") {
+		if current != "" && utf8.RuneCountInString(current+word)+2 > width {
+			lines = append(lines, "\""+current+"\"")
+			current = ""
+		}
+		current += word
+	}
+	if current != "" {
+		lines = append(lines, "\""+current+"\"")
+	}
+	return lines
+}
+
+// entryWidth returns the wrap width for an entry with the given flags, or 0
+// when wrapping is disabled by --no-wrap or the entry's no-wrap flag.
+func (p *Processor) entryWidth(flags []string) int {
+	if p.NoWrap || hasFlag(flags, "no-wrap") {
+		return 0
+	}
+	return p.Width
+}
+
+// escapes maps the characters written as C escape sequences in PO strings
+// to their escaped form.
+var escapes = map[rune]string{
+	'\\': `\\`, '"': `\"`, '\n': `\n`, '\t': `\t`, '\r': `\r`,
+	'\a': `\a`, '\b': `\b`, '\f': `\f`, '\v': `\v`,
+}
+
+// escapeString escapes a value for a PO string, the inverse of extractString.
+// Other control characters are written as octal escapes.
+func escapeString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if escaped, exists := escapes[r]; exists {
+			b.WriteString(escaped)
+		} else if r < 0x20 || r == 0x7f {
+			fmt.Fprintf(&b, "\\%03o", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// GetTargetLanguage returns the language of a PO file, from its Language
+// header or its file name, depending on --trust.
+func (p *Processor) GetTargetLanguage(poFile, domain string) (string, error) {
+	header, err := p.headerLanguage(poFile)
+	if err != nil {
+		return "", err
+	}
+	path := p.pathLanguage(poFile, domain)
+
+	if header != "" && path != "" && NormalizeLocale(header) != NormalizeLocale(path) {
+		p.warnLanguageMismatch(poFile, header, path)
+	}
+	if header != "" && (p.Trust == "header" || path == "") {
+		return header, nil
+	}
+	if path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("could not determine target language")
+}
+
+// headerLanguage returns the Language of the PO file header, or an empty
+// string when it has none.
+func (p *Processor) headerLanguage(poFile string) (string, error) {
+	header, err := p.ReadHeader(poFile)
+	if err != nil {
+		return "", err
+	}
+	return header.Get("Language"), nil
+}
+
+// pathLanguage returns the language in the path of the PO file, or an empty
+// string when the path doesn't follow the layout.
+func (p *Processor) pathLanguage(poFile, domain string) string {
+	// Locale directory (e.g., es/LC_MESSAGES/default.po -> es)
+	dir := filepath.Dir(poFile)
+	if filepath.Base(dir) == "LC_MESSAGES" {
+		lang := filepath.Base(filepath.Dir(dir))
+		if lang != "" && lang != "." && lang != string(filepath.Separator) {
+			return lang
+		}
+	}
+
+	// Filename (e.g., default_es.po -> es, default_pt_BR.po -> pt_BR, or
+	// default-es.po with --naming hyphen)
+	base := strings.TrimSuffix(catalogName(filepath.Base(poFile)), ".po")
+	if lang, found := strings.CutPrefix(base, domain+p.namingSeparator()); found && lang != "" {
+		return lang
+	}
+	return ""
+}
+
+// warnLanguageMismatch warns once per PO file that its header and filename
+// don't agree on the language, naming the one --trust picks.
+func (p *Processor) warnLanguageMismatch(poFile, header, path string) {
+	if _, warned := p.languageWarnings.LoadOrStore(poFile, true); warned {
+		return
+	}
+	used := header
+	if p.Trust == "filename" {
+		used = path
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s has Language %s in its header but %s in its filename, using %s (--trust %s)\n", filepath.Base(poFile), header, path, used, p.Trust)
+}
+
+// TranslationResult holds the counts of processing one or more PO files.
+type TranslationResult struct {
+	Added      int // Entries added from the POT file
+	Translated int // Entries translated during this run
+	Merged     int // Entries filled from the --merge-from file
+	Imported   int // Entries filled from the --import file
+	Failed     int // Entries the translator returned an error for
+	Skipped    int // Entries not translated because of an interruption, --interactive, --limit or --import
+	Deferred   int // Entries left untranslated for a later run by --no-network
+	Ignored    int // Entries matching --ignore-pattern, copied or left untranslated
+	Removed    int // Obsolete entries removed (rewrite mode)
+	Deduped    int // Duplicate entries collapsed by --dedupe (rewrite mode)
+
+	// The backend requests and cache lookups, for --timing
+	Requests RequestStats
+}
+
+// Add adds the counts of another result.
+func (r *TranslationResult) Add(other TranslationResult) {
+	r.Added += other.Added
+	r.Translated += other.Translated
+	r.Merged += other.Merged
+	r.Imported += other.Imported
+	r.Failed += other.Failed
+	r.Skipped += other.Skipped
+	r.Deferred += other.Deferred
+	r.Ignored += other.Ignored
+	r.Removed += other.Removed
+	r.Deduped += other.Deduped
+	r.Requests.add(other.Requests)
+}
+
+// addEntries adds the counts of translating the given number of entries.
+// The entries left untranslated are deferred with --no-network, otherwise
+// they are skipped.
+func (r *TranslationResult) addEntries(entries *entryTranslations, keys int, deferred bool) {
+	r.Translated += entries.count - entries.merged - entries.imported
+	r.Merged += entries.merged
+	r.Imported += entries.imported
+	r.Failed += entries.failed
+	r.Ignored += entries.ignored
+	r.Requests.add(entries.requests)
+	left := keys - entries.count - entries.failed - entries.ignored
+	if deferred {
+		r.Deferred += left
+	} else {
+		r.Skipped += left
+	}
+}
+
+// Details lists the counts other than Translated that aren't zero, like
+// " (2 added, 1 failed)", or returns "" when they are all zero.
+func (r TranslationResult) Details() string {
+	var parts []string
+	for _, count := range []struct {
+		n     int
+		label string
+	}{{r.Added, "added"}, {r.Merged, "merged"}, {r.Imported, "imported"}, {r.Failed, "failed"}, {r.Skipped, "skipped"}, {r.Deferred, "deferred"}, {r.Ignored, "ignored"}, {r.Removed, "removed"}, {r.Deduped, "deduplicated"}} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.label))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// Summary describes the result, like "Translated 3 string(s) (1 failed)".
+func (r TranslationResult) Summary() string {
+	return fmt.Sprintf("Translated %d string(s)%s", r.Translated, r.Details())
+}
+
+// TranslatePoFile adds the entries of the POT file that are missing from the
+// PO file and translates the untranslated ones, keeping the rest of the file
+// as it is.
+func (p *Processor) TranslatePoFile(poFile string, potEntries map[string]POEntry, sourceLang, targetLang string, delay time.Duration, translator Translator) (TranslationResult, error) {
+	// Read PO file
+	content, err := p.readCatalog(poFile)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	writer, err := p.newPoWriter(poFile, content)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+
+	lines, lineEnding := splitLines(string(content))
+	lines, repaired, err := p.checkPoStrings(poFile, lines)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	nplurals := parsePluralCount(lines)
+
+	// Collect existing msgids in PO file. With --normalize-newlines, msgids
+	// only differing from the POT in a trailing newline get the POT msgid.
+	existingMsgids := make(map[string]bool)
+	canonical := p.canonicalKeys(potEntries)
+	blocks, _ := parsePoLines(lines)
+	renamed := 0
+	for _, block := range blocks {
+		if block.missingMsgstr > 0 {
+			warnMissingMsgstr(poFile, block.missingMsgstr)
+		}
+		if !block.isEntry || block.isHeader() {
+			continue
+		}
+		if existingMsgids[p.normalizedKey(block.key())] {
+			warnDuplicate(poFile, block.key())
+		}
+		if key, exists := canonical[p.normalizedKey(block.key())]; exists && key != block.key() {
+			_, msgid := splitEntryKey(key)
+			block.setMsgid(msgid, p.entryWidth(block.Flags))
+			renamed++
+		}
+		existingMsgids[p.normalizedKey(block.key())] = true
+	}
+	if renamed > 0 {
+		lines = p.formatPoLines(blocks)
+	}
+
+	// Find missing entries that need to be added, once for POT entries that
+	// are the same after normalizing. Entries before --since aren't added.
+	var missingKeys []string
+	for _, key := range p.entryOrder(potEntries) {
+		if key != "" && !existingMsgids[p.normalizedKey(key)] && !p.filteredBySince(potEntries[key]) {
+			missingKeys = append(missingKeys, key)
+			existingMsgids[p.normalizedKey(key)] = true
+		}
+	}
+
+	// Add missing entries to the end of the file. Without them the content
+	// is only written when it needs formatting.
+	writeContent := writer.writeUnchanged
+	if len(missingKeys) > 0 || renamed > 0 || repaired > 0 {
+		// Each entry gets a single blank line before it, so the blank lines
+		// at the end of the file don't add up on every run
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+
+		for _, key := range missingKeys {
+			lines = append(lines, "")
+			lines = append(lines, p.formatMissingEntry(key, potEntries[key], nplurals)...)
+		}
+
+		// Translate the updated content, it is written once with the
+		// translations
+		lines = p.stampHeader(lines)
+		content = []byte(joinLines(lines, lineEnding))
+		writeContent = writer.write
+
+		if len(missingKeys) > 0 {
+			fmt.Fprintf(p.Output, "Added %d missing entry/entries from POT file\n", len(missingKeys))
+		}
+	}
+
+	// Find entries that need translation, fuzzy entries don't count as
+	// translated
+	blocks, stray := parsePoLines(lines)
+	for _, lineNumber := range stray {
+		fmt.Fprintf(os.Stderr, "Warning: %s:%d: continuation line doesn't belong to any string, kept as is\n", filepath.Base(poFile), lineNumber)
+	}
+	var needsTranslation []string
+	pluralSources := make(map[string]string)
+	contexts := make(map[string]string)
+	flags := make(map[string][]string)
+	for _, block := range blocks {
+		// The header is copied verbatim and never translated
+		if !block.isEntry || block.isHeader() {
+			continue
+		}
+		entry, exists := potEntries[block.key()]
+		if !exists || !p.needsTranslation(block) || p.filteredByRef(entry) || p.filteredBySince(entry) || skippedByDirective(entry) {
+			continue
+		}
+		needsTranslation = append(needsTranslation, block.key())
+		flags[block.key()] = entry.Flags
+		if block.isPlural() {
+			pluralSources[block.key()] = block.MsgidPlural
+		}
+		if context := directiveContext(entry); context != "" {
+			contexts[block.key()] = context
+		}
+	}
+
+	// A complete file is left alone, unless --canonical or --to-utf8 still
+	// has to format it
+	fileResult := TranslationResult{Added: len(missingKeys)}
+	if len(needsTranslation) == 0 {
+		if len(missingKeys) == 0 && renamed == 0 && repaired == 0 {
+			fmt.Fprintln(p.Output, "Up to date")
+		}
+		return fileResult, writeContent(string(content))
+	}
+
+	// withTranslations updates the entries with the translations and returns
+	// the content to write
+	withTranslations := func(result *entryTranslations) string {
+		for _, block := range blocks {
+			if !block.isEntry || block.isHeader() {
+				continue
+			}
+			key := block.key()
+			if translated, exists := result.singular[key]; exists && !block.isPlural() {
+				block.Msgstr = translated
+			} else if forms, exists := result.plural[key]; exists {
+				block.Msgstrs = forms
+			} else {
+				continue
+			}
+			block.Flags = p.translatedFlags(block.Flags, result.needsReview[key])
+			if p.ClearPrevious && !result.needsReview[key] {
+				block.removePrevious()
+			}
+			block.modified = true
+		}
+		return joinLines(p.stampHeader(p.formatPoLines(blocks)), lineEnding)
+	}
+	flush := func(partial *entryTranslations) error {
+		return writer.write(withTranslations(partial))
+	}
+
+	// Translate each missing string
+	result := p.translateEntries(filepath.Base(poFile), needsTranslation, pluralSources, contexts, flags, nplurals, sourceLang, targetLang, delay, translator, flush)
+	p.checkLengths(filepath.Base(poFile), potEntries, result)
+	fileResult.addEntries(result, len(needsTranslation), p.NoNetwork)
+
+	if len(result.singular) == 0 && len(result.plural) == 0 {
+		return fileResult, writeContent(string(content))
+	}
+
+	// Write updated content back to file
+	if err := writer.write(withTranslations(result)); err != nil {
+		return TranslationResult{}, err
+	}
+
+	return fileResult, nil
+}
+
+// formatMissingEntry returns the lines of an untranslated entry added from
+// the POT file, with the comments of the POT entry. Entries without comments
+// get the --added-comment as an extracted comment, unless it is empty.
+func (p *Processor) formatMissingEntry(key string, entry POEntry, nplurals int) []string {
+	comments := entry.Comments
+	if len(comments.lines()) == 0 && p.AddedComment != "" {
+		comments.Extracted = []string{"#. " + p.AddedComment}
+	}
+	lines := p.formatEntryComments(comments, entry.Flags, "")
+	msgctxt, msgid := splitEntryKey(key)
+	if msgctxt != "" {
+		lines = append(lines, formatPoString("msgctxt", msgctxt, p.entryWidth(entry.Flags))...)
+	}
+	lines = append(lines, formatPoString("msgid", msgid, p.entryWidth(entry.Flags))...)
+	if entry.MsgidPlural != "" {
+		return append(lines, formatPluralStrings(nil, entry.MsgidPlural, make([]string, nplurals), p.entryWidth(entry.Flags))...)
+	}
+	return append(lines, "msgstr \"\"")
+}
+
+// entryTranslations holds the outcome of translating a set of entries.
+type entryTranslations struct {
+	singular    map[string]string
+	plural      map[string][]string
+	needsReview map[string]bool // Translations that should be marked fuzzy
+	count       int
+	failed      int // Entries the translator returned an error for
+	merged      int // Entries filled from --merge-from, included in count
+	imported    int // Entries filled from --import, included in count
+	ignored     int // Entries matching --ignore-pattern, not included in count
+	requests    RequestStats
+}
+
+// translateEntries translates the given entry keys using a pool of
+// --concurrency workers, each applying the delay between its own requests,
+// or the --lang-delay of the target language.
+// Keys listed in pluralSources are translated into nplurals forms, and those
+// in contexts are sent with the context of their potranslate directive. The
+// format flags of the keys in flags select the placeholders to protect. Keys
+// found in the --merge-from translations are filled without the backend. With
+// --interactive the backend translations are reviewed once they are all done.
+// Every --flush-every backend translations, the flush function writes the
+// translations so far, so they survive a crash.
+func (p *Processor) translateEntries(name string, keys []string, pluralSources, contexts map[string]string, flags map[string][]string, nplurals int, sourceLang, targetLang string, delay time.Duration, translator Translator, flush func(*entryTranslations) error) *entryTranslations {
+	result := &entryTranslations{
+		singular:    make(map[string]string),
+		plural:      make(map[string][]string),
+		needsReview: make(map[string]bool),
+	}
+	before := p.currentStats()
+	defer func() { result.requests = p.currentStats().since(before) }()
+
+	// Entries found in the --import file, the --merge-from translations or
+	// matching an --ignore-pattern don't need the backend. Without it the
+	// rest is left untranslated.
+	keys = p.importTranslations(keys, pluralSources, flags, result)
+	keys = p.mergeMemory(keys, pluralSources, nplurals, result)
+	keys = p.skipIgnored(keys, pluralSources, nplurals, result)
+	if len(keys) == 0 || !p.usesBackend() {
+		return result
+	}
+
+	progress := p.newProgress(name, len(keys))
+	delay = p.targetDelay(targetLang, delay)
+
+	// Nothing is written before the review of --interactive, and --diff
+	// only prints the final changes
+	if p.FlushEvery <= 0 || p.Interactive || p.Diff {
+		flush = nil
+	}
+	unflushed := 0
+
+	workers := p.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var processed atomic.Int64
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				// Entries over the --limit are left for the next run
+				if !p.claimLimit() {
+					continue
+				}
+				// Only the msgid is sent to the translator, never the msgctxt,
+				// which keeps the translations of each context apart
+				msgctxt, msgid := splitEntryKey(key)
+				var forms []string
+				var translated string
+				var cached bool
+				var issue string
+				var err error
+				msgidPlural, isPlural := pluralSources[key]
+				style := p.entryStyle(flags[key])
+				entryTranslator := translator
+				var recorder *backendRecorder
+				if chain, ok := translator.(*fallbackTranslator); ok {
+					recorder = &backendRecorder{chain: chain}
+					entryTranslator = recorder
+				}
+				if isPlural {
+					forms, cached, issue, err = p.translatePlural(msgctxt, msgid, msgidPlural, contexts[key], style, sourceLang, targetLang, nplurals, delay, entryTranslator)
+				} else {
+					translated, cached, issue, err = p.translateInContext(entryTranslator, msgctxt, msgid, contexts[key], style, sourceLang, targetLang)
+				}
+				done := processed.Add(1)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\nWarning: Translation failed for '%s': %v\n", msgid, err)
+					p.releaseLimit()
+					mu.Lock()
+					result.failed++
+					mu.Unlock()
+					progress.Add(1)
+					continue
+				}
+
+				if issue != "" {
+					fmt.Fprintf(os.Stderr, "\nWarning: %s for '%s', marking as fuzzy\n", issue, msgid)
+				}
+				if p.Verbose {
+					fmt.Fprintf(p.Output, "\nTranslated '%s' into %s %s\n", msgid, targetLang, p.translatedBy(cached, recorder))
+				}
+
+				mu.Lock()
+				if isPlural {
+					result.plural[key] = forms
+				} else {
+					result.singular[key] = translated
+				}
+				if issue != "" {
+					result.needsReview[key] = true
+				}
+				result.count++
+				if unflushed++; flush != nil && unflushed == p.FlushEvery {
+					unflushed = 0
+					if err := flush(result); err != nil {
+						fmt.Fprintf(os.Stderr, "\nWarning: Could not save the translations of %s so far: %v\n", name, err)
+					}
+				}
+				mu.Unlock()
+				progress.Add(1)
+
+				// Rate limiting, cached translations don't reach the backend
+				if !cached && !p.interrupted.Load() && !p.limitReached() && done < int64(len(keys)) {
+					p.Sleeper.Sleep(delay)
+				}
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		if p.interrupted.Load() {
+			break
+		}
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	progress.Finish()
+
+	if p.Interactive {
+		p.reviewTranslations(keys, result)
+	}
+	return result
+}
+
+// translatedBy says which backend translated an entry for --verbose: the
+// backends of the --fallback chain that translated its texts, or --backend.
+// Cached entries and texts without letters don't need the backend.
+func (p *Processor) translatedBy(cached bool, recorder *backendRecorder) string {
+	names := []string{p.Backend}
+	if recorder != nil {
+		names = recorder.names()
+	}
+	switch {
+	case cached || len(names) == 0:
+		return "without the backend"
+	case len(names) > 1:
+		return "with the " + strings.Join(names, " and ") + " backends"
+	}
+	return "with the " + names[0] + " backend"
+}
+
+// translateString translates a single text, protecting its placeholders
+// according to --placeholder-style and its surrounding whitespace. Texts
+// without any letters are copied verbatim. It reports whether the backend was
+// skipped (cached or nothing to translate), and describes the issue when the
+// translation needs review, like lost placeholders or broken markup.
+func (p *Processor) translateString(translator Translator, text, sourceLang, targetLang string) (string, bool, string, error) {
+	return p.translateInContext(translator, "", text, "", p.entryStyle(nil), sourceLang, targetLang)
+}
+
+// translateInContext translates a single text of an entry with the msgctxt
+// like translateString, protecting the placeholders of the style and sending
+// the context of a "#. potranslate: context=..." directive along with it.
+// When the backend doesn't keep the context apart, the text is translated
+// again without it.
+func (p *Processor) translateInContext(translator Translator, msgctxt, text, context, style, sourceLang, targetLang string) (string, bool, string, error) {
+	leading, core, trailing := splitWhitespace(text)
+	if !hasLetters(protectedRemainder(core, style)) {
+		return text, true, "", nil
+	}
+
+	masked, placeholders := protectPlaceholders(core, style)
+
+	translated, cached, err := p.cachedTranslate(translator, msgctxt, withContext(masked, context), sourceLang, targetLang)
+	if err != nil {
+		return "", false, "", err
+	}
+	translated, kept := withoutContext(translated, context)
+	if !kept {
+		if translated, cached, err = p.cachedTranslate(translator, msgctxt, masked, sourceLang, targetLang); err != nil {
+			return "", false, "", err
+		}
+	}
+	restored, ok := restorePlaceholders(strings.TrimSpace(translated), placeholders)
+
+	// A printf call crashes on a specifier the msgid doesn't have
+	mismatch := formatMismatch(core, restored, style)
+	if mismatch != "" && p.RejectFormatMismatch {
+		return "", false, "", fmt.Errorf("the translation %s", mismatch)
+	}
+
+	issue := ""
+	if !ok {
+		issue = "Placeholders were not preserved"
+	} else if mismatch != "" {
+		issue = "The translation " + mismatch
+	} else if p.CheckMarkup && !markupMatches(core, restored) {
+		issue = "Markup tags don't match"
+	}
+	return leading + restored + trailing, cached, issue, nil
+}
+
+// splitWhitespace splits text into its leading whitespace, its core and its
+// trailing whitespace.
+func splitWhitespace(text string) (string, string, string) {
+	core := strings.TrimLeftFunc(text, unicode.IsSpace)
+	leading := text[:len(text)-len(core)]
+	core = strings.TrimRightFunc(core, unicode.IsSpace)
+	trailing := text[len(leading)+len(core):]
+	return leading, core, trailing
+}
+
+// hasLetters reports whether text contains any letter, and so anything worth
+// sending to the translator.
+func hasLetters(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
+
+// translatePlural translates the singular and plural source strings of a
+// plural entry into nplurals forms. The translator can't distinguish between
+// the plural cases of languages with more than two forms, so the plural
+// translation is duplicated across the remaining forms. Languages with a
+// single form, or a failed plural translation, fall back to the singular.
+func (p *Processor) translatePlural(msgctxt, msgid, msgidPlural, context, style, sourceLang, targetLang string, nplurals int, delay time.Duration, translator Translator) ([]string, bool, string, error) {
+	singular, cached, issue, err := p.translateInContext(translator, msgctxt, msgid, context, style, sourceLang, targetLang)
+	if err != nil {
+		return nil, false, "", err
+	}
+
+	plural := singular
+	if nplurals > 1 && msgidPlural != "" {
+		if !cached {
+			p.Sleeper.Sleep(delay)
+		}
+		translated, pluralCached, pluralIssue, err := p.translateInContext(translator, msgctxt, msgidPlural, context, style, sourceLang, targetLang)
+		if err == nil {
+			plural = translated
+			if issue == "" {
+				issue = pluralIssue
+			}
+		}
+		cached = cached && pluralCached
+	}
+
+	return pluralCopies(singular, plural, nplurals), cached, issue, nil
+}
+
+// pluralCopies returns nplurals forms, the singular followed by copies of the
+// plural.
+func pluralCopies(singular, plural string, nplurals int) []string {
+	forms := make([]string, nplurals)
+	for n := range forms {
+		if n == 0 {
+			forms[n] = singular
+		} else {
+			forms[n] = plural
+		}
+	}
+	return forms
+}
+
+// RewritePoFile completely rewrites a PO file based on the POT file structure,
+// maintaining existing translations but removing obsolete entries and their comments.
+func (p *Processor) RewritePoFile(poFile string, potEntries map[string]POEntry, sourceLang, targetLang string, delay time.Duration, translator Translator) (TranslationResult, error) {
+	// Read existing PO file to get current translations
+	existingTranslations := make(map[string]string)
+	existingFlags := make(map[string][]string)
+	existingPrevious := make(map[string]string)
+	existingComments := make(map[string][]string) // Translator comments
+	existingPlurals := make(map[string]POEntry)   // msgid_plural and msgstr[N] of plural entries
+	existingLines := make(map[string][]string)    // Keyword and continuation lines, as they were read
+	var existingOrder []string
+
+	content, err := p.readCatalog(poFile)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	writer, err := p.newPoWriter(poFile, content)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+
+	lines, lineEnding := splitLines(string(content))
+	lines, _, err = p.checkPoStrings(poFile, lines)
+	if err != nil {
+		return TranslationResult{}, err
+	}
+	headerLines, lines := splitHeader(lines)
+	nplurals := parsePluralCount(headerLines)
+	var currentMsgctxt, currentMsgid, currentMsgstr, currentMsgidPlural string
+	var currentMsgstrs, currentLines []string
+	var inMsgctxt, inMsgid, inMsgstr, inMsgidPlural, inMsgstrs, hasMsgctxt, hasMsgstr bool
+	var msgidLine int
+	var currentFlags, pendingFlags []string
+	var currentPrevious, pendingPrevious previousMsgid
+	var currentComments, pendingComments entryComments
+	var obsoleteLines []string
+	var obsoleteFlags [][]string
+	deduped := 0
+
+	// With --normalize-newlines, existing translations are matched to the POT
+	// msgid that only differs in a trailing newline
+	canonical := p.canonicalKeys(potEntries)
+	saveTranslation := func() {
+		key := entryKey(currentMsgctxt, currentMsgid)
+		if potKey, exists := canonical[p.normalizedKey(key)]; exists && potKey != key {
+			_, msgid := splitEntryKey(potKey)
+			key = potKey
+			currentMsgstr = matchTrailingNewline(msgid, currentMsgstr)
+			for n, form := range currentMsgstrs {
+				currentMsgstrs[n] = matchTrailingNewline(msgid, form)
+			}
+		}
+		if _, exists := existingTranslations[key]; !exists {
+			existingOrder = append(existingOrder, key)
+		} else if !p.Dedupe {
+			warnDuplicate(poFile, key)
+		} else {
+			// The first translated duplicate is kept
+			deduped++
+			if hasTranslation(existingTranslations[key], existingPlurals[key].Msgstrs) {
+				return
+			}
+		}
+		existingTranslations[key] = currentMsgstr
+		if currentMsgidPlural != "" {
+			existingPlurals[key] = POEntry{MsgidPlural: currentMsgidPlural, Msgstrs: currentMsgstrs}
+		} else {
+			delete(existingPlurals, key)
+		}
+		existingFlags[key] = currentFlags
+		existingPrevious[key] = currentPrevious.value
+		existingComments[key] = currentComments.Translator
+		existingLines[key] = currentLines
+	}
+	// endEntry saves the current entry, if any. Entries are separated by
+	// blank lines, but an entry without msgstr ends at the next keyword.
+	endEntry := func() {
+		if currentMsgid == "" {
+			return
+		}
+		if !hasMsgstr {
+			warnMissingMsgstr(poFile, msgidLine)
+		}
+		saveTranslation()
+		currentMsgid = ""
+		currentMsgstr = ""
+	}
+
+	// Extract existing translations
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Collect obsolete entries, separated by empty lines, with the flags
+		// preceding them
+		if strings.HasPrefix(trimmed, "#~") {
+			if len(obsoleteLines) == 0 || obsoleteLines[len(obsoleteLines)-1] == "" {
+				obsoleteFlags = append(obsoleteFlags, pendingFlags)
+				pendingFlags = nil
+				pendingComments = entryComments{}
+			}
+			obsoleteLines = append(obsoleteLines, strings.TrimSpace(trimmed[2:]))
+			continue
+		}
+		if len(obsoleteLines) > 0 && obsoleteLines[len(obsoleteLines)-1] != "" {
+			obsoleteLines = append(obsoleteLines, "")
+		}
+
+		if strings.HasPrefix(trimmed, "msgctxt ") {
+			endEntry()
+			currentMsgctxt = extractString(trimmed[8:])
+			currentLines = []string{line}
+			hasMsgctxt = true
+			inMsgctxt = true
+			inMsgid = false
+			inMsgstr = false
+		} else if strings.HasPrefix(trimmed, "msgid ") {
+			endEntry()
+			if !hasMsgctxt {
+				currentMsgctxt = ""
+				currentLines = nil
+			}
+			currentLines = append(currentLines, line)
+			hasMsgctxt = false
+			inMsgctxt = false
+			currentMsgid = extractString(trimmed[6:])
+			msgidLine = len(headerLines) + i + 1
+			hasMsgstr = false
+			currentMsgstr = ""
+			currentFlags = pendingFlags
+			pendingFlags = nil
+			currentPrevious = pendingPrevious
+			pendingPrevious = previousMsgid{}
+			currentComments = pendingComments
+			pendingComments = entryComments{}
+			currentMsgidPlural = ""
+			currentMsgstrs = nil
+			inMsgid = true
+			inMsgstr = false
+			inMsgidPlural = false
+			inMsgstrs = false
+		} else if strings.HasPrefix(trimmed, "msgid_plural ") {
+			currentMsgidPlural = extractString(trimmed[13:])
+			currentLines = append(currentLines, line)
+			inMsgid = false
+			inMsgidPlural = true
+		} else if _, form, ok := parsePluralMsgstr(trimmed); ok {
+			currentMsgstrs = append(currentMsgstrs, form)
+			currentLines = append(currentLines, line)
+			hasMsgstr = true
+			inMsgid = false
+			inMsgidPlural = false
+			inMsgstrs = true
+		} else if strings.HasPrefix(trimmed, "msgstr ") {
+			currentMsgstr = extractString(trimmed[7:])
+			currentLines = append(currentLines, line)
+			hasMsgstr = true
+			inMsgid = false
+			inMsgstr = true
+		} else if strings.HasPrefix(trimmed, "\"") {
+			currentLines = append(currentLines, line)
+			if inMsgctxt {
+				currentMsgctxt += extractString(trimmed)
+			} else if inMsgid {
+				currentMsgid += extractString(trimmed)
+			} else if inMsgidPlural {
+				currentMsgidPlural += extractString(trimmed)
+			} else if inMsgstrs {
+				currentMsgstrs[len(currentMsgstrs)-1] += extractString(trimmed)
+			} else if inMsgstr {
+				currentMsgstr += extractString(trimmed)
+			}
+		} else if trimmed == "" {
+			endEntry()
+			inMsgctxt = false
+			inMsgid = false
+			inMsgstr = false
+			inMsgidPlural = false
+			inMsgstrs = false
+		} else if strings.HasPrefix(trimmed, "#,") {
+			pendingFlags = mergeFlags(pendingFlags, parseFlags(trimmed))
+		} else if strings.HasPrefix(trimmed, "#|") {
+			pendingPrevious.add(trimmed)
+		} else if strings.HasPrefix(trimmed, "#") {
+			pendingComments.add(line)
+		}
+	}
+
+	// Save last translation if exists
+	endEntry()
+
+	// Entries that became obsolete, in file order, followed by the entries
+	// that were already obsolete. Obsolete entries back in the POT are revived.
+	var obsoleteKeys []string
+	removedCount := 0
+	for _, key := range existingOrder {
+		if _, exists := potEntries[key]; !exists {
+			removedCount++
+			if hasTranslation(existingTranslations[key], existingPlurals[key].Msgstrs) {
+				obsoleteKeys = append(obsoleteKeys, key)
+			}
+		}
+	}
+	newlyObsolete := len(obsoleteKeys)
+	previousKeys, previousEntries := parseObsoleteEntries(obsoleteLines)
+	for n, key := range previousKeys {
+		if hasTranslation(existingTranslations[key], existingPlurals[key].Msgstrs) {
+			continue
+		}
+		existingTranslations[key] = previousEntries[key].Msgstr
+		if previousEntries[key].MsgidPlural != "" {
+			existingPlurals[key] = previousEntries[key]
+		}
+		if n < len(obsoleteFlags) {
+			existingFlags[key] = obsoleteFlags[n]
+		}
+		if _, exists := potEntries[key]; !exists {
+			obsoleteKeys = append(obsoleteKeys, key)
+		}
+	}
+
+	// Fuzzy entries don't count as translated
+	retranslate := func(key string) bool {
+		comments := slices.Concat(existingComments[key], potEntries[key].Comments.Extracted)
+		return hasFlag(existingFlags[key], "fuzzy") || p.forceRetranslation(existingFlags[key], comments)
+	}
+
+	// Count entries that need translation. Plural entries get the msgstr[N]
+	// forms of the Plural-Forms header, and need translation when any of them
+	// is empty.
+	var needsTranslation []string
+	pluralSources := make(map[string]string)
+	contexts := make(map[string]string)
+	flags := make(map[string][]string)
+	added := 0
+	for _, key := range p.entryOrder(potEntries) {
+		if key == "" {
+			continue
+		}
+		existingTrans, exists := existingTranslations[key]
+		if !exists {
+			added++
+		}
+		if p.filteredByRef(potEntries[key]) || p.filteredBySince(potEntries[key]) || skippedByDirective(potEntries[key]) {
+			continue
+		}
+		if context := directiveContext(potEntries[key]); context != "" {
+			contexts[key] = context
+		}
+		flags[key] = potEntries[key].Flags
+		if msgidPlural := potEntries[key].MsgidPlural; msgidPlural != "" {
+			forms := pluralForms(existingTrans, existingPlurals[key].Msgstrs, nplurals)
+			if slices.Contains(forms, "") || retranslate(key) {
+				needsTranslation = append(needsTranslation, key)
+				pluralSources[key] = msgidPlural
+			}
+		} else if singularTranslation(existingTrans, existingPlurals[key].Msgstrs) == "" || retranslate(key) {
+			needsTranslation = append(needsTranslation, key)
+		}
+	}
+
+	// rewrittenLines builds the new PO file from the POT structure, with the
+	// translations of the result
+	rewrittenLines := func(result *entryTranslations) []string {
+		translations, pluralTranslations := result.singular, result.plural
+
+		var newLines []string
+
+		// Add header exactly as it was
+		newLines = append(newLines, headerLines...)
+
+		// Add all entries from POT in order
+		for _, key := range p.entryOrder(potEntries) {
+			if key == "" {
+				continue
+			}
+			potEntry := potEntries[key]
+			msgctxt, msgid := splitEntryKey(key)
+
+			newLines = append(newLines, "")
+
+			// Add comments from POT, with the translator comments of the old PO
+			// only for --keep-translator-comments
+			comments := potEntry.Comments
+			if p.KeepTranslatorComments && len(existingComments[key]) > 0 {
+				comments.Translator = existingComments[key]
+			}
+
+			// Add flags from POT and PO, resolving fuzzy on fresh translations,
+			// and the previous msgid right before the msgid
+			flags := mergeFlags(potEntry.Flags, existingFlags[key])
+			previous := existingPrevious[key]
+			_, translated := translations[key]
+			if _, exists := pluralTranslations[key]; exists {
+				translated = true
+			}
+			if translated {
+				flags = p.translatedFlags(flags, result.needsReview[key])
+				if p.ClearPrevious && !result.needsReview[key] {
+					previous = ""
+				}
+			}
+			newLines = append(newLines, p.formatEntryComments(comments, flags, previous)...)
+
+			// Add msgctxt and msgid, with the lines of the old PO when the
+			// strings are unchanged
+			original := existingLines[key]
+			if msgctxt != "" {
+				newLines = append(newLines, formatKeptString(original, "msgctxt", msgctxt, p.entryWidth(flags))...)
+			}
+			newLines = append(newLines, formatKeptString(original, "msgid", msgid, p.entryWidth(flags))...)
+
+			// Add the msgstr[N] forms of plural entries, keeping the existing
+			// forms and filling the empty ones
+			if potEntry.MsgidPlural != "" {
+				forms := pluralForms(existingTranslations[key], existingPlurals[key].Msgstrs, nplurals)
+				if translatedForms, exists := pluralTranslations[key]; exists {
+					for n := range forms {
+						if forms[n] == "" || retranslate(key) {
+							forms[n] = translatedForms[n]
+						}
+					}
+				}
+				newLines = append(newLines, formatPluralStrings(original, potEntry.MsgidPlural, forms, p.entryWidth(flags))...)
+				continue
+			}
+
+			// Add msgstr (from existing translation, new translation, or empty)
+			msgstr := singularTranslation(existingTranslations[key], existingPlurals[key].Msgstrs)
+			if trans, exists := translations[key]; exists {
+				msgstr = trans
+			}
+
+			newLines = append(newLines, formatKeptString(original, "msgstr", msgstr, p.entryWidth(flags))...)
+		}
+
+		// Keep obsolete entries as #~ comments, so their translations survive
+		if !p.PurgeObsolete {
+			for _, key := range obsoleteKeys {
+				msgctxt, msgid := splitEntryKey(key)
+				flags := existingFlags[key]
+
+				newLines = append(newLines, "")
+				if len(flags) > 0 {
+					newLines = append(newLines, formatFlags(flags))
+				}
+				var entryLines []string
+				if msgctxt != "" {
+					entryLines = append(entryLines, formatKeptString(existingLines[key], "msgctxt", msgctxt, p.entryWidth(flags))...)
+				}
+				entryLines = append(entryLines, formatKeptString(existingLines[key], "msgid", msgid, p.entryWidth(flags))...)
+				if plural, exists := existingPlurals[key]; exists {
+					entryLines = append(entryLines, formatPluralStrings(existingLines[key], plural.MsgidPlural, plural.Msgstrs, p.entryWidth(flags))...)
+				} else {
+					entryLines = append(entryLines, formatKeptString(existingLines[key], "msgstr", existingTranslations[key], p.entryWidth(flags))...)
+				}
+				for _, entryLine := range entryLines {
+					newLines = append(newLines, "#~ "+entryLine)
+				}
+			}
+		}
+		return newLines
+	}
+	flush := func(partial *entryTranslations) error {
+		return writer.write(joinLines(p.stampHeader(rewrittenLines(partial)), lineEnding))
+	}
+
+	// Translate missing entries
+	result := p.translateEntries(filepath.Base(poFile), needsTranslation, pluralSources, contexts, flags, nplurals, sourceLang, targetLang, delay, translator, flush)
+	p.checkLengths(filepath.Base(poFile), potEntries, result)
+	newLines := rewrittenLines(result)
+
+	// Write the new PO file, if it changed
+	if newContent := keepFinalNewline(joinLines(newLines, lineEnding), string(content)); newContent != string(content) {
+		newContent = joinLines(p.stampHeader(newLines), lineEnding)
+		if err := writer.write(newContent); err != nil {
+			return TranslationResult{}, fmt.Errorf("failed to write rewritten PO file: %v", err)
+		}
+	} else {
+		// Nothing to translate or prune, so the file is already complete
+		if len(needsTranslation) == 0 {
+			fmt.Fprintln(p.Output, "Up to date")
+		}
+		if err := writer.writeUnchanged(newContent); err != nil {
+			return TranslationResult{}, fmt.Errorf("failed to write rewritten PO file: %v", err)
+		}
+	}
+
+	if p.PurgeObsolete {
+		newlyObsolete = 0
+	}
+	if newlyObsolete > 0 {
+		fmt.Fprintf(p.Output, "Marked %d obsolete entry/entries\n", newlyObsolete)
+	}
+	if removedCount > newlyObsolete {
+		fmt.Fprintf(p.Output, "Removed %d obsolete entry/entries\n", removedCount-newlyObsolete)
+	}
+	if deduped > 0 {
+		fmt.Fprintf(p.Output, "Collapsed %d duplicate entry/entries\n", deduped)
+	}
+
+	fileResult := TranslationResult{Added: added, Removed: removedCount - newlyObsolete, Deduped: deduped}
+	fileResult.addEntries(result, len(needsTranslation), p.NoNetwork)
+	return fileResult, nil
+}
+
+// splitHeader splits the lines of a PO file after the header entry. The
+// header is everything up to and including the last msgstr line of the entry
+// with the empty msgid, so the comments and flags before it are kept. Without
+// a header entry, all lines are returned as the rest.
+func splitHeader(lines []string) ([]string, []string) {
+	blocks, _ := parsePoLines(lines)
+	n := 0
+	for _, block := range blocks {
+		n += len(block.other) + len(block.comments) + len(block.keyLines) + len(block.msgstrLines)
+		if block.isHeader() {
+			return lines[:n], lines[n:]
+		}
+		if block.isEntry {
+			break
+		}
+	}
+	return nil, lines
+}
+
+// parseObsoleteEntries parses the lines of #~ obsolete entries, with the #~
+// prefix removed and empty lines between the entries. It returns the entry
+// keys in order and their translations, with the msgid_plural and msgstr[N]
+// forms of plural entries.
+func parseObsoleteEntries(lines []string) ([]string, map[string]POEntry) {
+	var keys []string
+	entries := make(map[string]POEntry)
+	var msgctxt, msgid string
+	var entry POEntry
+	var target *string
+	hasEntry := false
+
+	save := func() {
+		if hasEntry && msgid != "" {
+			key := entryKey(msgctxt, msgid)
+			if _, exists := entries[key]; !exists {
+				keys = append(keys, key)
+			}
+			entries[key] = entry
+		}
+		msgctxt, msgid, entry = "", "", POEntry{}
+		target = nil
+		hasEntry = false
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "msgctxt ") {
+			save()
+			msgctxt = extractString(line[8:])
+			target = &msgctxt
+		} else if strings.HasPrefix(line, "msgid ") {
+			if msgid != "" {
+				save()
+			}
+			hasEntry = true
+			msgid = extractString(line[6:])
+			target = &msgid
+		} else if strings.HasPrefix(line, "msgid_plural ") {
+			entry.MsgidPlural = extractString(line[13:])
+			target = &entry.MsgidPlural
+		} else if _, form, ok := parsePluralMsgstr(line); ok {
+			entry.Msgstrs = append(entry.Msgstrs, form)
+			target = &entry.Msgstrs[len(entry.Msgstrs)-1]
+		} else if strings.HasPrefix(line, "msgstr ") {
+			entry.Msgstr = extractString(line[7:])
+			target = &entry.Msgstr
+		} else if strings.HasPrefix(line, "\"") && target != nil {
+			*target += extractString(line)
+		} else if line == "" {
+			save()
+		} else {
+			// Other keywords aren't kept
+			target = nil
+		}
+	}
+	save()
+
+	return keys, entries
+}
+
+// hasTranslation reports whether an entry has a translation, in its msgstr
+// or any of its msgstr[N] forms.
+func hasTranslation(msgstr string, forms []string) bool {
+	return msgstr != "" || slices.ContainsFunc(forms, func(form string) bool { return form != "" })
+}
+
+// singularTranslation returns the msgstr of an entry, or the first form of an
+// entry that was plural before.
+func singularTranslation(msgstr string, forms []string) string {
+	if msgstr == "" && len(forms) > 0 {
+		return forms[0]
+	}
+	return msgstr
+}
+
+// pluralForms returns the nplurals msgstr[N] forms of an entry, from its
+// existing forms, or its msgstr for an entry that wasn't plural before.
+// Missing forms are empty and extra forms are dropped.
+func pluralForms(msgstr string, forms []string, nplurals int) []string {
+	if len(forms) == 0 {
+		forms = []string{msgstr}
+	}
+	result := make([]string, nplurals)
+	copy(result, forms)
+	return result
+}
+
+// formatPluralStrings renders the msgid_plural and msgstr[N] lines of a
+// plural entry, keeping the unchanged strings of the original lines.
+func formatPluralStrings(original []string, msgidPlural string, forms []string, width int) []string {
+	lines := formatKeptString(original, "msgid_plural", msgidPlural, width)
+	for n, form := range forms {
+		lines = append(lines, formatKeptString(original, fmt.Sprintf("msgstr[%d]", n), form, width)...)
+	}
+	return lines
+}
+
+// writePotLanguage stores the source language in the POT file, unless the
+// sources are left untouched by writing to --out-dir or showing a --diff.
+func (p *Processor) writePotLanguage(potFile, language string) {
+	if p.OutDir != "" || p.Diff {
+		return
+	}
+	if err := p.updatePotLanguage(potFile, language); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not update POT file metadata: %v\n", err)
+	} else {
+		fmt.Fprintf(p.Output, "Updated POT file with source language: %s\n", language)
+	}
+}
+
+func (p *Processor) updatePotLanguage(potFile, language string) error {
+	content, err := p.readCatalog(potFile)
+	if err != nil {
+		return err
+	}
+
+	lines, lineEnding := splitLines(string(content))
+	blocks, _ := parsePoLines(lines)
+	updated := false
+	for _, block := range blocks {
+		if !block.isHeader() {
+			continue
+		}
+		// Add the Language header after Content-Type if not found
+		if updated = block.replaceHeaderField("Language", language); !updated {
+			if _, end := block.headerField("Content-Type"); end >= 0 {
+				block.insertHeaderField(end, "Language", language)
+				updated = true
+			}
+		}
+		break
+	}
+
+	if !updated {
+		return fmt.Errorf("could not find appropriate place to insert Language header")
+	}
+
+	newContent := joinLines(p.formatPoLines(blocks), lineEnding)
+	return p.writeCatalog(potFile, []byte(newContent))
+}
+
+// addLanguage creates the PO file of the language from the POT file for
+// --add-lang, fills in the translations of the --seed-from file and
+// translates the rest. An existing PO file is left alone with a warning.
+func (p *Processor) addLanguage(directory, root, domain, potFile, lang, seedFile string, potEntries map[string]POEntry, sourceLang string, delay time.Duration, translator Translator, report *Report) (TranslationResult, error) {
+	newPoFile := p.poFilePath(directory, domain, lang, p.Layout)
+	if strings.HasSuffix(potFile, gzipExt) {
+		// New catalogs are compressed like their template
+		newPoFile += gzipExt
+	}
+
+	// Check if file already exists
+	if _, err := os.Stat(newPoFile); err == nil {
+		fmt.Fprintf(os.Stderr, "Warning: PO file '%s' already exists, skipping %s\n", newPoFile, lang)
+		return TranslationResult{}, nil
+	}
+
+	fmt.Fprintf(p.Output, "\nCreating new language file: %s\n", relativePath(root, newPoFile))
+
+	// With --out-dir the new file is created there instead
+	outFile := p.outputFile(root, newPoFile)
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return TranslationResult{}, fmt.Errorf("creating directory: %v", err)
+	}
+
+	// Copy POT to new PO file
+	if err := p.CopyPotToPo(potFile, outFile, lang); err != nil {
+		return TranslationResult{}, fmt.Errorf("creating PO file: %v", err)
+	}
+
+	if p.OutDir != "" {
+		fmt.Fprintf(p.Output, "Created: %s\n", outFile)
+	} else {
+		fmt.Fprintf(p.Output, "Created: %s\n", relativePath(root, newPoFile))
+	}
+
+	// Translations of the seed language only leave the rest to translate
+	if seedFile != "" {
+		seeded, err := p.seedPoFile(outFile, seedFile)
+		if err != nil {
+			return TranslationResult{}, fmt.Errorf("seeding PO file: %v", err)
+		}
+		fmt.Fprintf(p.Output, "Seeded %d translation(s) from %s\n", seeded, relativePath(root, seedFile))
+	}
+	fmt.Fprintf(p.Output, "Translating to: %s\n\n", lang)
+
+	// Translate the new file
+	result, err := p.TranslatePoFile(outFile, potEntries, translatorLanguage(sourceLang), translatorLanguage(lang), delay, translator)
+	if err != nil {
+		return TranslationResult{}, fmt.Errorf("translating new PO file: %v", err)
+	}
+
+	fmt.Fprintf(p.Output, "%s\n\n", result.Summary())
+	if report != nil {
+		p.addFileReport(report, root, newPoFile, domain, lang, potEntries, nil, result.Translated)
+	}
+	return result, nil
+}
+
+// CopyPotToPo creates a new PO file from the POT template with the specified
+// language. The Plural-Forms header is set to the rule of the language, when
+// it is known, instead of the one of the template.
+func (p *Processor) CopyPotToPo(potFile, newPoFile, targetLang string) error {
+	// Read POT file
+	content, err := p.readCatalog(potFile)
+	if err != nil {
+		return fmt.Errorf("failed to read POT file: %v", err)
+	}
+
+	lines, lineEnding := splitLines(string(content))
+	blocks, _ := parsePoLines(lines)
+	for _, block := range blocks {
+		if !block.isHeader() {
+			continue
+		}
+		block.replaceHeaderField("Language", targetLang)
+		block.replaceHeaderField("Language-Team", strings.ToUpper(targetLang))
+		block.replaceHeaderField("PO-Revision-Date", timeNow().Format("2006-01-02 15:04-0700"))
+		// Use the plural rule of the language, added at the end of the header
+		// entry when it has none
+		if rule, knownRule := pluralRule(targetLang); knownRule {
+			block.setHeaderField("Plural-Forms", rule)
+		}
+		break
+	}
+	newLines := p.formatPoLines(blocks)
+
+	// Write to new PO file
+	newContent := joinLines(newLines, lineEnding)
+	if err := p.writeCatalog(newPoFile, []byte(newContent)); err != nil {
+		return fmt.Errorf("failed to write PO file: %v", err)
+	}
+
+	return nil
+}
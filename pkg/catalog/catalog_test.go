@@ -0,0 +1,3277 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExtractString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "simple quoted string",
+			input:    `"Hello, World!"`,
+			expected: "Hello, World!",
+		},
+		{
+			name:     "string with newline escape",
+			input:    `"Hello\nWorld"`,
+			expected: "Hello\nWorld",
+		},
+		{
+			name:     "string with tab escape",
+			input:    `"Hello\tWorld"`,
+			expected: "Hello\tWorld",
+		},
+		{
+			name:     "string with escaped quote",
+			input:    `"Hello \"World\""`,
+			expected: `Hello "World"`,
+		},
+		{
+			name:     "string with escaped backslash",
+			input:    `"C:\\path\\to"`,
+			expected: `C:\path\to`,
+		},
+		{
+			name:     "escaped backslash before n",
+			input:    `"a\\nb"`,
+			expected: `a\nb`,
+		},
+		{
+			name:     "string with carriage return",
+			input:    `"Hello\r\n"`,
+			expected: "Hello\r\n",
+		},
+		{
+			name:     "string with bell, backspace, form feed and vertical tab",
+			input:    `"\a\b\f\v"`,
+			expected: "\a\b\f\v",
+		},
+		{
+			name:     "string with single quote and question mark escapes",
+			input:    `"\'\?"`,
+			expected: "'?",
+		},
+		{
+			name:     "string with octal escapes",
+			input:    `"\101\0\0334"`,
+			expected: "A\x00\x1b4",
+		},
+		{
+			name:     "string with hex escapes",
+			input:    `"\x41\x1bZ\x7"`,
+			expected: "A\x1bZ\x07",
+		},
+		{
+			name:     "unknown escapes are kept",
+			input:    `"\q\x\8"`,
+			expected: `\q\x\8`,
+		},
+		{
+			name:     "trailing backslash",
+			input:    `"end\"`,
+			expected: `end\`,
+		},
+		{
+			name:     "unquoted string",
+			input:    "Hello",
+			expected: "Hello",
+		},
+		{
+			name:     "empty string",
+			input:    `""`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractString(tt.input)
+			if result != tt.expected {
+				t.Errorf("extractString(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "simple string",
+			input:    "Hello, World!",
+			expected: "Hello, World!",
+		},
+		{
+			name:     "string with newline",
+			input:    "Hello\nWorld",
+			expected: "Hello\\nWorld",
+		},
+		{
+			name:     "string with tab",
+			input:    "Hello\tWorld",
+			expected: "Hello\\tWorld",
+		},
+		{
+			name:     "string with quote",
+			input:    `Hello "World"`,
+			expected: `Hello \"World\"`,
+		},
+		{
+			name:     "string with backslash",
+			input:    `C:\path\to\file`,
+			expected: `C:\\path\\to\\file`,
+		},
+		{
+			name:     "string with carriage return",
+			input:    "Hello\r\n",
+			expected: `Hello\r\n`,
+		},
+		{
+			name:     "string with bell, backspace, form feed and vertical tab",
+			input:    "\a\b\f\v",
+			expected: `\a\b\f\v`,
+		},
+		{
+			name:     "string with other control characters",
+			input:    "\x00\x1b[0m\x7f",
+			expected: `\000\033[0m\177`,
+		},
+		{
+			name:     "backslash before n",
+			input:    `a\nb`,
+			expected: `a\\nb`,
+		},
+		{
+			name:     "unicode",
+			input:    "Größe ✓",
+			expected: "Größe ✓",
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := escapeString(tt.input)
+			if result != tt.expected {
+				t.Errorf("escapeString(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+			if back := extractString(`"` + result + `"`); back != tt.input {
+				t.Errorf("extractString(escapeString(%q)) = %q", tt.input, back)
+			}
+		})
+	}
+}
+
+func TestParsePotFile(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+
+	content := `# Test POT file
+msgid ""
+msgstr ""
+"Project-Id-Version: Test 1.0\n"
+"Language: en\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: test.py:10
+msgid "Hello, World!"
+msgstr ""
+
+#: test.py:15
+msgid "Goodbye"
+msgstr ""
+
+#: test.py:20
+msgid "Multi-line"
+" string"
+msgstr ""
+`
+
+	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test POT file: %v", err)
+	}
+
+	entries, sourceLang, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+
+	if sourceLang != "en" {
+		t.Errorf("Expected source language 'en', got %q", sourceLang)
+	}
+
+	expectedEntries := map[string]struct{}{
+		"Hello, World!":     {},
+		"Goodbye":           {},
+		"Multi-line string": {},
+	}
+
+	if len(entries) != len(expectedEntries) {
+		t.Errorf("Expected %d entries, got %d", len(expectedEntries), len(entries))
+	}
+
+	for msgid := range expectedEntries {
+		if _, exists := entries[msgid]; !exists {
+			t.Errorf("Expected entry %q not found in parsed entries", msgid)
+		}
+	}
+}
+
+func TestParsePotFileMalformed(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+
+	header := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\n"
+	tests := []struct {
+		name    string
+		content string
+		err     string
+		warning string
+	}{
+		{"unterminated msgid", "msgid \"Hello\nmsgstr \"\"\n", "test.pot:5: unterminated string", ""},
+		{"escaped quote at the end", "msgid \"Hello\"\nmsgstr \"\"\n\"more\\\"\n", "test.pot:7: unterminated string", ""},
+		{"missing quote", "msgid \"Hello\"\nmsgstr Hola\n", "test.pot:6: expected a quoted string after msgstr", ""},
+		{"text after the string", "msgid \"Hello\" world\nmsgstr \"\"\n", "test.pot:5: unexpected text after the end of the string", ""},
+		{"unexpected continuation", "msgid \"Hello\"\nmsgstr \"\"\n\n\"stray\"\n", "", "test.pot:8: unexpected continuation line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(header+tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test POT file: %v", err)
+			}
+			stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+			if err != nil {
+				t.Fatalf("Failed to create stderr file: %v", err)
+			}
+			defer stderr.Close()
+			os.Stderr = stderr
+
+			entries, _, err := p.ParsePotFile(potFile)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("ParsePotFile() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePotFile() error = %v", err)
+			}
+			if _, exists := entries["Hello"]; !exists {
+				t.Errorf("Expected the Hello entry, got %v", entries)
+			}
+			if written, _ := os.ReadFile(stderr.Name()); !strings.Contains(string(written), tt.warning) {
+				t.Errorf("Expected warning %q, got %q", tt.warning, written)
+			}
+		})
+	}
+}
+
+func TestParsePotFileNoLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+
+	content := `# Test POT file
+msgid ""
+msgstr ""
+"Project-Id-Version: Test 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr ""
+`
+
+	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test POT file: %v", err)
+	}
+
+	_, sourceLang, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+
+	if sourceLang != "" {
+		t.Errorf("Expected empty source language, got %q", sourceLang)
+	}
+}
+
+func TestGetTargetLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name         string
+		filename     string
+		content      string
+		expectedLang string
+		expectError  bool
+	}{
+		{
+			name:     "language from metadata",
+			filename: "test_es.po",
+			content: `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+`,
+			expectedLang: "es",
+			expectError:  false,
+		},
+		{
+			name:     "language from filename with underscore",
+			filename: "default_de.po",
+			content: `msgid ""
+msgstr ""
+
+msgid "Hello"
+msgstr ""
+`,
+			expectedLang: "de",
+			expectError:  false,
+		},
+		{
+			name:     "no language detectable",
+			filename: "test.po",
+			content: `msgid ""
+msgstr ""
+
+msgid "Hello"
+msgstr ""
+`,
+			expectedLang: "",
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poFile := filepath.Join(tempDir, tt.filename)
+			if err := os.WriteFile(poFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test PO file: %v", err)
+			}
+
+			lang, err := p.GetTargetLanguage(poFile, "default")
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if lang != tt.expectedLang {
+					t.Errorf("Expected language %q, got %q", tt.expectedLang, lang)
+				}
+			}
+		})
+	}
+}
+
+func TestFindPoFiles(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	testFiles := []string{
+		"default_es.po",
+		"default_fr.po",
+		"default_de.po",
+		"admin_es.po",
+		"admin_panel_es.po",
+		"admin_panel_pt_BR.po",
+		"other.po",
+		"default.pot",
+		"admin.pot",
+		"admin_panel.pot",
+	}
+
+	for _, filename := range testFiles {
+		path := filepath.Join(tempDir, filename)
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		domain        string
+		expectedCount int
+		expectedFiles []string
+	}{
+		{
+			name:          "default domain",
+			domain:        "default",
+			expectedCount: 3,
+			expectedFiles: []string{"default_es.po", "default_fr.po", "default_de.po"},
+		},
+		{
+			name:          "admin domain",
+			domain:        "admin",
+			expectedCount: 1,
+			expectedFiles: []string{"admin_es.po"},
+		},
+		{
+			name:          "domain with underscore",
+			domain:        "admin_panel",
+			expectedCount: 2,
+			expectedFiles: []string{"admin_panel_es.po", "admin_panel_pt_BR.po"},
+		},
+		{
+			name:          "nonexistent domain",
+			domain:        "nonexistent",
+			expectedCount: 0,
+			expectedFiles: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := p.FindPoFiles(tempDir, tt.domain, "flat")
+			if err != nil {
+				t.Fatalf("FindPoFiles() error = %v", err)
+			}
+
+			if len(files) != tt.expectedCount {
+				t.Errorf("Expected %d files, got %d", tt.expectedCount, len(files))
+			}
+
+			for _, expectedFile := range tt.expectedFiles {
+				found := false
+				for _, file := range files {
+					if filepath.Base(file) == expectedFile {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected file %q not found in results", expectedFile)
+				}
+				// Only the domain is stripped from the language
+				lang, err := p.GetTargetLanguage(filepath.Join(tempDir, expectedFile), tt.domain)
+				if expected := strings.TrimSuffix(strings.TrimPrefix(expectedFile, tt.domain+"_"), ".po"); err != nil || lang != expected {
+					t.Errorf("GetTargetLanguage(%s) = %q, %v, want %q", expectedFile, lang, err, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNamingSchemes(t *testing.T) {
+	p := New(DefaultOptions())
+
+	tests := []struct {
+		naming   string
+		files    []string
+		expected map[string]string
+	}{
+		{
+			naming:   "underscore",
+			files:    []string{"messages_es.po", "messages_pt_BR.po", "messages-fr.po", "messages.de.po"},
+			expected: map[string]string{"messages_es.po": "es", "messages_pt_BR.po": "pt_BR"},
+		},
+		{
+			naming:   "hyphen",
+			files:    []string{"messages-es.po", "messages-pt-BR.po", "messages_fr.po", "messages.de.po"},
+			expected: map[string]string{"messages-es.po": "es", "messages-pt-BR.po": "pt-BR"},
+		},
+		{
+			naming:   "dot",
+			files:    []string{"messages.es.po", "messages.pt_BR.po", "messages_fr.po", "messages-de.po", "messages.pot"},
+			expected: map[string]string{"messages.es.po": "es", "messages.pt_BR.po": "pt_BR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.naming, func(t *testing.T) {
+			p.Naming = tt.naming
+			tempDir := t.TempDir()
+			for _, filename := range tt.files {
+				// Without a Language header, the language comes from the name
+				content := "msgid \"\"\nmsgstr \"\"\n"
+				if err := os.WriteFile(filepath.Join(tempDir, filename), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			files, err := p.FindPoFiles(tempDir, "messages", "flat")
+			if err != nil {
+				t.Fatalf("FindPoFiles() error = %v", err)
+			}
+			if len(files) != len(tt.expected) {
+				t.Errorf("Expected %d files, got %v", len(tt.expected), files)
+			}
+			for _, file := range files {
+				lang, err := p.GetTargetLanguage(file, "messages")
+				if err != nil {
+					t.Errorf("GetTargetLanguage(%q) error = %v", filepath.Base(file), err)
+					continue
+				}
+				if want, exists := tt.expected[filepath.Base(file)]; !exists || lang != want {
+					t.Errorf("GetTargetLanguage(%q) = %q, want %q", filepath.Base(file), lang, want)
+				}
+			}
+
+			newFile := filepath.Base(p.poFilePath(tempDir, "messages", "it", "flat"))
+			if want := "messages" + namingSeparators[tt.naming] + "it.po"; newFile != want {
+				t.Errorf("poFilePath() = %q, want %q", newFile, want)
+			}
+		})
+	}
+}
+
+func TestUpdatePotLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	tests := []struct {
+		name        string
+		content     string
+		language    string
+		expectError bool
+	}{
+		{
+			name: "update existing language",
+			content: `msgid ""
+msgstr ""
+"Language: \n"
+"Content-Type: text/plain; charset=UTF-8\n"
+`,
+			language:    "en",
+			expectError: false,
+		},
+		{
+			name: "add language after Content-Type",
+			content: `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+`,
+			language:    "en",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+
+			if err := os.WriteFile(potFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test POT file: %v", err)
+			}
+
+			err := p.updatePotLanguage(potFile, tt.language)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+
+				_, sourceLang, err := p.ParsePotFile(potFile)
+				if err != nil {
+					t.Fatalf("Failed to parse updated POT file: %v", err)
+				}
+				if sourceLang != tt.language {
+					t.Errorf("Expected language %q, got %q", tt.language, sourceLang)
+				}
+			}
+		})
+	}
+}
+
+func TestAddMissingEntriesToPO(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	// Create a POT file with multiple entries
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+
+	// Create a PO file with only some entries
+	poFile := filepath.Join(tempDir, "test-es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	// Parse POT file
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// Call TranslatePoFile (which should add missing entries)
+	_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+
+	// Read the updated PO file
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+
+	updatedStr := string(updatedContent)
+
+	// Check that missing entries were added
+	if !strings.Contains(updatedStr, `msgid "World"`) {
+		t.Error("Missing entry 'World' was not added to PO file")
+	}
+	if !strings.Contains(updatedStr, `msgid "Goodbye"`) {
+		t.Error("Missing entry 'Goodbye' was not added to PO file")
+	}
+
+	// Check that existing entry is still there
+	if !strings.Contains(updatedStr, `msgid "Hello"`) {
+		t.Error("Existing entry 'Hello' was removed")
+	}
+	if !strings.Contains(updatedStr, `msgstr "Hola"`) {
+		t.Error("Existing translation 'Hola' was removed")
+	}
+}
+
+func TestAddedComment(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+	tests := []struct {
+		comment  string
+		expected string
+	}{
+		{comment: DefaultOptions().AddedComment, expected: "\n\n#. added from POT\nmsgid \"World\"\nmsgstr \"es:World\"\n"},
+		{comment: "new in 2.0", expected: "\n\n#. new in 2.0\nmsgid \"World\"\nmsgstr \"es:World\"\n"},
+		{comment: "", expected: "\n\nmsgid \"World\"\nmsgstr \"es:World\"\n"},
+	}
+	for _, tt := range tests {
+		p.AddedComment = tt.comment
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test-es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+		if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+			t.Fatalf("TranslatePoFile failed: %v", err)
+		}
+
+		content, _ := os.ReadFile(poFile)
+		if !strings.HasSuffix(string(content), tt.expected) {
+			t.Errorf("comment %q: expected the entry %q at the end of:\n%s", tt.comment, tt.expected, content)
+		}
+		if tt.comment == "" && strings.Contains(string(content), "#") {
+			t.Errorf("Expected no comment on the added entry, got:\n%s", content)
+		}
+	}
+}
+
+func TestCommentsAreCopiedFromPOT(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	// Create a POT file with comments
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#: source.py:10
+msgid "First"
+msgstr ""
+
+#: source.py:20
+#: source.py:30
+msgid "Second"
+msgstr ""
+
+#. Translator comment
+#: source.py:40
+msgid "Third"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+
+	// Create a PO file missing some entries
+	poFile := filepath.Join(tempDir, "test-es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+#: source.py:10
+msgid "First"
+msgstr "Primero"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	// Parse POT file
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// Call TranslatePoFile to add missing entries
+	_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+
+	// Read the updated PO file
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+
+	updatedStr := string(updatedContent)
+
+	// Check that comments were copied for "Second"
+	if !strings.Contains(updatedStr, "#: source.py:20") {
+		t.Error("Comment '#: source.py:20' was not copied from POT file")
+	}
+	if !strings.Contains(updatedStr, "#: source.py:30") {
+		t.Error("Comment '#: source.py:30' was not copied from POT file")
+	}
+
+	// Check that comments were copied for "Third"
+	if !strings.Contains(updatedStr, "#. Translator comment") {
+		t.Error("Translator comment was not copied from POT file")
+	}
+	if !strings.Contains(updatedStr, "#: source.py:40") {
+		t.Error("Comment '#: source.py:40' was not copied from POT file")
+	}
+}
+
+func TestCopyPotToPo(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		potContent string
+		targetLang string
+		wantError  bool
+	}{
+		{
+			name: "basic POT to PO conversion",
+			potContent: `# Translation Template
+msgid ""
+msgstr ""
+"Project-Id-Version: Test 1.0\n"
+"Language: en\n"
+"Language-Team: English\n"
+"PO-Revision-Date: 2024-01-01 12:00+0000\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: test.py:10
+msgid "Hello"
+msgstr ""
+
+#: test.py:20
+msgid "World"
+msgstr ""
+`,
+			targetLang: "es",
+			wantError:  false,
+		},
+		{
+			name: "POT with multi-line strings",
+			potContent: `msgid ""
+msgstr ""
+"Language: en\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "This is a "
+"multi-line string"
+msgstr ""
+`,
+			targetLang: "fr",
+			wantError:  false,
+		},
+		{
+			name: "POT without language metadata",
+			potContent: `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Test"
+msgstr ""
+`,
+			targetLang: "de",
+			wantError:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			potFile := filepath.Join(tempDir, tt.name+".pot")
+			if err := os.WriteFile(potFile, []byte(tt.potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+
+			newPoFile := filepath.Join(tempDir, tt.name+"-"+tt.targetLang+".po")
+
+			err := p.CopyPotToPo(potFile, newPoFile, tt.targetLang)
+			if (err != nil) != tt.wantError {
+				t.Errorf("CopyPotToPo() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+
+			if tt.wantError {
+				return
+			}
+
+			// Verify the new PO file was created
+			if _, err := os.Stat(newPoFile); os.IsNotExist(err) {
+				t.Error("New PO file was not created")
+				return
+			}
+
+			// Read and verify content
+			content, err := os.ReadFile(newPoFile)
+			if err != nil {
+				t.Fatalf("Failed to read new PO file: %v", err)
+			}
+
+			contentStr := string(content)
+
+			// Check that Language header was updated
+			if strings.Contains(tt.potContent, "\"Language:") {
+				expectedLangHeader := fmt.Sprintf("\"Language: %s\\n\"", tt.targetLang)
+				if !strings.Contains(contentStr, expectedLangHeader) {
+					t.Errorf("Expected Language header %q not found in PO file", expectedLangHeader)
+				}
+			}
+
+			// Check that Language-Team header was updated
+			if strings.Contains(tt.potContent, "\"Language-Team:") {
+				expectedTeamHeader := fmt.Sprintf("\"Language-Team: %s\\n\"", strings.ToUpper(tt.targetLang))
+				if !strings.Contains(contentStr, expectedTeamHeader) {
+					t.Errorf("Expected Language-Team header %q not found in PO file", expectedTeamHeader)
+				}
+			}
+
+			// Check that PO-Revision-Date was updated
+			if strings.Contains(tt.potContent, "\"PO-Revision-Date:") {
+				if !strings.Contains(contentStr, "\"PO-Revision-Date:") {
+					t.Error("PO-Revision-Date header not found in PO file")
+				}
+				// Verify date format is reasonable (contains year 20xx)
+				if !strings.Contains(contentStr, "202") {
+					t.Error("PO-Revision-Date does not appear to be current")
+				}
+			}
+
+			// Check that msgid entries are preserved
+			if strings.Contains(tt.potContent, `msgid "Hello"`) {
+				if !strings.Contains(contentStr, `msgid "Hello"`) {
+					t.Error("msgid 'Hello' was not preserved in PO file")
+				}
+			}
+
+			if strings.Contains(tt.potContent, `msgid "World"`) {
+				if !strings.Contains(contentStr, `msgid "World"`) {
+					t.Error("msgid 'World' was not preserved in PO file")
+				}
+			}
+
+			// Check that comments are preserved
+			if strings.Contains(tt.potContent, "#: test.py:10") {
+				if !strings.Contains(contentStr, "#: test.py:10") {
+					t.Error("Comment '#: test.py:10' was not preserved in PO file")
+				}
+			}
+
+			// Verify msgstr entries remain empty
+			if strings.Contains(tt.potContent, `msgid "Hello"`) {
+				// Find Hello's msgstr
+				if !strings.Contains(contentStr, `msgid "Hello"`+"\nmsgstr \"\"") &&
+					!strings.Contains(contentStr, `msgid "Hello"`+"\r\nmsgstr \"\"") {
+					t.Error("msgstr for 'Hello' should be empty in new PO file")
+				}
+			}
+		})
+	}
+}
+
+func TestCopyPotToPoFileErrors(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		setupFunc func() (potFile, newPoFile string)
+		wantError bool
+	}{
+		{
+			name: "nonexistent POT file",
+			setupFunc: func() (string, string) {
+				return filepath.Join(tempDir, "nonexistent.pot"),
+					filepath.Join(tempDir, "output.po")
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid output directory",
+			setupFunc: func() (string, string) {
+				potFile := filepath.Join(tempDir, "test.pot")
+				os.WriteFile(potFile, []byte("msgid \"\"\nmsgstr \"\""), 0644)
+				return potFile, "/invalid/path/output.po"
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			potFile, newPoFile := tt.setupFunc()
+
+			err := p.CopyPotToPo(potFile, newPoFile, "es")
+			if (err != nil) != tt.wantError {
+				t.Errorf("CopyPotToPo() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestRewritePreservesPotOrder(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "A"
+msgstr ""
+
+msgid "B"
+msgstr ""
+
+msgid "C"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// PO file lists the entries in reverse order, all translated
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "C"
+msgstr "c"
+
+msgid "B"
+msgstr "b"
+
+msgid "A"
+msgstr "a"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	for run := 0; run < 5; run++ {
+		if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+			t.Fatalf("RewritePoFile failed: %v", err)
+		}
+
+		content, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read rewritten PO file: %v", err)
+		}
+		contentStr := string(content)
+
+		posA := strings.Index(contentStr, `msgid "A"`)
+		posB := strings.Index(contentStr, `msgid "B"`)
+		posC := strings.Index(contentStr, `msgid "C"`)
+		if posA < 0 || posB < 0 || posC < 0 {
+			t.Fatalf("Run %d: missing entries in rewritten PO file:\n%s", run, contentStr)
+		}
+		if !(posA < posB && posB < posC) {
+			t.Errorf("Run %d: entries not in POT order:\n%s", run, contentStr)
+		}
+	}
+}
+
+// fakeTranslator is a Translator for tests that records the texts it was
+// asked to translate. Without a translate function it returns "<to>:<text>".
+type fakeTranslator struct {
+	mu        sync.Mutex
+	texts     []string
+	translate func(text, from, to string) (string, error)
+}
+
+func (f *fakeTranslator) Translate(text, from, to string) (string, error) {
+	f.mu.Lock()
+	f.texts = append(f.texts, text)
+	f.mu.Unlock()
+	if f.translate != nil {
+		return f.translate(text, from, to)
+	}
+	return to + ":" + text, nil
+}
+
+func (f *fakeTranslator) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.texts)
+}
+
+func TestParsePotFilePlural(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+
+	content := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#: test.py:10
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test POT file: %v", err)
+	}
+
+	entries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+
+	entry, exists := entries["One file"]
+	if !exists {
+		t.Fatal("Expected plural entry 'One file' not found")
+	}
+	if entry.MsgidPlural != "%d files" {
+		t.Errorf("Expected msgid_plural %q, got %q", "%d files", entry.MsgidPlural)
+	}
+	if len(entry.Msgstrs) != 2 {
+		t.Errorf("Expected 2 msgstr forms, got %d", len(entry.Msgstrs))
+	}
+}
+
+func TestTranslatePoFilePlural(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "Hello"
+msgstr ""
+`
+
+	tests := []struct {
+		name          string
+		lang          string
+		pluralForms   string
+		expectedForms []string
+	}{
+		{
+			name:          "two forms",
+			lang:          "es",
+			pluralForms:   `"Plural-Forms: nplurals=2; plural=(n != 1);\n"`,
+			expectedForms: []string{`msgstr[0] "es:One file"`, `msgstr[1] "es:%d files"`},
+		},
+		{
+			name:        "three forms",
+			lang:        "pl",
+			pluralForms: `"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"`,
+			expectedForms: []string{
+				`msgstr[0] "pl:One file"`,
+				`msgstr[1] "pl:%d files"`,
+				`msgstr[2] "pl:%d files"`,
+			},
+		},
+	}
+
+	translator := &fakeTranslator{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+
+			// PO file without the plural entry, so it is added from the POT
+			poFile := filepath.Join(tempDir, "test_"+tt.lang+".po")
+			poContent := "msgid \"\"\nmsgstr \"\"\n\"Language: " + tt.lang + "\\n\"\n" + tt.pluralForms + "\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			result, err := p.TranslatePoFile(poFile, potEntries, "en", tt.lang, 0, translator)
+			if err != nil {
+				t.Fatalf("TranslatePoFile failed: %v", err)
+			}
+			if result.Translated != 2 {
+				t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+			}
+
+			updatedContent, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated PO file: %v", err)
+			}
+			updatedStr := string(updatedContent)
+
+			if !strings.Contains(updatedStr, `msgid_plural "%d files"`) {
+				t.Error("msgid_plural line missing from PO file")
+			}
+			for _, form := range tt.expectedForms {
+				if !strings.Contains(updatedStr, form) {
+					t.Errorf("Expected %q in PO file:\n%s", form, updatedStr)
+				}
+			}
+			if strings.Count(updatedStr, "msgstr[") != len(tt.expectedForms) {
+				t.Errorf("Expected %d msgstr forms, got %d:\n%s", len(tt.expectedForms), strings.Count(updatedStr, "msgstr["), updatedStr)
+			}
+			if !strings.Contains(updatedStr, `msgstr "`+tt.lang+`:Hello"`) {
+				t.Error("Singular entry 'Hello' was not translated")
+			}
+		})
+	}
+}
+
+func TestRewritePoFilePlural(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "One folder"
+msgid_plural "%d folders"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "One user"
+msgid_plural "%d users"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Jeden plik"
+msgstr[1] "%d pliki"
+msgstr[2] ""
+
+msgid "One folder"
+msgid_plural "%d folders"
+msgstr[0] "Jeden folder"
+msgstr[1] "%d foldery"
+msgstr[2] "%d folderów"
+
+msgid "Old"
+msgid_plural "Olds"
+msgstr[0] "Stary"
+msgstr[1] "Stare"
+msgstr[2] "Starych"
+`
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_pl.po")
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	translator := &fakeTranslator{}
+	result, err := p.RewritePoFile(poFile, potEntries, "en", "pl", 0, translator)
+	if err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	// The gap of "One file", the new "One user" and "Hello"
+	if result.Translated != 3 {
+		t.Errorf("Expected 3 translated entries, got %d", result.Translated)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	updatedStr := string(updatedContent)
+
+	expected := []string{
+		// Existing forms are kept, the empty form is filled
+		"msgid \"One file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"Jeden plik\"\nmsgstr[1] \"%d pliki\"\nmsgstr[2] \"pl:%d files\"\n",
+		// Complete entries are kept as they are
+		"msgid \"One folder\"\nmsgid_plural \"%d folders\"\nmsgstr[0] \"Jeden folder\"\nmsgstr[1] \"%d foldery\"\nmsgstr[2] \"%d folderów\"\n",
+		// New entries get the three forms of the Plural-Forms header
+		"msgid \"One user\"\nmsgid_plural \"%d users\"\nmsgstr[0] \"pl:One user\"\nmsgstr[1] \"pl:%d users\"\nmsgstr[2] \"pl:%d users\"\n",
+		"msgid \"Hello\"\nmsgstr \"pl:Hello\"\n",
+		// Obsolete plural entries keep their forms
+		"#~ msgid \"Old\"\n#~ msgid_plural \"Olds\"\n#~ msgstr[0] \"Stary\"\n#~ msgstr[1] \"Stare\"\n#~ msgstr[2] \"Starych\"",
+	}
+	for _, want := range expected {
+		if !strings.Contains(updatedStr, want) {
+			t.Errorf("Expected %q in PO file:\n%s", want, updatedStr)
+		}
+	}
+
+	// A second rewrite has nothing left to do
+	calls := translator.calls()
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "pl", 0, translator); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	if translator.calls() != calls {
+		t.Errorf("Expected no translations on the second rewrite, got %d", translator.calls()-calls)
+	}
+	secondContent, _ := os.ReadFile(poFile)
+	if string(secondContent) != updatedStr {
+		t.Errorf("Expected the second rewrite to keep the file, got:\n%s", secondContent)
+	}
+}
+
+func TestMsgctxtRoundTrip(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgctxt "verb"
+msgid "Open"
+msgstr ""
+
+msgctxt "adjective"
+msgid "Open"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	if len(potEntries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(potEntries))
+	}
+	for _, msgctxt := range []string{"verb", "adjective"} {
+		entry, exists := potEntries[entryKey(msgctxt, "Open")]
+		if !exists {
+			t.Errorf("Entry 'Open' with context %q not found", msgctxt)
+		} else if entry.Msgctxt != msgctxt {
+			t.Errorf("Expected context %q, got %q", msgctxt, entry.Msgctxt)
+		}
+	}
+
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		return "Abrir", nil
+	}}
+
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if result.Translated != 2 {
+		t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+	}
+	for _, text := range translator.texts {
+		if text != "Open" {
+			t.Errorf("Expected only the msgid to be translated, got %q", text)
+		}
+	}
+
+	checkContexts := func(stage string) {
+		updatedContent, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read updated PO file: %v", err)
+		}
+		updatedStr := string(updatedContent)
+
+		for _, msgctxt := range []string{"verb", "adjective"} {
+			expected := "msgctxt \"" + msgctxt + "\"\nmsgid \"Open\"\nmsgstr \"Abrir\""
+			if !strings.Contains(updatedStr, expected) {
+				t.Errorf("After %s: expected entry with context %q in PO file:\n%s", stage, msgctxt, updatedStr)
+			}
+		}
+	}
+
+	checkContexts("translate")
+
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	checkContexts("rewrite")
+}
+
+func TestParsePotFileFlags(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+
+	content := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#: test.py:10
+#, fuzzy, c-format
+msgid "Hello %s"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test POT file: %v", err)
+	}
+
+	entries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+
+	entry := entries["Hello %s"]
+	if !hasFlag(entry.Flags, "fuzzy") || !hasFlag(entry.Flags, "c-format") {
+		t.Errorf("Expected flags [fuzzy c-format], got %v", entry.Flags)
+	}
+	for _, comment := range entry.Comments.lines() {
+		if strings.HasPrefix(comment, "#,") {
+			t.Errorf("Flag line %q should not be stored as a comment", comment)
+		}
+	}
+}
+
+func TestFuzzyEntriesAreRetranslated(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+#, fuzzy
+msgid "Hello"
+msgstr "Hola vieja"
+
+msgid "World"
+msgstr ""
+`
+
+	tests := []struct {
+		name      string
+		markFuzzy bool
+		rewrite   bool
+		wantFuzzy int
+	}{
+		{name: "translate resolves fuzzy", markFuzzy: false, wantFuzzy: 0},
+		{name: "translate with mark-fuzzy", markFuzzy: true, wantFuzzy: 2},
+		{name: "rewrite resolves fuzzy", markFuzzy: false, rewrite: true, wantFuzzy: 0},
+		{name: "rewrite with mark-fuzzy", markFuzzy: true, rewrite: true, wantFuzzy: 2},
+	}
+
+	translator := &fakeTranslator{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.MarkFuzzy = tt.markFuzzy
+			defer func() { p.MarkFuzzy = false }()
+
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			var result TranslationResult
+			if tt.rewrite {
+				result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+			} else {
+				result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			}
+			if err != nil {
+				t.Fatalf("Processing failed: %v", err)
+			}
+			if result.Translated != 2 {
+				t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+			}
+
+			updatedContent, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated PO file: %v", err)
+			}
+			updatedStr := string(updatedContent)
+
+			if strings.Contains(updatedStr, "Hola vieja") {
+				t.Error("Fuzzy translation was not replaced")
+			}
+			if !strings.Contains(updatedStr, `msgstr "es:Hello"`) {
+				t.Errorf("Fuzzy entry was not retranslated:\n%s", updatedStr)
+			}
+			if count := strings.Count(updatedStr, "#, fuzzy"); count != tt.wantFuzzy {
+				t.Errorf("Expected %d fuzzy flags, got %d:\n%s", tt.wantFuzzy, count, updatedStr)
+			}
+			if tt.markFuzzy && !strings.Contains(updatedStr, "#, fuzzy\nmsgid \"World\"") {
+				t.Errorf("Expected fuzzy flag before 'World':\n%s", updatedStr)
+			}
+		})
+	}
+}
+
+func TestRewritePreservesFlags(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#, c-format
+msgid "Hello %s"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+#, no-wrap
+msgid "Hello %s"
+msgstr "Hola %s"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	if !strings.Contains(string(updatedContent), "#, c-format, no-wrap\nmsgid \"Hello %s\"\nmsgstr \"Hola %s\"") {
+		t.Errorf("Flags were not preserved:\n%s", updatedContent)
+	}
+}
+
+func TestRewritePreservesHeaderComments(t *testing.T) {
+	p := New(DefaultOptions())
+	p.LastTranslator = ""
+	previousNow := timeNow
+	timeNow = func() time.Time { return stampTime }
+	defer func() { timeNow = previousNow }()
+
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	header := `# Copyright (C) 2024 Example
+# This file is distributed under the same license as the package.
+#
+#, fuzzy
+msgid ""
+msgstr ""
+"Project-Id-Version: example\n"
+"Language: es\n"
+`
+	poContent := header + `
+msgid "Hello"
+msgstr "Hola"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	expected := header + `"PO-Revision-Date: 2026-10-14 12:00+0000\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "World"
+msgstr "es:World"
+`
+	if string(updatedContent) != expected {
+		t.Errorf("Header was not preserved, got:\n%s\nwant:\n%s", updatedContent, expected)
+	}
+}
+
+func TestTranslationResult(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+msgid "Broken"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Removed"
+msgstr ""
+`
+
+	tests := []struct {
+		name     string
+		rewrite  bool
+		down     bool // The backend fails every translation
+		expected TranslationResult
+		summary  string
+	}{
+		{"translate", false, false, TranslationResult{Added: 2, Translated: 2, Failed: 1}, "Translated 2 string(s) (2 added, 1 failed)"},
+		{"rewrite", true, false, TranslationResult{Added: 2, Translated: 2, Failed: 1, Removed: 1}, "Translated 2 string(s) (2 added, 1 failed, 1 removed)"},
+		// The added entries are counted even when nothing gets translated
+		{"backend down", false, true, TranslationResult{Added: 2, Failed: 3}, "Translated 0 string(s) (2 added, 3 failed)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+				if text == "Broken" || tt.down {
+					return "", fmt.Errorf("backend unavailable")
+				}
+				return to + ":" + text, nil
+			}}
+			var result TranslationResult
+			if tt.rewrite {
+				result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+			} else {
+				result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			}
+			if err != nil {
+				t.Fatalf("Processing failed: %v", err)
+			}
+			// The requests are checked by TestTiming
+			result.Requests = RequestStats{}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+			if summary := result.Summary(); summary != tt.summary {
+				t.Errorf("Expected summary %q, got %q", tt.summary, summary)
+			}
+		})
+	}
+
+	var total TranslationResult
+	total.Add(TranslationResult{Translated: 3, Added: 1})
+	total.Add(TranslationResult{Translated: 1, Skipped: 2})
+	if summary := total.Summary(); summary != "Translated 4 string(s) (1 added, 2 skipped)" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+	if details := (TranslationResult{Translated: 1}).Details(); details != "" {
+		t.Errorf("Expected no details, got %q", details)
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Sort = true
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "zebra"
+msgstr ""
+
+msgid "Mango"
+msgstr ""
+
+msgctxt "verb"
+msgid "Open"
+msgstr ""
+
+msgid "apple"
+msgstr ""
+
+msgid "Open"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "zebra"
+msgstr "cebra"
+`
+
+	tests := []struct {
+		name     string
+		rewrite  bool
+		expected []string
+	}{
+		// Existing entries keep their place, added ones are sorted
+		{"translate", false, []string{"", "zebra", "Mango", "Open", "verb\x04Open", "apple"}},
+		{"rewrite", true, []string{"", "Mango", "Open", "verb\x04Open", "apple", "zebra"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			if tt.rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			if err != nil {
+				t.Fatalf("Processing failed: %v", err)
+			}
+
+			content, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read PO file: %v", err)
+			}
+			lines, _ := splitLines(string(content))
+			blocks, _ := parsePoLines(lines)
+			var keys []string
+			for _, block := range blocks {
+				if block.isEntry {
+					keys = append(keys, block.key())
+				}
+			}
+			if strings.Join(keys, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected entries %q, got %q", tt.expected, keys)
+			}
+		})
+	}
+}
+
+func TestTranslatePoFileIgnoresPotMsgstr(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr "Sample translation"
+
+msgid "World"
+msgstr "Sample translation"
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr "Mundo"
+`
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if result.Translated != 1 {
+		t.Errorf("Expected 1 translated entry, got %d", result.Translated)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	for _, expected := range []string{"msgid \"Hello\"\nmsgstr \"es:Hello\"", "msgid \"World\"\nmsgstr \"Mundo\""} {
+		if !strings.Contains(string(updatedContent), expected) {
+			t.Errorf("Expected %q in PO file, got:\n%s", expected, updatedContent)
+		}
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	p := New(DefaultOptions())
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Some text\n"
+msgstr ""
+
+msgid "Other text"
+msgstr ""
+`
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Some text"
+msgstr "Algún texto"
+
+msgid "Other text\n"
+msgstr "Otro texto\n"
+`
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	for _, rewrite := range []bool{false, true} {
+		p.NormalizeNewlines, p.Rewrite = true, rewrite
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		translator := &fakeTranslator{}
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("rewrite %v: failed: %v", rewrite, err)
+		}
+		if result.Added != 0 || translator.calls() != 0 {
+			t.Errorf("rewrite %v: expected no added or translated entries, got %+v", rewrite, result)
+		}
+
+		// The POT msgid is written, with the translation matching it
+		entries, _, err := p.ParsePotFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to parse PO file: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("rewrite %v: expected 2 entries, got %v", rewrite, entries)
+		}
+		for msgid, msgstr := range map[string]string{"Some text\n": "Algún texto\n", "Other text": "Otro texto"} {
+			if entries[msgid].Msgstr != msgstr {
+				t.Errorf("rewrite %v: expected %q for %q, got %q", rewrite, msgstr, msgid, entries[msgid].Msgstr)
+			}
+		}
+	}
+
+	// Without normalizing, the POT entry is a different one
+	p.NormalizeNewlines, p.Rewrite = false, false
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if result.Added != 2 {
+		t.Errorf("Expected 2 added entries without normalizing, got %d", result.Added)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := utf8BOM + "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := utf8BOM + "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, sourceLang, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	if sourceLang != "en" {
+		t.Errorf("Expected source language 'en', got %q", sourceLang)
+	}
+	if _, exists := potEntries["Hello"]; !exists || len(potEntries) != 1 {
+		t.Errorf("Expected only the Hello entry, got %v", potEntries)
+	}
+	if lang, err := p.GetTargetLanguage(poFile, "test"); err != nil || lang != "es" {
+		t.Errorf("GetTargetLanguage() = %q, %v, want \"es\"", lang, err)
+	}
+
+	translator := &fakeTranslator{}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if translator.calls() != 1 {
+		t.Errorf("Expected only Hello to be translated, got %v", translator.texts)
+	}
+
+	newPoFile := filepath.Join(tempDir, "test_de.po")
+	if err := p.CopyPotToPo(potFile, newPoFile, "de"); err != nil {
+		t.Fatalf("CopyPotToPo failed: %v", err)
+	}
+
+	for _, file := range []string{poFile, newPoFile} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read PO file: %v", err)
+		}
+		if strings.HasPrefix(string(content), utf8BOM) {
+			t.Errorf("Expected %s to be written without BOM", filepath.Base(file))
+		}
+		if !strings.HasPrefix(string(content), "msgid \"\"\n") {
+			t.Errorf("Expected %s to start with the header, got:\n%s", filepath.Base(file), content)
+		}
+	}
+}
+
+func TestTranslatePoFilePreservesCRLF(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := "msgid \"\"\r\nmsgstr \"\"\r\n\"Language: en\\n\"\r\n\r\nmsgid \"Hello\"\r\nmsgstr \"\"\r\n\r\nmsgid \"World\"\r\nmsgstr \"\"\r\n"
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	poContent := "msgid \"\"\r\nmsgstr \"\"\r\n\"Language: es\\n\"\r\n\r\nmsgid \"Hello\"\r\nmsgstr \"\"\r\n"
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	translator := &fakeTranslator{}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	updatedStr := string(updatedContent)
+
+	if strings.Count(updatedStr, "\n") != strings.Count(updatedStr, "\r\n") {
+		t.Errorf("Expected only CRLF line endings, got %q", updatedStr)
+	}
+	if strings.Count(updatedStr, "\r") != strings.Count(updatedStr, "\r\n") {
+		t.Errorf("Found stray carriage return in %q", updatedStr)
+	}
+	for _, expected := range []string{"msgstr \"es:Hello\"\r\n", "msgstr \"es:World\""} {
+		if !strings.Contains(updatedStr, expected) {
+			t.Errorf("Expected %q in PO file, got %q", expected, updatedStr)
+		}
+	}
+}
+
+func TestGnuLayout(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+
+	for _, lang := range []string{"es", "fr"} {
+		dir := filepath.Join(tempDir, lang, "LC_MESSAGES")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create locale directory: %v", err)
+		}
+		// No Language header, so the language must come from the directory
+		content := "msgid \"\"\nmsgstr \"\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "default.po"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "admin.po"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "default_de.po"), []byte("test"), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	files, err := p.FindPoFiles(tempDir, "default", "gnu")
+	if err != nil {
+		t.Fatalf("FindPoFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d: %v", len(files), files)
+	}
+
+	languages := make(map[string]bool)
+	for _, file := range files {
+		if filepath.Base(file) != "default.po" {
+			t.Errorf("Unexpected file %q", file)
+		}
+		lang, err := p.GetTargetLanguage(file, "default")
+		if err != nil {
+			t.Errorf("GetTargetLanguage(%q) error = %v", file, err)
+		}
+		languages[lang] = true
+	}
+	if !languages["es"] || !languages["fr"] {
+		t.Errorf("Expected languages es and fr, got %v", languages)
+	}
+
+	if path := p.poFilePath(tempDir, "default", "de", "gnu"); path != filepath.Join(tempDir, "de", "LC_MESSAGES", "default.po") {
+		t.Errorf("Unexpected GNU layout path %q", path)
+	}
+}
+
+func TestTranslatePoFileConcurrency(t *testing.T) {
+	p := New(DefaultOptions())
+	var potContent strings.Builder
+	potContent.WriteString("msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&potContent, "\nmsgid \"String %d\"\nmsgstr \"\"\n", i)
+	}
+
+	for _, workers := range []int{1, 3, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			p.Concurrency = workers
+			defer func() { p.Concurrency = 1 }()
+			translator := &fakeTranslator{}
+
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent.String()), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			if err != nil {
+				t.Fatalf("TranslatePoFile failed: %v", err)
+			}
+			if result.Translated != 20 {
+				t.Errorf("Expected 20 translated entries, got %d", result.Translated)
+			}
+			if translator.calls() != 20 {
+				t.Errorf("Expected 20 translator calls, got %d", translator.calls())
+			}
+
+			updatedContent, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated PO file: %v", err)
+			}
+			for i := 0; i < 20; i++ {
+				expected := fmt.Sprintf("msgid \"String %d\"\nmsgstr \"es:String %d\"", i, i)
+				if !strings.Contains(string(updatedContent), expected) {
+					t.Errorf("Missing translation for 'String %d'", i)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateStringWhitespace(t *testing.T) {
+	p := New(DefaultOptions())
+	p.PlaceholderStyle = "c"
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantCalls int
+	}{
+		{name: "surrounding spaces", input: " Save ", want: " es:Save ", wantCalls: 1},
+		{name: "placeholder with spaces", input: " %s: ", want: " %s: ", wantCalls: 0},
+		{name: "tab only", input: "\t", want: "\t", wantCalls: 0},
+		{name: "punctuation only", input: "...", want: "...", wantCalls: 0},
+		{name: "newline kept", input: "Done\n", want: "es:Done\n", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+				// Google trims the text it translates
+				return " " + to + ":" + text + " ", nil
+			}}
+
+			got, _, issue, err := p.translateString(translator, tt.input, "en", "es")
+			if err != nil {
+				t.Fatalf("translateString() error = %v", err)
+			}
+			if issue != "" {
+				t.Errorf("translateString() reported an issue: %s", issue)
+			}
+			if got != tt.want {
+				t.Errorf("translateString(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if calls := translator.calls(); calls != tt.wantCalls {
+				t.Errorf("Expected %d backend calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFormatPoStringWrap(t *testing.T) {
+	long := "This is a rather long translation that does not fit on a single line of seventy-nine columns"
+
+	tests := []struct {
+		name     string
+		keyword  string
+		value    string
+		width    int
+		expected []string
+	}{
+		{
+			name:     "short string",
+			keyword:  "msgstr",
+			value:    "Hello",
+			width:    79,
+			expected: []string{`msgstr "Hello"`},
+		},
+		{
+			name:    "long string wrapped at spaces",
+			keyword: "msgstr",
+			value:   long,
+			width:   79,
+			expected: []string{
+				`msgstr ""`,
+				`"This is a rather long translation that does not fit on a single line of "`,
+				`"seventy-nine columns"`,
+			},
+		},
+		{
+			name:     "wrapping disabled",
+			keyword:  "msgstr",
+			value:    long,
+			width:    0,
+			expected: []string{`msgstr "` + long + `"`},
+		},
+		{
+			name:    "narrow width",
+			keyword: "msgid",
+			value:   "one two three four",
+			width:   12,
+			expected: []string{
+				`msgid ""`,
+				`"one two "`,
+				`"three four"`,
+			},
+		},
+		{
+			name:    "newlines with wrapping",
+			keyword: "msgstr",
+			value:   "First line\nSecond line is longer",
+			width:   16,
+			expected: []string{
+				`msgstr ""`,
+				`"First line\n"`,
+				`"Second line "`,
+				`"is longer"`,
+			},
+		},
+		{
+			name:     "word longer than width",
+			keyword:  "msgstr",
+			value:    "Supercalifragilistic",
+			width:    10,
+			expected: []string{`msgstr ""`, `"Supercalifragilistic"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatPoString(tt.keyword, tt.value, tt.width)
+			if strings.Join(result, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("formatPoString() =\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestTranslatePoFileWrapsLongLines(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Width = 46
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Wrap this fairly long sentence please"
+msgstr ""
+
+#, no-wrap
+msgid "Keep this fairly long sentence whole"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Wrap this fairly long sentence please"
+msgstr ""
+
+#, no-wrap
+msgid "Keep this fairly long sentence whole"
+msgstr ""
+`
+	expected := `msgid "Wrap this fairly long sentence please"
+msgstr ""
+"es:Wrap this fairly long sentence please"
+
+#, no-wrap
+msgid "Keep this fairly long sentence whole"
+msgstr "es:Keep this fairly long sentence whole"`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		if rewrite {
+			_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		} else {
+			_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("Processing failed: %v", err)
+		}
+
+		updatedContent, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read updated PO file: %v", err)
+		}
+		if !strings.Contains(string(updatedContent), expected) {
+			t.Errorf("Unexpected output (rewrite=%v):\n%s\nwant\n%s", rewrite, updatedContent, expected)
+		}
+	}
+}
+
+func TestRewriteKeepsObsoleteEntries(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Revived"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgctxt "menu"
+msgid "Removed"
+msgstr "Eliminado"
+
+msgid "Untranslated"
+msgstr ""
+
+#, fuzzy
+#~ msgid "Old"
+#~ msgstr "Viejo"
+
+#~ msgid "Revived"
+#~ msgstr "Revivido"
+`
+
+	tests := []struct {
+		name     string
+		purge    bool
+		expected []string
+		absent   []string
+	}{
+		{
+			name: "keep obsolete",
+			expected: []string{
+				"#~ msgctxt \"menu\"\n#~ msgid \"Removed\"\n#~ msgstr \"Eliminado\"",
+				"#, fuzzy\n#~ msgid \"Old\"\n#~ msgstr \"Viejo\"",
+				"msgid \"Revived\"\nmsgstr \"Revivido\"",
+			},
+			absent: []string{"Untranslated", "#~ msgid \"Revived\""},
+		},
+		{
+			name:     "purge obsolete",
+			purge:    true,
+			expected: []string{"msgid \"Revived\"\nmsgstr \"Revivido\""},
+			absent:   []string{"Eliminado", "Viejo", "Untranslated", "#~"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.PurgeObsolete = tt.purge
+			defer func() { p.PurgeObsolete = false }()
+
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			translator := &fakeTranslator{}
+			if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+				t.Fatalf("RewritePoFile failed: %v", err)
+			}
+			if translator.calls() != 0 {
+				t.Errorf("Expected no backend calls, got %d", translator.calls())
+			}
+
+			updatedContent, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated PO file: %v", err)
+			}
+			updatedStr := string(updatedContent)
+
+			for _, want := range tt.expected {
+				if !strings.Contains(updatedStr, want) {
+					t.Errorf("Expected %q in output:\n%s", want, updatedStr)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(updatedStr, unwanted) {
+					t.Errorf("Unexpected %q in output:\n%s", unwanted, updatedStr)
+				}
+			}
+
+			// Obsolete entries don't count as active entries
+			entries, _, err := p.ParsePotFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to parse updated PO file: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Errorf("Expected 2 active entries, got %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestRecursiveDirectories(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: %s\n"
+`
+
+	root := t.TempDir()
+	files := map[string]string{
+		"app/default.pot":                     potContent,
+		"app/default_es.po":                   fmt.Sprintf(poContent, "es"),
+		"app/plugins/editor/default.pot":      potContent,
+		"app/plugins/editor/default_fr.po":    fmt.Sprintf(poContent, "fr"),
+		"app/plugins/editor/default_de.po":    fmt.Sprintf(poContent, "de"),
+		"vendor/library/default.pot":          potContent,
+		"vendor/library/default_it.po":        fmt.Sprintf(poContent, "it"),
+		"app/node_modules/widget/default.pot": potContent,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	directories, err := FindPotDirectories(root, "default", "vendor, node_modules")
+	if err != nil {
+		t.Fatalf("FindPotDirectories() error = %v", err)
+	}
+	expected := []string{filepath.Join(root, "app"), filepath.Join(root, "app", "plugins", "editor")}
+	if strings.Join(directories, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("FindPotDirectories() = %v, want %v", directories, expected)
+	}
+
+	translator := &fakeTranslator{}
+	report := &Report{Domain: "default", Files: []FileReport{}}
+	total := 0
+	for _, dir := range directories {
+		result, err := p.ProcessDirectory(dir, root, "default", 0, translator, report)
+		if err != nil {
+			t.Fatalf("ProcessDirectory(%q) error = %v", dir, err)
+		}
+		total += result.Translated
+	}
+
+	if total != 6 {
+		t.Errorf("Expected 6 translated strings across directories, got %d", total)
+	}
+	if len(report.Files) != 3 {
+		t.Fatalf("Expected 3 file reports, got %d", len(report.Files))
+	}
+	if file := report.Files[0].File; file != "app/default_es.po" {
+		t.Errorf("Expected report path relative to root, got %q", file)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "vendor", "library", "default_it.po"))
+	if err != nil {
+		t.Fatalf("Failed to read excluded PO file: %v", err)
+	}
+	if string(content) != fmt.Sprintf(poContent, "it") {
+		t.Error("Excluded directory was modified")
+	}
+}
+
+func TestMultipleDomains(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "%s"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	root := t.TempDir()
+	files := map[string]string{
+		"default.pot":       fmt.Sprintf(potContent, "Hello"),
+		"default_es.po":     poContent,
+		"admin.pot":         fmt.Sprintf(potContent, "Settings"),
+		"admin_es.po":       poContent,
+		"b/admin.pot":       fmt.Sprintf(potContent, "Settings"),
+		"other/default.pot": fmt.Sprintf(potContent, "Hello"),
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		spec      string
+		expected  []string
+		wantError bool
+	}{
+		{"all", []string{"admin", "default"}, false},
+		{"default, admin", []string{"default", "admin"}, false},
+		{"default,emails", []string{"default"}, false},
+		{"missing", []string{"missing"}, false},
+		{"emails,missing", nil, true},
+	}
+	for _, tt := range tests {
+		domains, err := p.SelectDomains(root, tt.spec)
+		if (err != nil) != tt.wantError {
+			t.Fatalf("SelectDomains(%q) error = %v, wantError %v", tt.spec, err, tt.wantError)
+		}
+		if strings.Join(domains, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("SelectDomains(%q) = %v, want %v", tt.spec, domains, tt.expected)
+		}
+	}
+
+	if _, err := p.SelectDomains(filepath.Join(root, "empty"), "all"); err == nil {
+		t.Error("Expected an error for a directory without POT files")
+	}
+
+	// The b directory is walked in between the POT files of the root
+	directories, err := FindPotDirectories(root, "all", "")
+	if err != nil {
+		t.Fatalf("FindPotDirectories() error = %v", err)
+	}
+	expected := []string{root, filepath.Join(root, "b"), filepath.Join(root, "other")}
+	if strings.Join(directories, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected each directory once, got %v", directories)
+	}
+
+	report := &Report{Domain: "all", Files: []FileReport{}}
+	for _, name := range []string{"admin", "default"} {
+		result, err := p.ProcessDirectory(root, root, name, 0, &fakeTranslator{}, report)
+		if err != nil {
+			t.Fatalf("ProcessDirectory(%q) error = %v", name, err)
+		}
+		if result.Translated != 1 {
+			t.Errorf("Expected 1 translated string for %s, got %d", name, result.Translated)
+		}
+	}
+
+	for name, msgid := range map[string]string{"admin": "Settings", "default": "Hello"} {
+		content, err := os.ReadFile(filepath.Join(root, name+"_es.po"))
+		if err != nil {
+			t.Fatalf("Failed to read PO file: %v", err)
+		}
+		if !strings.Contains(string(content), `msgstr "es:`+msgid+`"`) {
+			t.Errorf("Expected %s_es.po to be translated, got:\n%s", name, content)
+		}
+	}
+	if len(report.Files) != 2 || report.Files[0].Domain != "admin" || report.Files[1].Domain != "default" {
+		t.Errorf("Expected a file report per domain, got %+v", report.Files)
+	}
+}
+
+func TestLanguageFilters(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: %s\n"
+`
+	languages := []string{"de", "es", "fr", "it", "pt_BR"}
+
+	tests := []struct {
+		name      string
+		onlyLang  string
+		skipLang  string
+		processed []string
+	}{
+		{name: "only", onlyLang: "es,fr", processed: []string{"es", "fr"}},
+		{name: "only with region", onlyLang: "pt-br", processed: []string{"pt_BR"}},
+		{name: "skip", skipLang: "de", processed: []string{"es", "fr", "it", "pt_BR"}},
+		{name: "no filter", processed: languages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.OnlyLang, p.SkipLang = tt.onlyLang, tt.skipLang
+
+			tempDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tempDir, "default.pot"), []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			for _, lang := range languages {
+				poFile := filepath.Join(tempDir, "default_"+lang+".po")
+				if err := os.WriteFile(poFile, []byte(fmt.Sprintf(poContent, lang)), 0644); err != nil {
+					t.Fatalf("Failed to create PO file: %v", err)
+				}
+			}
+
+			result, err := p.ProcessDirectory(tempDir, tempDir, "default", 0, &fakeTranslator{}, nil)
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			if result.Translated != len(tt.processed) {
+				t.Errorf("Expected %d translated strings, got %d", len(tt.processed), result.Translated)
+			}
+
+			processed := make(map[string]bool)
+			for _, lang := range tt.processed {
+				processed[lang] = true
+			}
+			for _, lang := range languages {
+				content, err := os.ReadFile(filepath.Join(tempDir, "default_"+lang+".po"))
+				if err != nil {
+					t.Fatalf("Failed to read PO file: %v", err)
+				}
+				modified := string(content) != fmt.Sprintf(poContent, lang)
+				if modified != processed[lang] {
+					t.Errorf("Language %s: modified = %v, want %v", lang, modified, processed[lang])
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyPot(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout, p.SourceLang = "flat", ""
+
+	// No Language header, since there is nothing to detect it from either
+	potContent := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+`
+
+	tests := []struct {
+		name      string
+		rewrite   bool
+		addLang   string
+		file      string
+		unchanged bool
+	}{
+		{name: "translate", file: "default_es.po", unchanged: true},
+		{name: "rewrite", rewrite: true, file: "default_es.po"},
+		{name: "add language", addLang: "fr", file: "default_fr.po"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p.Rewrite, p.AddLang = tt.rewrite, tt.addLang
+			files := map[string]string{"default.pot": potContent, "default_es.po": poContent}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			var buf bytes.Buffer
+			p.Output = &buf
+			translator := &fakeTranslator{}
+			result, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil)
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), "POT contains no translatable strings") {
+				t.Errorf("Expected the empty POT message, got:\n%s", buf.String())
+			}
+			if result.Translated != 0 || translator.calls() != 0 {
+				t.Errorf("Expected nothing to be translated, got %d", result.Translated)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", tt.file, err)
+			}
+			if tt.unchanged && string(content) != poContent {
+				t.Errorf("Expected %s to be unchanged, got:\n%s", tt.file, content)
+			}
+			entries, _, err := p.ParsePotFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", tt.file, err)
+			}
+			if !tt.unchanged && len(entries) != 0 {
+				t.Errorf("Expected no active entries in %s, got %d", tt.file, len(entries))
+			}
+			if tt.addLang != "" && !strings.Contains(string(content), `msgid ""`) {
+				t.Errorf("Expected a header entry in the new PO file, got:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestPotPath(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+	dir := t.TempDir()
+	files := map[string]string{"template.pot": potContent, "messages_es.po": poContent}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// The POT file isn't named after the domain
+	if _, err := p.ProcessDirectory(dir, dir, "messages", 0, &fakeTranslator{}, nil); err == nil || !strings.Contains(err.Error(), "messages.pot") {
+		t.Errorf("Expected messages.pot not to be found, got %v", err)
+	}
+
+	p.Pot = filepath.Join(dir, "template.pot")
+	result, err := p.ProcessDirectory(dir, dir, "messages", 0, &fakeTranslator{}, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if result.Translated != 1 {
+		t.Errorf("Expected 1 translated string, got %d", result.Translated)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "messages_es.po"))
+	if !strings.Contains(string(content), `msgstr "es:Hello"`) {
+		t.Errorf("Expected the PO file of the domain to be translated, got:\n%s", content)
+	}
+
+	p.Pot = filepath.Join(dir, "missing.pot")
+	if _, err := p.ProcessDirectory(dir, dir, "messages", 0, &fakeTranslator{}, nil); err == nil || !strings.Contains(err.Error(), "missing.pot' not found") {
+		t.Errorf("Expected the missing POT file to be reported, got %v", err)
+	}
+}
+
+func TestForceSource(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hallo"
+msgstr ""
+`
+	tests := []struct {
+		force    bool
+		potLang  string
+		from     string
+		expected string
+	}{
+		// Without --force-source the POT language wins with a warning
+		{false, "en", "en", "instead of provided flag (de)"},
+		{true, "de", "de", "Updated POT file with source language: de"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		files := map[string]string{"messages.pot": potContent, "messages_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		var buf bytes.Buffer
+		p.Output, p.SourceLang, p.ForceSource = &buf, "de", tt.force
+		var from string
+		translator := &fakeTranslator{translate: func(text, f, to string) (string, error) {
+			from = f
+			return to + ":" + text, nil
+		}}
+		if _, err := p.ProcessDirectory(dir, dir, "messages", 0, translator, nil); err != nil {
+			t.Fatalf("ProcessDirectory() error = %v", err)
+		}
+		if from != tt.from {
+			t.Errorf("force=%v: Expected to translate from %q, got %q", tt.force, tt.from, from)
+		}
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("force=%v: Expected %q in output:\n%s", tt.force, tt.expected, buf.String())
+		}
+		if _, potLang, _ := p.ParsePotFile(filepath.Join(dir, "messages.pot")); potLang != tt.potLang {
+			t.Errorf("force=%v: Expected POT language %q, got %q", tt.force, tt.potLang, potLang)
+		}
+	}
+}
+
+func TestEscapedQuotesRoundTrip(t *testing.T) {
+	p := New(DefaultOptions())
+
+	// Continuation lines with escaped quotes and backslashes, also right
+	// before the closing quote
+	msgid := `He said "hi" \ " "x\"`
+	header := "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n\n"
+	entry := "msgid \"\"\n\"He said \\\"hi\\\"\"\n\" \\\\\"\n\" \\\" \\\"x\\\\\\\"\"\n"
+	translated := header + entry + "msgstr \"\"\n\"Dijo \\\"hola\\\"\"\n\" \\\\\"\n"
+
+	dir := t.TempDir()
+	potFile := filepath.Join(dir, "test.pot")
+	if err := os.WriteFile(potFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\n"+entry+"msgstr \"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	if _, exists := potEntries[msgid]; !exists {
+		t.Fatalf("Expected msgid %q, got %q", msgid, p.entryOrder(potEntries))
+	}
+
+	poFile := filepath.Join(dir, "test_es.po")
+	parsed := func() *catalogEntry {
+		content, _ := os.ReadFile(poFile)
+		lines, _ := splitLines(string(content))
+		blocks, _ := parsePoLines(lines)
+		for _, block := range blocks {
+			if block.isEntry && !block.isHeader() {
+				return block
+			}
+		}
+		t.Fatalf("No entry in:\n%s", content)
+		return nil
+	}
+
+	// A run without changes leaves the file as is
+	if err := os.WriteFile(poFile, []byte(translated), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(poFile); string(content) != translated {
+		t.Errorf("Expected the PO file to be unchanged, got:\n%s", content)
+	}
+
+	// A rewrite joins the lines without changing the strings
+	p.Rewrite = true
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	p.Rewrite = false
+	if block := parsed(); block.Msgid != msgid || block.Msgstr != `Dijo "hola" \` {
+		t.Errorf("Expected the strings to survive the rewrite, got %q and %q", block.Msgid, block.Msgstr)
+	}
+
+	// The translator gets the unescaped msgid, and its translation is
+	// escaped once
+	if err := os.WriteFile(poFile, []byte(header+entry+"msgstr \"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	translator := &fakeTranslator{}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if len(translator.texts) != 1 || translator.texts[0] != msgid {
+		t.Errorf("Expected %q to be translated, got %q", msgid, translator.texts)
+	}
+	if block := parsed(); block.Msgstr != "es:"+msgid {
+		t.Errorf("Expected msgstr %q, got %q", "es:"+msgid, block.Msgstr)
+	}
+}
+
+func TestNoNetwork(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+`
+	dir := t.TempDir()
+	files := map[string]string{"messages.pot": potContent, "messages_es.po": poContent}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	p.Output, p.NoNetwork = &buf, true
+	translator := &fakeTranslator{}
+	result, err := p.ProcessDirectory(dir, dir, "messages", 0, translator, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if translator.calls() != 0 {
+		t.Errorf("Expected no translator calls, got %q", translator.texts)
+	}
+	expected := TranslationResult{Added: 1, Deferred: 2}
+	if result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+	if !strings.Contains(buf.String(), "Translated 0 string(s) (1 added, 2 deferred)") {
+		t.Errorf("Expected the deferred entries in the summary, got:\n%s", buf.String())
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "messages_es.po"))
+	if !strings.Contains(string(content), "msgid \"World\"\nmsgstr \"\"") {
+		t.Errorf("Expected the missing entry to be added untranslated, got:\n%s", content)
+	}
+}
+
+func TestAddLanguages(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout, p.AddLang = "flat", "es,de,pt_BR,fr"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	// The existing French file is skipped, without stopping the others
+	frContent := `msgid ""
+msgstr ""
+"Language: fr\n"
+`
+	dir := t.TempDir()
+	files := map[string]string{"default.pot": potContent, "default_fr.po": frContent}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	translator := &fakeTranslator{}
+	result, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if result.Translated != 3 || translator.calls() != 3 {
+		t.Errorf("Expected 3 translations, got %+v with %d calls", result, translator.calls())
+	}
+	for lang, translation := range map[string]string{"es": "es:Hello", "de": "de:Hello", "pt_BR": "pt-BR:Hello"} {
+		content, err := os.ReadFile(filepath.Join(dir, "default_"+lang+".po"))
+		if err != nil {
+			t.Fatalf("Expected default_%s.po to be created: %v", lang, err)
+		}
+		if !strings.Contains(string(content), `"Language: `+lang+`\n"`) || !strings.Contains(string(content), `msgstr "`+translation+`"`) {
+			t.Errorf("Expected a translated %s file, got:\n%s", lang, content)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "default_fr.po")); string(content) != frContent {
+		t.Errorf("Expected the existing French file to be unchanged, got:\n%s", content)
+	}
+}
+
+func TestProcessFile(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	// The header has no language, so it is taken from the name of the
+	// PO file with the domain of the POT file
+	poContent := `msgid ""
+msgstr ""
+
+msgid "Hello"
+msgstr ""
+`
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	potFile := filepath.Join(dir, "templates", "messages.pot")
+	files := map[string]string{potFile: potContent, filepath.Join(dir, "messages_es.po"): poContent, filepath.Join(dir, "messages_de.po"): poContent}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	translator := &fakeTranslator{}
+	result, err := p.ProcessFile(filepath.Join(dir, "messages_es.po"), potFile, 0, translator, nil)
+	if err != nil {
+		t.Fatalf("ProcessFile() error = %v", err)
+	}
+	if result.Translated != 1 || translator.calls() != 1 {
+		t.Errorf("Expected 1 translation, got %+v with %d calls", result, translator.calls())
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "messages_es.po")); !strings.Contains(string(content), `msgstr "es:Hello"`) {
+		t.Errorf("Expected the Spanish file to be translated, got:\n%s", content)
+	}
+	// Only the given file is processed, not the others of the domain
+	if content, _ := os.ReadFile(filepath.Join(dir, "messages_de.po")); string(content) != poContent {
+		t.Errorf("Expected the German file to be unchanged, got:\n%s", content)
+	}
+
+	if _, err := p.ProcessFile(filepath.Join(dir, "messages_fr.po"), potFile, 0, translator, nil); err == nil {
+		t.Error("Expected an error for a missing PO file")
+	}
+}
+
+func TestFinalNewline(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	partial := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"`
+	complete := partial + `
+
+msgid "World"
+msgstr "Mundo"`
+
+	tests := []struct {
+		name       string
+		lineEnding string
+		final      bool
+	}{
+		{"without final newline", "\n", false},
+		{"with final newline", "\n", true},
+		{"with final CRLF", "\r\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withEnding := func(content string) string {
+				content = strings.ReplaceAll(content, "\n", tt.lineEnding)
+				if tt.final {
+					content += tt.lineEnding
+				}
+				return content
+			}
+
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			// A rewrite without changes leaves the file byte for byte
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(withEnding(complete)), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+				t.Fatalf("RewritePoFile failed: %v", err)
+			}
+			if updated, _ := os.ReadFile(poFile); string(updated) != withEnding(complete) {
+				t.Errorf("Expected the rewritten file to be unchanged, got %q", updated)
+			}
+
+			// Added entries keep the end of the file as it was
+			if err := os.WriteFile(poFile, []byte(withEnding(partial)), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+				t.Fatalf("TranslatePoFile failed: %v", err)
+			}
+			updated, _ := os.ReadFile(poFile)
+			if !strings.Contains(string(updated), "es:World") {
+				t.Errorf("Expected the missing entry to be translated, got %q", updated)
+			}
+			if strings.HasSuffix(string(updated), "\n") != tt.final {
+				t.Errorf("Expected final newline %v, got %q", tt.final, updated)
+			}
+		})
+	}
+}
+
+func TestUpToDate(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	complete := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+`
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte(complete), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	p.Output = &buf
+	translator := &fakeTranslator{}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if updated, _ := os.ReadFile(poFile); string(updated) != complete || translator.calls() != 0 {
+		t.Errorf("Expected the complete file to be unchanged without translations, got %d call(s) and:\n%s", translator.calls(), updated)
+	}
+	if !strings.Contains(buf.String(), "Up to date") {
+		t.Errorf("Expected the file to be reported up to date, got:\n%s", buf.String())
+	}
+
+	// An obsolete entry still gets pruned in rewrite mode
+	p.PurgeObsolete = true
+	if err := os.WriteFile(poFile, []byte(complete+"\n#~ msgid \"Old\"\n#~ msgstr \"Viejo\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	buf.Reset()
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	if updated, _ := os.ReadFile(poFile); strings.Contains(string(updated), "Old") {
+		t.Errorf("Expected the obsolete entry to be removed, got:\n%s", updated)
+	}
+	if strings.Contains(buf.String(), "Up to date") {
+		t.Errorf("Expected the pruned file not to be reported up to date, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	pruned, _ := os.ReadFile(poFile)
+	if _, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+		t.Fatalf("RewritePoFile failed: %v", err)
+	}
+	if updated, _ := os.ReadFile(poFile); string(updated) != string(pruned) || !strings.Contains(buf.String(), "Up to date") {
+		t.Errorf("Expected the pruned file to be up to date, got:\n%s\n%s", buf.String(), updated)
+	}
+}
@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// charsetRegexp matches the charset of the Content-Type header, like
+// "Content-Type: text/plain; charset=ISO-8859-1\n".
+var charsetRegexp = regexp.MustCompile(`"Content-Type:[^"\n]*charset=([-\w.:]+)`)
+
+// charsetPeek is the size of the start of a catalog searched for the charset,
+// which is in the header entry at the top.
+const charsetPeek = 64 * 1024
+
+// headerCharset returns the charset declared in the Content-Type header of
+// the content, or "" when there is none.
+func headerCharset(content []byte) string {
+	if match := charsetRegexp.FindSubmatch(content); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// charsetEncoding returns the encoding of a charset, or nil for UTF-8 and
+// ASCII, which are used as is. The "CHARSET" placeholder of templates and
+// unknown charsets are read as UTF-8 too, the latter with a warning.
+func (p *Processor) charsetEncoding(charset string) encoding.Encoding {
+	switch strings.ToUpper(charset) {
+	case "", "CHARSET", "UTF-8", "UTF8", "US-ASCII", "ASCII":
+		return nil
+	}
+	enc, err := ianaindex.IANA.Encoding(charset)
+	if err != nil || enc == nil {
+		if _, warned := p.charsetWarnings.LoadOrStore(charset, true); !warned {
+			fmt.Fprintf(os.Stderr, "Warning: Unknown charset '%s', reading the catalog as UTF-8\n", charset)
+		}
+		return nil
+	}
+	return enc
+}
+
+// decodingReader wraps the reader of a catalog to convert it to UTF-8 from
+// the charset declared in its header.
+func (p *Processor) decodingReader(reader io.Reader) io.Reader {
+	buffered := bufio.NewReaderSize(reader, charsetPeek)
+	head, _ := buffered.Peek(charsetPeek)
+	if enc := p.charsetEncoding(headerCharset(head)); enc != nil {
+		return transform.NewReader(buffered, enc.NewDecoder())
+	}
+	return buffered
+}
+
+// encodeCharset converts UTF-8 content to the charset declared in its header.
+func (p *Processor) encodeCharset(content []byte) ([]byte, error) {
+	charset := headerCharset(content)
+	enc := p.charsetEncoding(charset)
+	if enc == nil {
+		return content, nil
+	}
+	encoded, err := enc.NewEncoder().Bytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode the catalog in %s (use --to-utf8 to convert it): %v", charset, err)
+	}
+	return encoded, nil
+}
+
+// needsUTF8 reports whether the content should be converted for --to-utf8.
+func (p *Processor) needsUTF8(content []byte) bool {
+	return p.ToUTF8 && p.charsetEncoding(headerCharset(content)) != nil
+}
+
+// withUTF8Charset returns the content with UTF-8 as the charset of its
+// header, for --to-utf8.
+func withUTF8Charset(content string) string {
+	match := charsetRegexp.FindStringSubmatchIndex(content)
+	if match == nil {
+		return content
+	}
+	return content[:match[2]] + "UTF-8" + content[match[3]:]
+}
@@ -0,0 +1,119 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLatin1Catalog(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\"Content-Type: text/plain; charset=CHARSET\\n\"\n\nmsgid \"Coffee\"\nmsgstr \"\"\n\nmsgid \"Greetings\"\nmsgstr \"\"\n"
+	// "Café" in ISO-8859-1, with a single byte for the é
+	poContent := "msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n\"Content-Type: text/plain; charset=ISO-8859-1\\n\"\n\nmsgid \"Coffee\"\nmsgstr \"Caf\xe9\"\n"
+
+	tests := []struct {
+		name    string
+		toUTF8  bool
+		charset string
+		want    []string // Expected bytes of the written file
+	}{
+		{name: "kept", charset: "ISO-8859-1", want: []string{"msgstr \"Caf\xe9\"", "msgstr \"Gr\xfc\xdfe\""}},
+		{name: "converted", toUTF8: true, charset: "UTF-8", want: []string{"msgstr \"Café\"", "msgstr \"Grüße\""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.ToUTF8 = tt.toUTF8
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_de.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			// The existing translation is read as UTF-8
+			entries, _, err := p.ParsePotFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to parse PO file: %v", err)
+			}
+			if msgstr := entries["Coffee"].Msgstr; msgstr != "Café" {
+				t.Errorf("Expected the decoded translation \"Café\", got %q", msgstr)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+			translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+				return "Grüße", nil
+			}}
+			if _, err := p.TranslatePoFile(poFile, potEntries, "en", "de", 0, translator); err != nil {
+				t.Fatalf("TranslatePoFile() error = %v", err)
+			}
+
+			content, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read PO file: %v", err)
+			}
+			if !strings.Contains(string(content), "charset="+tt.charset+"\\n") {
+				t.Errorf("Expected charset %s in the header, got:\n%s", tt.charset, content)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("Expected %q in the PO file, got:\n%q", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestToUTF8Unchanged(t *testing.T) {
+	p := New(DefaultOptions())
+	p.ToUTF8 = true
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Coffee\"\nmsgstr \"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_fr.po")
+	poContent := "msgid \"\"\nmsgstr \"\"\n\"Content-Type: text/plain; charset=ISO-8859-1\\n\"\n\nmsgid \"Coffee\"\nmsgstr \"Caf\xe9\"\n"
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// Nothing to translate, the file is still converted
+	for _, rewrite := range []bool{false, true} {
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		var err error
+		if rewrite {
+			_, err = p.RewritePoFile(poFile, potEntries, "en", "fr", 0, &fakeTranslator{})
+		} else {
+			_, err = p.TranslatePoFile(poFile, potEntries, "en", "fr", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+		content, _ := os.ReadFile(poFile)
+		if !strings.Contains(string(content), "charset=UTF-8\\n") || !strings.Contains(string(content), "msgstr \"Café\"") {
+			t.Errorf("rewrite=%v: expected the converted file, got:\n%q", rewrite, content)
+		}
+	}
+}
+
+func TestEncodeCharsetUnsupported(t *testing.T) {
+	p := New(DefaultOptions())
+	content := []byte("msgid \"\"\nmsgstr \"\"\n\"Content-Type: text/plain; charset=ISO-8859-1\\n\"\n\nmsgid \"Yen\"\nmsgstr \"円\"\n")
+	if _, err := p.encodeCharset(content); err == nil || !strings.Contains(err.Error(), "--to-utf8") {
+		t.Errorf("Expected an error suggesting --to-utf8, got %v", err)
+	}
+}
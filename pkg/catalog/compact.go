@@ -0,0 +1,23 @@
+package catalog
+
+import "strings"
+
+// compactContent formats the content of a PO file for --compact: the runs of
+// blank lines between the entries become a single blank line, and the blank
+// lines at the start and the end of the file are dropped. The newline at the
+// end of the file is kept.
+func compactContent(content string) string {
+	lines, lineEnding := splitLines(content)
+	var compacted []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			compacted = append(compacted, line)
+		} else if len(compacted) > 0 && compacted[len(compacted)-1] != "" {
+			compacted = append(compacted, "")
+		}
+	}
+	if len(compacted) > 0 && compacted[len(compacted)-1] == "" {
+		compacted = compacted[:len(compacted)-1]
+	}
+	return keepFinalNewline(joinLines(compacted, lineEnding), content)
+}
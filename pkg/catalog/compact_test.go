@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompactContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"single blank lines", "msgid \"a\"\nmsgstr \"\"\n\nmsgid \"b\"\nmsgstr \"\"\n", "msgid \"a\"\nmsgstr \"\"\n\nmsgid \"b\"\nmsgstr \"\"\n"},
+		{"runs of blank lines", "msgid \"a\"\nmsgstr \"\"\n\n\n  \nmsgid \"b\"\nmsgstr \"\"\n", "msgid \"a\"\nmsgstr \"\"\n\nmsgid \"b\"\nmsgstr \"\"\n"},
+		{"blank lines around", "\n\nmsgid \"a\"\nmsgstr \"\"\n\n\n", "msgid \"a\"\nmsgstr \"\"\n"},
+		{"no final newline", "msgid \"a\"\nmsgstr \"\"\n\n", "msgid \"a\"\nmsgstr \"\"\n"},
+		{"crlf", "msgid \"a\"\r\nmsgstr \"\"\r\n\r\n\r\nmsgid \"b\"\r\nmsgstr \"\"", "msgid \"a\"\r\nmsgstr \"\"\r\n\r\nmsgid \"b\"\r\nmsgstr \"\""},
+	}
+	for _, tt := range tests {
+		if compacted := compactContent(tt.content); compacted != tt.expected {
+			t.Errorf("%s: compactContent() = %q, want %q", tt.name, compacted, tt.expected)
+		}
+	}
+}
+
+func TestBlankLinesDontGrow(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"World\"\nmsgstr \"\"\n\nmsgid \"Goodbye\"\nmsgstr \"\"\n"
+	poContent := "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n\n\nmsgid \"Hello\"\nmsgstr \"Hola\"\n\n\n"
+
+	for _, compactMode := range []bool{false, true} {
+		p.Compact = compactMode
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		var blankLines []int
+		for run := 0; run < 2; run++ {
+			// The POT file grows between the runs
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+			if run == 0 {
+				delete(potEntries, "Goodbye")
+			}
+			if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+				t.Fatalf("TranslatePoFile() error = %v", err)
+			}
+			content, _ := os.ReadFile(poFile)
+			blank := 0
+			for _, line := range strings.Split(string(content), "\n") {
+				if line == "" {
+					blank++
+				}
+			}
+			blankLines = append(blankLines, blank)
+
+			// The entries added on each run are separated by one blank line
+			if strings.Contains(string(content), "\n\n\n#.") {
+				t.Errorf("compact=%v: expected a single blank line before the added entries, got:\n%s", compactMode, content)
+			}
+			if compactMode && strings.Contains(string(content), "\n\n\n") {
+				t.Errorf("Expected single blank lines with --compact, got:\n%s", content)
+			}
+		}
+		// One more blank line for the added entry
+		if blankLines[1] != blankLines[0]+1 {
+			t.Errorf("compact=%v: expected one more blank line after adding an entry, got %v", compactMode, blankLines)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+	p.Rewrite = true
+
+	pot := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	// The first Hello is untranslated, the first translated one is kept
+	po := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr "Mundo"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Hello"
+msgstr "Buenos días"
+`
+
+	tests := []struct {
+		name     string
+		dedupe   bool
+		hello    string
+		deduped  int
+		warnings int
+	}{
+		{"without dedupe", false, "Buenos días", 0, 2},
+		{"dedupe", true, "Hola", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Dedupe = tt.dedupe
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(potFile, []byte(pot), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := os.WriteFile(poFile, []byte(po), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+			if err != nil {
+				t.Fatalf("Failed to create stderr file: %v", err)
+			}
+			defer stderr.Close()
+			os.Stderr = stderr
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+			result, err := p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			if err != nil {
+				t.Fatalf("RewritePoFile() error = %v", err)
+			}
+			if result.Deduped != tt.deduped || result.Translated != 0 {
+				t.Errorf("Expected %d deduplicated and none translated, got %+v", tt.deduped, result)
+			}
+
+			content, _ := os.ReadFile(poFile)
+			if strings.Count(string(content), `msgid "Hello"`) != 1 {
+				t.Errorf("Expected a single Hello entry, got:\n%s", content)
+			}
+			entries, _, _ := p.ParsePotFile(poFile)
+			if entries["Hello"].Msgstr != tt.hello || entries["World"].Msgstr != "Mundo" {
+				t.Errorf("Expected Hello %q and World \"Mundo\", got %q and %q", tt.hello, entries["Hello"].Msgstr, entries["World"].Msgstr)
+			}
+
+			written, _ := os.ReadFile(stderr.Name())
+			if n := strings.Count(string(written), "'Hello' appears more than once"); n != tt.warnings {
+				t.Errorf("Expected %d duplicate warnings, got %q", tt.warnings, written)
+			}
+		})
+	}
+
+	// Translate mode only warns about the duplicates
+	p.Rewrite, p.Dedupe = false, false
+	tempDir := t.TempDir()
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte(po), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+	if err != nil {
+		t.Fatalf("Failed to create stderr file: %v", err)
+	}
+	defer stderr.Close()
+	os.Stderr = stderr
+	potEntries := map[string]POEntry{"Hello": {}, "World": {}}
+	if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{}); err != nil {
+		t.Fatalf("TranslatePoFile() error = %v", err)
+	}
+	if written, _ := os.ReadFile(stderr.Name()); strings.Count(string(written), "appears more than once") != 2 {
+		t.Errorf("Expected 2 duplicate warnings, got %q", written)
+	}
+}
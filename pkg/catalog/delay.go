@@ -0,0 +1,30 @@
+package catalog
+
+import "time"
+
+// Sleeper waits the delays between translations. Tests use a fake one to
+// check the delays without waiting for them.
+type Sleeper interface {
+	Sleep(d time.Duration)
+}
+
+// SleeperFunc is a function used as a Sleeper, like time.Sleep.
+type SleeperFunc func(d time.Duration)
+
+func (f SleeperFunc) Sleep(d time.Duration) {
+	f(d)
+}
+
+// targetDelay returns the delay between the translations into the target
+// language: the --lang-delay of the language, or else that of its base
+// language, so "ja" also applies to "ja-JP", or else the delay of --delay or
+// --fast.
+func (p *Processor) targetDelay(targetLang string, delay time.Duration) time.Duration {
+	if override, exists := p.LanguageDelays[NormalizeLocale(targetLang)]; exists {
+		return override
+	}
+	if override, exists := p.LanguageDelays[baseLanguage(targetLang)]; exists {
+		return override
+	}
+	return delay
+}
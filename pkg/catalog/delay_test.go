@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSleeper records the delays instead of waiting for them.
+type fakeSleeper struct {
+	mu    sync.Mutex
+	slept []time.Duration
+}
+
+func (s *fakeSleeper) Sleep(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slept = append(s.slept, d)
+}
+
+func TestLanguageDelay(t *testing.T) {
+	p := New(DefaultOptions())
+	p.LanguageDelays = map[string]time.Duration{"ja": 2 * time.Second, "pt_BR": 3 * time.Second}
+
+	tests := []struct {
+		targetLang string
+		expected   time.Duration
+	}{
+		{"ja", 2 * time.Second},
+		{"ja-JP", 2 * time.Second},
+		{"pt-BR", 3 * time.Second},
+		{"pt", time.Second},
+		{"de", time.Second},
+	}
+	for _, tt := range tests {
+		fake := &fakeSleeper{}
+		p.Sleeper = fake
+		keys := []string{"Delay one " + tt.targetLang, "Delay two " + tt.targetLang, "Delay three " + tt.targetLang}
+		p.translateEntries("test.po", keys, nil, nil, nil, 2, "en", tt.targetLang, time.Second, &fakeTranslator{}, nil)
+
+		// No delay after the last translation
+		expected := []time.Duration{tt.expected, tt.expected}
+		if !slices.Equal(fake.slept, expected) {
+			t.Errorf("%s: slept %v, want %v", tt.targetLang, fake.slept, expected)
+		}
+	}
+}
+
+func TestSleeperDelays(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Sleep one"
+msgstr ""
+
+msgid "Sleep two"
+msgstr ""
+
+msgid "Sleep three"
+msgstr ""
+
+msgid "One sleep"
+msgid_plural "Many sleeps"
+msgstr[0] ""
+msgstr[1] ""
+`
+	for _, rewrite := range []bool{false, true} {
+		fake := &fakeSleeper{}
+		p.Sleeper = fake
+
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		translate := p.TranslatePoFile
+		if rewrite {
+			translate = p.RewritePoFile
+		}
+		if _, err := translate(poFile, potEntries, "en", "es", 500*time.Millisecond, &fakeTranslator{}); err != nil {
+			t.Fatalf("Translating failed: %v", err)
+		}
+
+		// A delay after each entry but the last, and one between the singular
+		// and the plural of the plural entry
+		expected := slices.Repeat([]time.Duration{500 * time.Millisecond}, 4)
+		if !slices.Equal(fake.slept, expected) {
+			t.Errorf("rewrite=%v: slept %v, want %v", rewrite, fake.slept, expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// detectSampleSize is the maximum number of msgids sent for detection.
+const detectSampleSize = 10
+
+// detectAgreement is the share of the detection confidence the most likely
+// language needs for the result to be used without asking the user.
+const detectAgreement = 0.75
+
+// Detector detects the language of a text, returning its code and a
+// confidence between 0 and 1, until the context is done. Translators that
+// can detect implement it.
+type Detector interface {
+	Detect(ctx context.Context, text string) (string, float64, error)
+}
+
+// confirm asks the user a yes/no question on stdin, until the context is
+// done. Tests replace it.
+var confirm = func(ctx context.Context, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := readAnswer(ctx)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// detectionSample returns up to detectSampleSize msgids of the entries,
+// longest first as those are detected most reliably. Msgids without letters
+// say nothing about the language and are skipped.
+func detectionSample(entries map[string]POEntry) []string {
+	var sample []string
+	for key := range entries {
+		_, msgid := splitEntryKey(key)
+		if hasLetters(msgid) {
+			sample = append(sample, msgid)
+		}
+	}
+	sort.Slice(sample, func(i, j int) bool {
+		if len(sample[i]) != len(sample[j]) {
+			return len(sample[i]) > len(sample[j])
+		}
+		return sample[i] < sample[j]
+	})
+	if len(sample) > detectSampleSize {
+		sample = sample[:detectSampleSize]
+	}
+	return sample
+}
+
+// detectSourceLanguage detects the language of a sample of the msgids. The
+// confidence of each detection counts as a vote for its language. It returns
+// the most likely language and whether it got less than detectAgreement of
+// the votes, in which case the result should be confirmed.
+func (p *Processor) detectSourceLanguage(entries map[string]POEntry, detector Detector, delay time.Duration) (string, bool, error) {
+	sample := detectionSample(entries)
+	if len(sample) == 0 {
+		return "", false, fmt.Errorf("no text to detect the language from")
+	}
+
+	votes := make(map[string]float64)
+	total := 0.0
+	for i, text := range sample {
+		if i > 0 {
+			p.Sleeper.Sleep(delay)
+		}
+		if err := p.waitRateLimit(); err != nil {
+			return "", false, err
+		}
+		ctx, cancel := p.requestContext()
+		language, confidence, err := detector.Detect(ctx, text)
+		cancel()
+		if err != nil {
+			return "", false, err
+		}
+		if language == "" || !ValidLocale(language) {
+			continue
+		}
+		votes[NormalizeLocale(language)] += confidence
+		total += confidence
+	}
+
+	best := ""
+	for language, score := range votes {
+		if best == "" || score > votes[best] || (score == votes[best] && language < best) {
+			best = language
+		}
+	}
+	if best == "" || total == 0 {
+		return "", false, fmt.Errorf("the language could not be detected")
+	}
+
+	return best, votes[best]/total < detectAgreement, nil
+}
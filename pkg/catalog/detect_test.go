@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeDetector is a fakeTranslator that detects the languages given per text,
+// defaulting to English.
+type fakeDetector struct {
+	fakeTranslator
+	languages map[string]string
+}
+
+func (f *fakeDetector) Detect(ctx context.Context, text string) (string, float64, error) {
+	if language, exists := f.languages[text]; exists {
+		return language, 0.9, nil
+	}
+	return "en", 0.9, nil
+}
+
+func TestDetectSourceLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	entries := map[string]POEntry{
+		"Hello world":            {},
+		"Save the file":          {},
+		"Open the settings":      {},
+		"Delete":                 {},
+		entryKey("menu", "Quit"): {},
+		"12:30":                  {},
+	}
+
+	tests := []struct {
+		name          string
+		languages     map[string]string
+		wantLanguage  string
+		wantAmbiguous bool
+	}{
+		{"unanimous", nil, "en", false},
+		{"region is normalized", map[string]string{"Hello world": "en-us", "Save the file": "en-us", "Open the settings": "en-us", "Delete": "en-us", "Quit": "en-us"}, "en_US", false},
+		{"majority", map[string]string{"Delete": "nl"}, "en", false},
+		{"ambiguous", map[string]string{"Delete": "nl", "Quit": "nl"}, "en", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := &fakeDetector{languages: tt.languages}
+			language, ambiguous, err := p.detectSourceLanguage(entries, detector, 0)
+			if err != nil {
+				t.Fatalf("detectSourceLanguage() error = %v", err)
+			}
+			if language != tt.wantLanguage || ambiguous != tt.wantAmbiguous {
+				t.Errorf("detectSourceLanguage() = %q, %v, want %q, %v", language, ambiguous, tt.wantLanguage, tt.wantAmbiguous)
+			}
+		})
+	}
+
+	if _, _, err := p.detectSourceLanguage(map[string]POEntry{"42": {}, "...": {}}, &fakeDetector{}, 0); err == nil {
+		t.Error("Expected an error without text to detect")
+	}
+}
+
+func TestDetectSourceUpdatesPot(t *testing.T) {
+	p := New(DefaultOptions())
+	previousConfirm := confirm
+	p.Layout, p.DetectSource = "flat", true
+	defer func() { confirm = previousConfirm }()
+
+	potContent := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	tests := []struct {
+		name      string
+		languages map[string]string
+		confirmed bool
+		wantError bool
+		asked     bool
+	}{
+		{"clear", nil, false, false, false},
+		{"ambiguous and confirmed", map[string]string{"Hello": "nl"}, true, false, true},
+		{"ambiguous and declined", map[string]string{"Hello": "nl"}, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			potFile := filepath.Join(dir, "default.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+
+			asked := false
+			confirm = func(ctx context.Context, question string) bool {
+				asked = true
+				return tt.confirmed
+			}
+
+			_, err := p.ProcessDirectory(dir, dir, "default", 0, &fakeDetector{languages: tt.languages}, nil)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ProcessDirectory() error = %v, wantError %v", err, tt.wantError)
+			}
+			if asked != tt.asked {
+				t.Errorf("Expected confirmation asked = %v, got %v", tt.asked, asked)
+			}
+
+			content, _ := os.ReadFile(potFile)
+			hasLanguage := strings.Contains(string(content), `"Language: `)
+			if hasLanguage == tt.wantError {
+				t.Errorf("Unexpected POT file content:\n%s", content)
+			}
+			if !tt.wantError && !strings.Contains(string(content), `"Language: en\n"`) {
+				t.Errorf("Expected detected language in POT file, got:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestLibreTranslatorDetect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/detect" || r.FormValue("q") != "Hallo wereld" {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode([]libreDetection{{Language: "nl", Confidence: 85}})
+	}))
+	defer server.Close()
+
+	language, confidence, err := newLibreTranslator(server.URL, "").Detect(context.Background(), "Hallo wereld")
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if language != "nl" || confidence != 0.85 {
+		t.Errorf("Detect() = %q, %v, want \"nl\", 0.85", language, confidence)
+	}
+}
@@ -0,0 +1,161 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// diffLine is a line of a diff, with ' ' for an unchanged line, '-' for a
+// removed line and '+' for an added line.
+type diffLine struct {
+	op   byte
+	text string // Including the line ending, if any
+}
+
+// unifiedDiff returns the changes between the contents as a unified diff of
+// the named file, or an empty string when they are equal.
+func unifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+	lines := diffLines(splitAfterNewline(before), splitAfterNewline(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", name, name)
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(lines); {
+		// Find the next change, and extend the hunk as long as the
+		// following change is close enough to share the context
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i-last <= 2*diffContext+1; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(lines))
+
+		oldLine += from - start
+		newLine += from - start
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				oldCount++
+			}
+			if line.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, line := range lines[from:to] {
+			b.WriteByte(line.op)
+			b.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		oldLine += oldCount
+		newLine += newCount
+		start = to
+	}
+	return b.String()
+}
+
+// hunkRange formats the start and length of a hunk, where an empty range
+// starts at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitAfterNewline splits the content into lines that keep their line
+// endings, so a missing newline at the end counts as a change.
+func splitAfterNewline(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit from the lines before to after, using
+// the Myers algorithm. Only the reachable part of each step is kept for the
+// backtracking, so the memory grows with the number of changes.
+func diffLines(before, after []string) []diffLine {
+	n, m := len(before), len(after)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int // The furthest x of each diagonal -d..d per step d
+search:
+	for d := 0; d <= n+m; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && before[x] == after[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				break search
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	// Walk back from the end, collecting the lines in reverse
+	var lines []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1] // Diagonal k is at index k+d-1
+		k := x - y
+		previousK := k - 1
+		if k == -d || (k != d && previous[k-1+d-1] < previous[k+1+d-1]) {
+			previousK = k + 1
+		}
+		previousX := previous[previousK+d-1]
+		previousY := previousX - previousK
+		for x > previousX && y > previousY {
+			lines = append(lines, diffLine{' ', before[x-1]})
+			x--
+			y--
+		}
+		if x == previousX {
+			lines = append(lines, diffLine{'+', after[y-1]})
+			y--
+		} else {
+			lines = append(lines, diffLine{'-', before[x-1]})
+			x--
+		}
+	}
+	for x > 0 {
+		lines = append(lines, diffLine{' ', before[x-1]})
+		x--
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
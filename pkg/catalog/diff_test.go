@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"added to empty", "", "a\n", "--- f\n+++ f\n@@ -0,0 +1 @@\n+a\n"},
+		{"missing newline", "a\nb", "a\nb\n", "--- f\n+++ f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"X\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nY\n",
+			"--- f\n+++ f\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+		{
+			"shared context",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"X\n2\n3\n4\n5\n6\n7\nY\n",
+			"--- f\n+++ f\n@@ -1,8 +1,8 @@\n-1\n+X\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+Y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", tt.before, tt.after); got != tt.expected {
+				t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffDoesNotWrite(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		var buf bytes.Buffer
+		p.Diff, p.Output = true, &buf
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+		if result.Translated != 2 {
+			t.Errorf("rewrite=%v: expected 2 translated strings, got %d", rewrite, result.Translated)
+		}
+
+		diff := buf.String()
+		for _, want := range []string{"--- " + poFile + "\n", "-msgstr \"\"\n+msgstr \"es:Hello\"\n", "+msgid \"Goodbye\"\n+msgstr \"es:Goodbye\"\n"} {
+			if !strings.Contains(diff, want) {
+				t.Errorf("rewrite=%v: expected %q in diff:\n%s", rewrite, want, diff)
+			}
+		}
+
+		content, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read PO file: %v", err)
+		}
+		if string(content) != poContent {
+			t.Errorf("rewrite=%v: expected the PO file to be unchanged, got:\n%s", rewrite, content)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"strings"
+)
+
+// directivePrefix starts the extracted comments with instructions for
+// potranslate, like "#. potranslate: skip".
+const directivePrefix = "potranslate:"
+
+// entryDirectives returns the potranslate directives of the extracted
+// comments of the POT entry: whether "skip" leaves it untranslated, and the
+// text of "context=...", which is sent to the backend with the msgid.
+func entryDirectives(entry POEntry) (bool, string) {
+	skip, context := false, ""
+	for _, line := range entry.Comments.Extracted {
+		comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#."))
+		if len(comment) < len(directivePrefix) || !strings.EqualFold(comment[:len(directivePrefix)], directivePrefix) {
+			continue
+		}
+		directive := strings.TrimSpace(comment[len(directivePrefix):])
+		if strings.EqualFold(directive, "skip") {
+			skip = true
+		} else if name, value, found := strings.Cut(directive, "="); found && strings.EqualFold(strings.TrimSpace(name), "context") {
+			context = strings.TrimSpace(value)
+		}
+	}
+	return skip, context
+}
+
+// skippedByDirective reports whether a "#. potranslate: skip" comment leaves
+// the POT entry untranslated.
+func skippedByDirective(entry POEntry) bool {
+	skip, _ := entryDirectives(entry)
+	return skip
+}
+
+// directiveContext returns the text of the "#. potranslate: context=..."
+// comment of the POT entry, or an empty string without one.
+func directiveContext(entry POEntry) string {
+	_, context := entryDirectives(entry)
+	return context
+}
+
+// withContext prepends the context to the text sent to the backend, on a line
+// of its own, so that it can be stripped from the translation again.
+func withContext(text, context string) string {
+	if context == "" {
+		return text
+	}
+	return context + "\n" + text
+}
+
+// withoutContext strips the translated context line from the translation. It
+// reports false when the backend didn't keep the context on its own line.
+func withoutContext(translation, context string) (string, bool) {
+	if context == "" {
+		return translation, true
+	}
+	_, rest, found := strings.Cut(translation, "\n")
+	return rest, found
+}
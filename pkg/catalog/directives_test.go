@@ -0,0 +1,100 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDirectives(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#. potranslate: skip
+msgid "ACME"
+msgstr ""
+
+#. Button of the checkout page
+#. potranslate: context=a bank card being charged
+msgid "Charge"
+msgstr ""
+
+#. POTRANSLATE: Skip
+msgid "Ltd."
+msgstr ""
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		// Translates each line on its own, like the backends do
+		translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+			return to + ":" + strings.ReplaceAll(text, "\n", "\n"+to+":"), nil
+		}}
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+
+		// The context is sent on a line of its own before the msgid
+		slices.Sort(translator.texts)
+		expected := []string{"Hello", "a bank card being charged\nCharge"}
+		if !slices.Equal(translator.texts, expected) {
+			t.Errorf("rewrite=%v: expected %q to be sent, got %q", rewrite, expected, translator.texts)
+		}
+		if result.Added != 4 || result.Translated != 2 {
+			t.Errorf("rewrite=%v: expected 4 added and 2 translated, got %+v", rewrite, result)
+		}
+
+		content, _ := os.ReadFile(poFile)
+		for _, expected := range []string{"msgid \"ACME\"\nmsgstr \"\"\n", "msgid \"Ltd.\"\nmsgstr \"\"\n", "msgid \"Charge\"\nmsgstr \"es:Charge\"\n"} {
+			if !strings.Contains(string(content)+"\n", expected) {
+				t.Errorf("rewrite=%v: expected %q in:\n%s", rewrite, expected, content)
+			}
+		}
+	}
+}
+
+func TestTranslateInContext(t *testing.T) {
+	p := New(DefaultOptions())
+	// A backend that joins the lines loses the context, so the text is
+	// translated again without it
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		return "es:" + strings.ReplaceAll(text, "\n", " "), nil
+	}}
+	translated, _, _, err := p.translateInContext(translator, "", "Charge", "a bank card", "c", "en", "es")
+	if err != nil {
+		t.Fatalf("translateInContext() error = %v", err)
+	}
+	if translated != "es:Charge" || translator.calls() != 2 {
+		t.Errorf("Expected the text to be translated again without the context, got %q with %d calls", translated, translator.calls())
+	}
+}
@@ -0,0 +1,60 @@
+package catalog_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mevdschee/potranslate/pkg/catalog"
+)
+
+// upperTranslator "translates" by converting the text to upper case.
+type upperTranslator struct{}
+
+func (upperTranslator) Translate(text, from, to string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
+func ExampleProcessor_TranslatePoFile() {
+	dir, err := os.MkdirTemp("", "catalog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	potFile := filepath.Join(dir, "default.pot")
+	poFile := filepath.Join(dir, "default_nl.po")
+	os.WriteFile(potFile, []byte("msgid \"\"\nmsgstr \"\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"), 0644)
+
+	options := catalog.DefaultOptions()
+	options.Progress = "none"
+	options.Output = io.Discard
+	processor := catalog.New(options)
+
+	potEntries, _, err := processor.ParsePotFile(potFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := processor.CopyPotToPo(potFile, poFile, "nl"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	result, err := processor.TranslatePoFile(poFile, potEntries, "en", "nl", 0, upperTranslator{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(result.Summary())
+
+	entries, _, _ := processor.ParsePotFile(poFile)
+	for _, entry := range entries {
+		fmt.Println(entry.Msgstr)
+	}
+	// Output:
+	// Translated 1 string(s)
+	// HELLO
+}
@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// csvColumns are the columns of a CSV file written by --export-missing.
+var csvColumns = []string{"msgctxt", "msgid", "msgid_plural", "msgstr", "comments"}
+
+// ExportMissing writes the entries of the PO file of the domain in the
+// directory that still need a translation to path, for --export-missing.
+// These are the entries that would be translated, including the POT entries
+// missing from the PO file, with their comments and context. A path ending
+// in .csv gets a CSV file, any other path a PO file with the header of the
+// PO file. The PO files are filtered by --only-lang and --skip-lang, which
+// must leave exactly one. It returns the number of exported entries.
+func (p *Processor) ExportMissing(path, directory, domain string) (int, error) {
+	potFile := p.potFilePath(directory, domain)
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("POT file '%s' not found", potFile)
+		}
+		return 0, fmt.Errorf("parsing POT file: %v", err)
+	}
+
+	poFiles, err := p.FindPoFiles(directory, domain, p.Layout)
+	if err != nil {
+		return 0, fmt.Errorf("finding PO files: %v", err)
+	}
+	poFiles = p.filterByLanguage(poFiles, domain)
+	if len(poFiles) != 1 {
+		return 0, fmt.Errorf("found %d PO files for domain '%s', select a single language with --only-lang", len(poFiles), domain)
+	}
+
+	content, err := p.readCatalog(poFiles[0])
+	if err != nil {
+		return 0, err
+	}
+	lines, lineEnding := splitLines(string(content))
+	nplurals := parsePluralCount(lines)
+	blocks, _ := parsePoLines(lines)
+
+	var header *catalogEntry
+	existing := make(map[string]*catalogEntry)
+	for _, block := range blocks {
+		if block.isHeader() && header == nil {
+			header = block
+		} else if block.isEntry {
+			existing[p.normalizedKey(block.key())] = block
+		}
+	}
+
+	// Entries are exported in POT order
+	var missing []*catalogEntry
+	for _, key := range p.entryOrder(potEntries) {
+		block, exists := existing[p.normalizedKey(key)]
+		if !exists {
+			added, _ := parsePoLines(p.formatMissingEntry(key, potEntries[key], nplurals))
+			block = added[0]
+		} else if !p.needsTranslation(block) {
+			continue
+		}
+		missing = append(missing, block)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return len(missing), writeMissingCSV(path, missing)
+	}
+
+	var exported []string
+	if header != nil {
+		exported = p.formatPoLines([]*catalogEntry{header})
+	}
+	for _, block := range missing {
+		if len(exported) > 0 {
+			exported = append(exported, "")
+		}
+		exported = append(exported, p.formatPoLines([]*catalogEntry{block})...)
+	}
+	exported = append(exported, "")
+	return len(missing), p.writeCatalog(path, []byte(joinLines(exported, lineEnding)))
+}
+
+// writeMissingCSV writes the entries as CSV rows with the csvColumns. The
+// msgstr of plural entries is left empty, and the comments are the comment
+// lines of the entry, including its flags.
+func writeMissingCSV(path string, entries []*catalogEntry) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvColumns)
+	for _, entry := range entries {
+		msgstr := entry.Msgstr
+		if entry.isPlural() {
+			msgstr = ""
+		}
+		w.Write([]string{entry.Msgctxt, entry.Msgid, entry.MsgidPlural, msgstr, strings.Join(entry.comments, "\n")})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), fileMode(path))
+}
@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExportMissing(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout, p.OnlyLang = "flat", "es"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+
+#: src/menu.go:3
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "Almost"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+#. Added to the POT later
+msgid "New"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Goodbye"
+msgstr ""
+
+#: src/menu.go:3
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+#, fuzzy
+msgid "Almost"
+msgstr "Casi"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+#~ msgid "Old"
+#~ msgstr ""
+`
+	dir := t.TempDir()
+	files := map[string]string{
+		"default.pot":   potContent,
+		"default_es.po": poContent,
+		"default_fr.po": strings.Replace(poContent, "Language: es", "Language: fr", 1),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	expected := []string{"%d file", "Almost", "Goodbye", "New", entryKey("menu", "Open")}
+
+	// As a PO file
+	exportFile := filepath.Join(t.TempDir(), "missing_es.po")
+	count, err := p.ExportMissing(exportFile, dir, "default")
+	if err != nil {
+		t.Fatalf("ExportMissing() error = %v", err)
+	}
+	if count != len(expected) {
+		t.Errorf("Expected %d exported entries, got %d", len(expected), count)
+	}
+	entries, language, err := p.ParsePotFile(exportFile)
+	if err != nil {
+		t.Fatalf("Failed to parse exported file: %v", err)
+	}
+	var keys []string
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, "|") != strings.Join(expected, "|") || language != "es" {
+		t.Errorf("Expected entries %q in language es, got %q in %q", expected, keys, language)
+	}
+	if entries["Almost"].Msgstr != "Casi" || !hasFlag(entries["Almost"].Flags, "fuzzy") {
+		t.Errorf("Expected the fuzzy translation to be kept, got %+v", entries["Almost"])
+	}
+	content, _ := os.ReadFile(exportFile)
+	for _, want := range []string{"#: src/menu.go:3\nmsgctxt \"menu\"\nmsgid \"Open\"\n", "#. Added to the POT later\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in exported file:\n%s", want, content)
+		}
+	}
+
+	// As a CSV file
+	exportFile = filepath.Join(t.TempDir(), "missing_es.csv")
+	if _, err := p.ExportMissing(exportFile, dir, "default"); err != nil {
+		t.Fatalf("ExportMissing() error = %v", err)
+	}
+	file, err := os.Open(exportFile)
+	if err != nil {
+		t.Fatalf("Failed to open exported file: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read exported CSV: %v", err)
+	}
+	if len(rows) != len(expected)+1 || strings.Join(rows[0], ",") != "msgctxt,msgid,msgid_plural,msgstr,comments" {
+		t.Fatalf("Expected a header and %d rows, got %q", len(expected), rows)
+	}
+	if open := rows[2]; open[0] != "menu" || open[1] != "Open" || open[4] != "#: src/menu.go:3" {
+		t.Errorf("Expected the menu entry with its comment in POT order, got %q", open)
+	}
+
+	// Every language at once can't be exported to a single file
+	p.OnlyLang = ""
+	if _, err := p.ExportMissing(exportFile, dir, "default"); err == nil {
+		t.Error("Expected an error for more than one PO file")
+	}
+}
@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// fallbackTranslator tries the backends of --backend and --fallback in order
+// for every text, until one of them translates it. A backend is skipped for
+// the target languages it doesn't list.
+type fallbackTranslator struct {
+	names       []string
+	translators []Translator
+	languages   languageLists
+}
+
+// NewFallbackTranslator creates the translators for the named backends and
+// returns a translator that falls back from each backend to the next.
+func NewFallbackTranslator(backends []string, endpoint, apiKey string) (Translator, error) {
+	chain := &fallbackTranslator{}
+	for _, name := range backends {
+		name = strings.TrimSpace(name)
+		translator, err := NewTranslator(name, endpoint, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		chain.names = append(chain.names, name)
+		chain.translators = append(chain.translators, translator)
+	}
+	return chain, nil
+}
+
+func (f *fallbackTranslator) Translate(text, from, to string) (string, error) {
+	return f.TranslateContext(context.Background(), text, from, to)
+}
+
+// TranslateContext passes a failed text on to the next backend, saying which
+// backend is used instead. Once the context is done, because of --timeout or
+// an interrupt, the text is not passed on.
+func (f *fallbackTranslator) TranslateContext(ctx context.Context, text, from, to string) (string, error) {
+	translation, _, err := f.translateBackend(ctx, text, from, to)
+	return translation, err
+}
+
+// translateBackend translates a text like TranslateContext, also returning
+// the name of the backend that translated it.
+func (f *fallbackTranslator) translateBackend(ctx context.Context, text, from, to string) (string, string, error) {
+	err := fmt.Errorf("target language '%s' is not supported by any backend", to)
+	failed := ""
+	for n, translator := range f.translators {
+		if !f.languages.supports(ctx, translator, f.names[n], to) {
+			continue
+		}
+		if failed != "" {
+			fmt.Fprintf(os.Stderr, "\nWarning: %s, using the %s backend for '%s'\n", failed, f.names[n], text)
+		}
+		var translation string
+		if translation, err = translateContext(ctx, translator, text, from, to); err == nil {
+			return translation, f.names[n], nil
+		}
+		if ctx.Err() != nil {
+			return "", "", err
+		}
+		failed = fmt.Sprintf("The %s backend failed: %v", f.names[n], err)
+	}
+	return "", "", err
+}
+
+// supports reports whether any of the backends translates to the language.
+func (f *fallbackTranslator) supports(ctx context.Context, language string) bool {
+	for n, translator := range f.translators {
+		if f.languages.supports(ctx, translator, f.names[n], language) {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect detects the language with the first backend that can.
+func (f *fallbackTranslator) Detect(ctx context.Context, text string) (string, float64, error) {
+	for _, translator := range f.translators {
+		if detector, ok := translator.(Detector); ok {
+			return detector.Detect(ctx, text)
+		}
+	}
+	return "", 0, fmt.Errorf("none of the backends can detect languages")
+}
+
+// backendRecorder translates the texts of a single entry with the chain,
+// recording the backends that translated them for --verbose. Timed out
+// requests may still finish in the background, hence the lock.
+type backendRecorder struct {
+	chain    *fallbackTranslator
+	mu       sync.Mutex
+	backends []string
+}
+
+func (r *backendRecorder) Translate(text, from, to string) (string, error) {
+	return r.TranslateContext(context.Background(), text, from, to)
+}
+
+func (r *backendRecorder) TranslateContext(ctx context.Context, text, from, to string) (string, error) {
+	translation, name, err := r.chain.translateBackend(ctx, text, from, to)
+	if err == nil {
+		r.mu.Lock()
+		if !slices.Contains(r.backends, name) {
+			r.backends = append(r.backends, name)
+		}
+		r.mu.Unlock()
+	}
+	return translation, err
+}
+
+// names returns the backends that translated the texts, in order.
+func (r *backendRecorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.backends)
+}
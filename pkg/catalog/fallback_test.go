@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFallbackTranslator(t *testing.T) {
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+
+	failing := func(name string) *fakeTranslator {
+		return &fakeTranslator{translate: func(text, from, to string) (string, error) {
+			return "", fmt.Errorf("%s is down", name)
+		}}
+	}
+	tests := []struct {
+		name        string
+		primary     Translator
+		secondary   *fakeTranslator
+		expected    string
+		expectedErr string
+		warning     string
+	}{
+		{"primary succeeds", &fakeTranslator{}, &fakeTranslator{}, "de:Save", "", ""},
+		{"primary fails", failing("deepl"), &fakeTranslator{}, "de:Save", "", "The deepl backend failed: deepl is down, using the google backend for 'Save'"},
+		{"language not listed", &fakeLister{languages: []string{"fr"}}, &fakeTranslator{}, "de:Save", "", ""},
+		{"all fail", failing("deepl"), failing("google"), "", "google is down", "using the google backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &fallbackTranslator{names: []string{"deepl", "google"}, translators: []Translator{tt.primary, tt.secondary}}
+
+			stderrFile := filepath.Join(t.TempDir(), "stderr")
+			stderr, err := os.Create(stderrFile)
+			if err != nil {
+				t.Fatalf("Failed to create stderr file: %v", err)
+			}
+			os.Stderr = stderr
+			translated, err := chain.Translate("Save", "en", "de")
+			os.Stderr = previousStderr
+			stderr.Close()
+			warnings, _ := os.ReadFile(stderrFile)
+
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Errorf("Expected error %q, got %v", tt.expectedErr, err)
+				}
+			} else if err != nil || translated != tt.expected {
+				t.Errorf("Translate() = %q, %v, want %q", translated, err, tt.expected)
+			}
+			if tt.warning == "" && len(warnings) > 0 || !strings.Contains(string(warnings), tt.warning) {
+				t.Errorf("Expected warning %q, got %q", tt.warning, warnings)
+			}
+			if lister, ok := tt.primary.(*fakeLister); ok && lister.calls() != 0 {
+				t.Errorf("Expected no request to the backend without the language, got %d", lister.calls())
+			}
+		})
+	}
+}
+
+func TestFallbackSupportsLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	chain := &fallbackTranslator{
+		names:       []string{"first", "second"},
+		translators: []Translator{&fakeLister{languages: []string{"fr"}}, &fakeLister{languages: []string{"nl"}}},
+	}
+	for language, expected := range map[string]bool{"fr": true, "nl_BE": true, "de": false} {
+		if supported := p.supportsLanguage(chain, language); supported != expected {
+			t.Errorf("supportsLanguage(%q) = %v, want %v", language, supported, expected)
+		}
+	}
+}
+
+func TestFallbackVerbose(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+	p.Verbose, p.Progress, p.Backend = true, "none", "deepl"
+	os.Stderr, _ = os.Open(os.DevNull)
+
+	// The primary backend only translates "Open"
+	primary := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		if text != "Open" {
+			return "", fmt.Errorf("deepl is down")
+		}
+		return to + ":" + text, nil
+	}}
+	chain := &fallbackTranslator{names: []string{"deepl", "google"}, translators: []Translator{primary, &fakeTranslator{}}}
+	for _, tt := range []struct {
+		name       string
+		translator Translator
+		expected   []string
+	}{
+		{"fallback", chain, []string{
+			"Translated 'Open' into de with the deepl backend",
+			"Translated 'Save' into de with the google backend",
+			"Translated 'File' into de with the google and deepl backends",
+			"Translated '42' into de without the backend",
+		}},
+		{"single backend", &fakeTranslator{}, []string{"Translated 'Save' into de with the deepl backend"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p.Output = &buf
+			plurals := map[string]string{"File": "Open"}
+			result := p.translateEntries("test_de.po", []string{"Open", "Save", "File", "42"}, plurals, nil, nil, 2, "en", "de", 0, tt.translator, nil)
+			if result.count != 4 {
+				t.Errorf("Expected 4 translations, got %+v", result)
+			}
+			for _, line := range tt.expected {
+				if !strings.Contains(buf.String(), line+"\n") {
+					t.Errorf("Expected %q in the output, got:\n%s", line, buf.String())
+				}
+			}
+		})
+	}
+}
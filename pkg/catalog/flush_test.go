@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlushEvery(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	tests := []struct {
+		flushEvery int
+		rewrite    bool
+		onDisk     []string // Translations in the file when the last one is requested
+	}{
+		{0, false, nil},
+		{2, false, []string{"es:Hello", "es:World"}},
+		{2, true, []string{"es:Hello", "es:World"}},
+		{1, false, []string{"es:Hello", "es:World"}},
+	}
+	for _, tt := range tests {
+		p.FlushEvery = tt.flushEvery
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		// The file on disk when translating the last string is what a crash
+		// at that point would leave
+		var crashed string
+		translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+			if text == "Goodbye" {
+				content, _ := os.ReadFile(poFile)
+				crashed = string(content)
+			}
+			return to + ":" + text, nil
+		}}
+		if tt.rewrite {
+			_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+		} else {
+			_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("flushEvery=%d rewrite=%v: unexpected error: %v", tt.flushEvery, tt.rewrite, err)
+		}
+
+		for _, translation := range []string{"es:Hello", "es:World"} {
+			flushed := strings.Contains(crashed, "msgstr \""+translation+"\"")
+			if expected := strings.Contains(strings.Join(tt.onDisk, ","), translation); flushed != expected {
+				t.Errorf("flushEvery=%d rewrite=%v: expected %s on disk before the end to be %v, got:\n%s", tt.flushEvery, tt.rewrite, translation, expected, crashed)
+			}
+		}
+		if strings.Contains(crashed, "es:Goodbye") {
+			t.Errorf("flushEvery=%d rewrite=%v: expected the last translation to be missing before the end", tt.flushEvery, tt.rewrite)
+		}
+
+		// The final write still has all the translations
+		content, _ := os.ReadFile(poFile)
+		for _, translation := range []string{"es:Hello", "es:World", "es:Goodbye"} {
+			if !strings.Contains(string(content), "msgstr \""+translation+"\"") {
+				t.Errorf("flushEvery=%d rewrite=%v: expected %s in the written file:\n%s", tt.flushEvery, tt.rewrite, translation, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package catalog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gzipExt is the extension of gzip-compressed catalogs, like messages.pot.gz
+// or messages_es.po.gz. They are read and written compressed, transparently.
+const gzipExt = ".gz"
+
+// catalogName returns a catalog file name without its .gz extension.
+func catalogName(name string) string {
+	return strings.TrimSuffix(name, gzipExt)
+}
+
+// openCatalog opens a PO or POT file for reading, decompressing .gz files
+// and converting other charsets to UTF-8.
+func (p *Processor) openCatalog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipExt) {
+		return catalogFile{p.decodingReader(file), file}, nil
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return catalogFile{p.decodingReader(reader), gzipFile{reader, file}}, nil
+}
+
+// catalogFile reads the decoded content of a catalog, closing the file it
+// reads on Close.
+type catalogFile struct {
+	io.Reader
+	file io.Closer
+}
+
+func (f catalogFile) Close() error {
+	return f.file.Close()
+}
+
+// gzipFile closes both the gzip reader and the file it reads.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// readCatalog reads the content of a PO or POT file, decompressing .gz files
+// and converting other charsets to UTF-8.
+func (p *Processor) readCatalog(path string) ([]byte, error) {
+	file, err := p.openCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// encodeCatalog returns the content as stored in the file at path, in the
+// charset of its header and gzip compressed for .gz files.
+func (p *Processor) encodeCatalog(path string, content []byte) ([]byte, error) {
+	content, err := p.encodeCharset(content)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipExt) {
+		return content, nil
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCatalog writes the content of a PO or POT file, compressing .gz files
+// and converting it to the charset of its header.
+func (p *Processor) writeCatalog(path string, content []byte) error {
+	encoded, err := p.encodeCatalog(path, content)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, encoded, fileMode(path))
+}
+
+// globCatalogs returns the files matching the pattern, followed by the
+// compressed files matching it with the .gz extension.
+func globCatalogs(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := filepath.Glob(pattern + gzipExt)
+	if err != nil {
+		return nil, err
+	}
+	return append(matches, compressed...), nil
+}
+
+// potFilePath returns the POT file of a domain in the directory, the
+// compressed <domain>.pot.gz when only that one exists, or the --pot file.
+func (p *Processor) potFilePath(directory, domain string) string {
+	if p.Pot != "" {
+		return p.Pot
+	}
+	potFile := filepath.Join(directory, domain+".pot")
+	if _, err := os.Stat(potFile); os.IsNotExist(err) {
+		if _, err := os.Stat(potFile + gzipExt); err == nil {
+			return potFile + gzipExt
+		}
+	}
+	return potFile
+}
@@ -0,0 +1,74 @@
+package catalog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeGzip writes the content gzip compressed.
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(content))
+	writer.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+}
+
+func TestGzipCatalogs(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	dir := t.TempDir()
+	writeGzip(t, filepath.Join(dir, "default.pot.gz"), "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"World\"\nmsgstr \"\"\n")
+	writeGzip(t, filepath.Join(dir, "default_es.po.gz"), "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n\nmsgid \"Hello\"\nmsgstr \"Hola\"\n")
+
+	domains, err := p.SelectDomains(dir, "all")
+	if err != nil || len(domains) != 1 || domains[0] != "default" {
+		t.Fatalf("SelectDomains() = %v, %v, want [default]", domains, err)
+	}
+	if potFile := p.potFilePath(dir, "default"); potFile != filepath.Join(dir, "default.pot.gz") {
+		t.Errorf("potFilePath() = %q, want the compressed POT file", potFile)
+	}
+	poFiles, err := p.FindPoFiles(dir, "default", p.Layout)
+	if err != nil || len(poFiles) != 1 || poFiles[0] != filepath.Join(dir, "default_es.po.gz") {
+		t.Fatalf("FindPoFiles() = %v, %v, want the compressed PO file", poFiles, err)
+	}
+
+	potEntries, sourceLang, err := p.ParsePotFile(filepath.Join(dir, "default.pot.gz"))
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+	if sourceLang != "en" || len(potEntries) != 2 {
+		t.Errorf("Expected 2 entries in en, got %d in %q", len(potEntries), sourceLang)
+	}
+
+	result, err := p.ProcessDirectory(dir, dir, "default", 0, &fakeTranslator{}, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if result.Translated != 1 {
+		t.Errorf("Expected 1 translated string, got %+v", result)
+	}
+
+	// The PO file is written back compressed
+	content, err := os.ReadFile(filepath.Join(dir, "default_es.po.gz"))
+	if err != nil {
+		t.Fatalf("Failed to read PO file: %v", err)
+	}
+	if _, err := gzip.NewReader(bytes.NewReader(content)); err != nil {
+		t.Fatalf("Expected a gzip compressed PO file: %v", err)
+	}
+	entries, _, err := p.ParsePotFile(filepath.Join(dir, "default_es.po.gz"))
+	if err != nil {
+		t.Fatalf("Failed to parse PO file: %v", err)
+	}
+	if entries["Hello"].Msgstr != "Hola" || entries["World"].Msgstr != "es:World" {
+		t.Errorf("Expected Hola and es:World, got %q and %q", entries["Hello"].Msgstr, entries["World"].Msgstr)
+	}
+}
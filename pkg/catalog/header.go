@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"io"
+	"strings"
+)
+
+// POHeader holds the fields of the header entry of a PO or POT file, the
+// msgstr of the entry with the empty msgid, keyed by their lowercase name.
+type POHeader map[string]string
+
+// Get returns the value of the named field, like "Language" or
+// "Plural-Forms", or an empty string when the header doesn't have it. Names
+// are matched without regard to case.
+func (h POHeader) Get(name string) string {
+	return h[strings.ToLower(name)]
+}
+
+// headerFields parses the msgstr of a header entry. Each "Name: value" line
+// is a field, however the msgstr was split into continuation lines, and the
+// spaces around names and values are dropped.
+func headerFields(msgstr string) POHeader {
+	header := make(POHeader)
+	for _, line := range strings.Split(msgstr, "\n") {
+		name, value, found := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			continue
+		}
+		header[strings.ToLower(name)] = strings.TrimSpace(value)
+	}
+	return header
+}
+
+// linesHeader returns the header of the PO file lines, empty when the lines
+// have no header entry.
+func linesHeader(lines []string) POHeader {
+	blocks, _ := parsePoLines(lines)
+	for _, block := range blocks {
+		if block.isHeader() {
+			return headerFields(block.Msgstr)
+		}
+	}
+	return POHeader{}
+}
+
+// ReadHeader reads the header of a PO or POT file.
+func (p *Processor) ReadHeader(path string) (POHeader, error) {
+	file, err := p.openCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	lines, _ := splitLines(string(content))
+	return linesHeader(lines), nil
+}
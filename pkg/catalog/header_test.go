@@ -0,0 +1,204 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeaderLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"usual", `msgid ""
+msgstr ""
+"Language: es\n"
+`, "es"},
+		{"split over continuation lines", `msgid ""
+msgstr ""
+"Project-Id-Version: 1.0\n"
+"Lang"
+"uage: pt_"
+"BR\n"
+`, "pt_BR"},
+		{"value on the msgstr line", `msgid ""
+msgstr "Language: nl\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+`, "nl"},
+		{"unusual spacing and case", `msgid  ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"language :   fr  \n"
+`, "fr"},
+		{"reordered after the other fields", `# Translation of the example
+#, fuzzy
+msgid ""
+msgstr ""
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Language-Team: Czech\n"
+"Language: cs\n"
+`, "cs"},
+		{"only in an entry", `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Language: xx\n"
+msgstr ""
+`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poFile := filepath.Join(t.TempDir(), "test.po")
+			content := tt.header + "\nmsgid \"Hello\"\nmsgstr \"\"\n"
+			if err := os.WriteFile(poFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			if language, err := p.headerLanguage(poFile); err != nil || language != tt.expected {
+				t.Errorf("headerLanguage() = %q, %v, want %q", language, err, tt.expected)
+			}
+			if _, language, err := p.ParsePotFile(poFile); err != nil || language != tt.expected {
+				t.Errorf("ParsePotFile() language = %q, %v, want %q", language, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	p := New(DefaultOptions())
+	poFile := filepath.Join(t.TempDir(), "test_cs.po")
+	content := `msgid ""
+msgstr ""
+"Content-Type: text/plain; "
+"charset=UTF-8\n"
+"Plural-Forms: nplurals=3; "
+"plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);\n"
+"Language: cs\n"
+`
+	if err := os.WriteFile(poFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	header, err := p.ReadHeader(poFile)
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v", err)
+	}
+	expected := map[string]string{
+		"Content-Type":  "text/plain; charset=UTF-8",
+		"plural-forms":  "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+		"Language":      "cs",
+		"Language-Team": "",
+	}
+	for name, value := range expected {
+		if got := header.Get(name); got != value {
+			t.Errorf("Get(%q) = %q, want %q", name, got, value)
+		}
+	}
+
+	// The number of plural forms is read from the split header too
+	lines, _ := splitLines(content)
+	if nplurals := parsePluralCount(lines); nplurals != 3 {
+		t.Errorf("Expected 3 plural forms, got %d", nplurals)
+	}
+}
+
+func TestSplitHeaderFieldWrites(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Project-Id-Version: 1.0\n"
+"Language: "
+"en\n"
+"Language-"
+"Team: English\n"
+"PO-Revision-Date: 2024-01-01 "
+"12:00+0000\n"
+"Plural-Forms: nplurals=2; "
+"plural=(n != 1);\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr ""
+`
+	tests := []struct {
+		name     string
+		write    func(potFile, poFile string) error
+		expected []string // Header lines after the msgstr line
+	}{
+		{"update POT language", func(potFile, poFile string) error {
+			if err := os.Rename(potFile, poFile); err != nil {
+				return err
+			}
+			return p.updatePotLanguage(poFile, "de")
+		}, []string{`"Project-Id-Version: 1.0\n"`, `"Language: de\n"`, `"Language-"`, `"Team: English\n"`}},
+		{"copy POT to PO", func(potFile, poFile string) error {
+			return p.CopyPotToPo(potFile, poFile, "fr")
+		}, []string{`"Project-Id-Version: 1.0\n"`, `"Language: fr\n"`, `"Language-Team: FR\n"`, `"PO-Revision-Date: ` + time.Now().Format("2006-01-02"), `"Plural-Forms: nplurals=2; plural=(n > 1);\n"`, `"Content-Type: text/plain; charset=UTF-8\n"`, ``}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			poFile := filepath.Join(tempDir, "test.po")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := tt.write(potFile, poFile); err != nil {
+				t.Fatalf("Writing the header failed: %v", err)
+			}
+			content, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read PO file: %v", err)
+			}
+
+			lines := strings.Split(string(content), "\n")[2:]
+			for i, expected := range tt.expected {
+				if i >= len(lines) || !strings.HasPrefix(lines[i], expected) {
+					t.Fatalf("Header line %d is not %q:\n%s", i+3, expected, content)
+				}
+			}
+			header, err := p.ReadHeader(poFile)
+			if err != nil {
+				t.Fatalf("ReadHeader failed: %v", err)
+			}
+			if header.Get("Language") == "en" || strings.Contains(string(content), "\"en\\n\"") {
+				t.Errorf("Expected the split Language value to be replaced:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestSetHeaderFieldSplit(t *testing.T) {
+	p := New(DefaultOptions())
+	lines := strings.Split(`msgid ""
+msgstr "Last-Translator: Old "
+"Name <old@example.com>\n"
+"PO-Revi"
+"sion-Date: 2024-01-01 12:00+0000\n"
+"Content-Type: text/plain; charset=UTF-8\n"`, "\n")
+	blocks, _ := parsePoLines(lines)
+	blocks[0].setHeaderField("Last-Translator", "potranslate")
+	blocks[0].setHeaderField("PO-Revision-Date", "2026-10-14 12:00+0000")
+	blocks[0].setHeaderField("Language", "es")
+
+	expected := []string{
+		`msgid ""`,
+		`msgstr "Last-Translator: potranslate\n"`,
+		`"PO-Revision-Date: 2026-10-14 12:00+0000\n"`,
+		`"Content-Type: text/plain; charset=UTF-8\n"`,
+		`"Language: es\n"`,
+	}
+	if written := p.formatPoLines(blocks); !slices.Equal(written, expected) {
+		t.Errorf("formatPoLines() = %q, want %q", written, expected)
+	}
+	if header := headerFields(blocks[0].Msgstr); header.Get("Last-Translator") != "potranslate" || header.Get("Language") != "es" {
+		t.Errorf("Expected the msgstr to be updated, got %q", blocks[0].Msgstr)
+	}
+}
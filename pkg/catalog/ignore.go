@@ -0,0 +1,40 @@
+package catalog
+
+// isIgnored reports whether the msgid matches an --ignore-pattern.
+func (p *Processor) isIgnored(msgid string) bool {
+	for _, pattern := range p.IgnorePatterns {
+		if pattern.MatchString(msgid) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipIgnored takes the entries whose msgid matches an --ignore-pattern out
+// of the keys, copying their source strings into the result unless
+// --ignore-empty leaves them untranslated. It returns the keys that still
+// need the backend.
+func (p *Processor) skipIgnored(keys []string, pluralSources map[string]string, nplurals int, result *entryTranslations) []string {
+	if len(p.IgnorePatterns) == 0 {
+		return keys
+	}
+
+	var remaining []string
+	for _, key := range keys {
+		_, msgid := splitEntryKey(key)
+		if !p.isIgnored(msgid) {
+			remaining = append(remaining, key)
+			continue
+		}
+		result.ignored++
+		if p.IgnoreEmpty {
+			continue
+		}
+		if msgidPlural, isPlural := pluralSources[key]; isPlural {
+			result.plural[key] = pluralCopies(msgid, msgidPlural, nplurals)
+		} else {
+			result.singular[key] = msgid
+		}
+	}
+	return remaining
+}
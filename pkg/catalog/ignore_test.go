@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	p := New(DefaultOptions())
+	p.IgnorePatterns = []*regexp.Regexp{regexp.MustCompile(`^https?://`), regexp.MustCompile(`^\{\{.*\}\}$`)}
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "https://example.com/docs"
+msgstr ""
+
+msgid "{{user.name}}"
+msgstr ""
+
+msgid "Hello"
+msgstr ""
+
+msgid "Visit https://example.com"
+msgstr ""
+`
+
+	tests := []struct {
+		name        string
+		ignoreEmpty bool
+		expected    []string
+	}{
+		{
+			name: "copied",
+			expected: []string{
+				"msgid \"https://example.com/docs\"\nmsgstr \"https://example.com/docs\"\n",
+				"msgid \"{{user.name}}\"\nmsgstr \"{{user.name}}\"\n",
+			},
+		},
+		{
+			name:        "left empty",
+			ignoreEmpty: true,
+			expected: []string{
+				"msgid \"https://example.com/docs\"\nmsgstr \"\"\n",
+				"msgid \"{{user.name}}\"\nmsgstr \"\"\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, rewrite := range []bool{false, true} {
+			p.IgnoreEmpty = tt.ignoreEmpty
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			translator := &fakeTranslator{}
+			var result TranslationResult
+			if rewrite {
+				result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+			} else {
+				result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			}
+			if err != nil {
+				t.Fatalf("%s, rewrite=%v: unexpected error: %v", tt.name, rewrite, err)
+			}
+
+			// Only whole msgids matching a pattern are skipped
+			if got := strings.Join(translator.texts, ", "); got != "Hello, Visit https://example.com" && got != "Visit https://example.com, Hello" {
+				t.Errorf("%s, rewrite=%v: expected only the normal strings to be translated, got %s", tt.name, rewrite, got)
+			}
+			if result.Translated != 2 || result.Ignored != 2 || result.Skipped != 0 {
+				t.Errorf("%s, rewrite=%v: expected 2 translated and 2 ignored, got %+v", tt.name, rewrite, result)
+			}
+
+			content, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read PO file: %v", err)
+			}
+			for _, want := range append(tt.expected, "msgstr \"es:Hello\"") {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("%s, rewrite=%v: expected %q in PO file:\n%s", tt.name, rewrite, want, content)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the file listing the PO files of a directory that are
+// never touched, like a hand-made default_en.po.
+const ignoreFileName = ".potranslateignore"
+
+// readIgnoreFile returns the globs of the .potranslateignore file in the
+// directory, skipping blank lines and # comments. Without the file there are
+// none.
+func readIgnoreFile(directory string) ([]string, error) {
+	file, err := os.Open(filepath.Join(directory, ignoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if _, err := filepath.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return nil, fmt.Errorf("invalid glob '%s' in %s", pattern, filepath.Join(directory, ignoreFileName))
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, scanner.Err()
+}
+
+// ignoredCatalog reports whether the PO file, relative to the directory of
+// the ignore file, matches its globs by name or by path. Like in a
+// .gitignore, the last matching glob wins and a glob starting with "!" takes
+// a file back in, so "*_en.po" followed by "!default_en.po" only keeps that
+// file. A compressed file also matches the globs of its uncompressed name.
+func ignoredCatalog(relative string, patterns []string) bool {
+	ignored := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		glob := []string{strings.TrimPrefix(pattern, "!")}
+		if excluded(relative, glob) || excluded(catalogName(relative), glob) {
+			ignored = !negated
+		}
+	}
+	return ignored
+}
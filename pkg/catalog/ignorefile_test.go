@@ -0,0 +1,69 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"default.pot":   "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n",
+		"default_en.po": "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n",
+		"default_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n",
+		"default_fr.po": "msgid \"\"\nmsgstr \"\"\n\"Language: fr\\n\"\n",
+		ignoreFileName:  "# Curated by hand\ndefault_en.po\n\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	poFiles, err := p.FindPoFiles(dir, "default", "flat")
+	if err != nil {
+		t.Fatalf("FindPoFiles() error = %v", err)
+	}
+	expected := []string{filepath.Join(dir, "default_es.po"), filepath.Join(dir, "default_fr.po")}
+	slices.Sort(poFiles)
+	if !slices.Equal(poFiles, expected) {
+		t.Errorf("FindPoFiles() = %v, want %v", poFiles, expected)
+	}
+
+	result, err := p.ProcessDirectory(dir, dir, "default", 0, &fakeTranslator{}, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if result.Translated != 2 {
+		t.Errorf("Expected 2 translated strings, got %+v", result)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "default_en.po")); string(content) != files["default_en.po"] {
+		t.Errorf("Expected the ignored PO file to be unchanged, got:\n%s", content)
+	}
+}
+
+func TestIgnoredCatalog(t *testing.T) {
+	tests := []struct {
+		relative string
+		patterns []string
+		expected bool
+	}{
+		{"default_en.po", nil, false},
+		{"default_en.po", []string{"default_en.po"}, true},
+		{"default_en.po.gz", []string{"default_en.po"}, true},
+		{"default_es.po", []string{"*_en.po"}, false},
+		{"default_en.po", []string{"*_en.po", "!default_en.po"}, false},
+		{"admin_en.po", []string{"*_en.po", "!default_en.po"}, true},
+		{filepath.Join("en", "LC_MESSAGES", "default.po"), []string{"en/LC_MESSAGES/*"}, true},
+	}
+	for _, tt := range tests {
+		if ignored := ignoredCatalog(tt.relative, tt.patterns); ignored != tt.expected {
+			t.Errorf("ignoredCatalog(%q, %q) = %v, want %v", tt.relative, tt.patterns, ignored, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// importedEntry is an entry of a JSON --import file in list form.
+type importedEntry struct {
+	Msgctxt string `json:"msgctxt"`
+	Msgid   string `json:"msgid"`
+	Msgstr  string `json:"msgstr"`
+}
+
+// loadImport reads the translations of a CSV or JSON file, keyed like the
+// POT entries. A CSV file has msgid and msgstr columns, and optionally a
+// msgctxt column, named in its first row like the files of --export-missing,
+// or otherwise the msgid and msgstr as its first two columns. A JSON file is
+// an object mapping each msgid to its msgstr, or a list of objects with
+// msgctxt, msgid and msgstr fields. Empty translations are left out.
+func loadImport(path string) (map[string]string, error) {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension != ".csv" && extension != ".json" {
+		return nil, fmt.Errorf("unsupported file type, use a .csv or .json file")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []importedEntry
+	if extension == ".csv" {
+		entries, err = parseImportCSV(string(content))
+	} else {
+		entries, err = parseImportJSON(content)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	translations := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Msgid != "" && entry.Msgstr != "" {
+			translations[entryKey(entry.Msgctxt, entry.Msgid)] = entry.Msgstr
+		}
+	}
+	return translations, nil
+}
+
+// parseImportCSV reads the rows of a CSV --import file.
+func parseImportCSV(content string) ([]importedEntry, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, utf8BOM)))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	msgctxt, msgid, msgstr := -1, 0, 1
+	if slices.Contains(rows[0], "msgid") && slices.Contains(rows[0], "msgstr") {
+		msgctxt = slices.Index(rows[0], "msgctxt")
+		msgid = slices.Index(rows[0], "msgid")
+		msgstr = slices.Index(rows[0], "msgstr")
+		rows = rows[1:]
+	}
+
+	column := func(row []string, n int) string {
+		if n < 0 || n >= len(row) {
+			return ""
+		}
+		return row[n]
+	}
+	var entries []importedEntry
+	for _, row := range rows {
+		entries = append(entries, importedEntry{Msgctxt: column(row, msgctxt), Msgid: column(row, msgid), Msgstr: column(row, msgstr)})
+	}
+	return entries, nil
+}
+
+// parseImportJSON reads a JSON --import file in object or list form.
+func parseImportJSON(content []byte) ([]importedEntry, error) {
+	var entries []importedEntry
+	if err := json.Unmarshal(content, &entries); err == nil {
+		return entries, nil
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(content, &mapping); err != nil {
+		return nil, fmt.Errorf("expected an object of msgid to msgstr or a list of entries: %v", err)
+	}
+	for msgid, msgstr := range mapping {
+		entries = append(entries, importedEntry{Msgid: msgid, Msgstr: msgstr})
+	}
+	return entries, nil
+}
+
+// importTranslations fills the entries found in the --import translations
+// into the result and returns the keys that aren't in there. Translations
+// that don't keep the placeholders of their msgid, in the style of their
+// flags, are marked for review. Plural entries are never imported.
+func (p *Processor) importTranslations(keys []string, pluralSources map[string]string, flags map[string][]string, result *entryTranslations) []string {
+	if p.imported == nil {
+		return keys
+	}
+
+	var remaining []string
+	for _, key := range keys {
+		translation, exists := p.imported[key]
+		if _, isPlural := pluralSources[key]; isPlural || !exists {
+			remaining = append(remaining, key)
+			continue
+		}
+		_, msgid := splitEntryKey(key)
+		if !samePlaceholders(msgid, translation, p.entryStyle(flags[key])) {
+			fmt.Fprintf(os.Stderr, "Warning: Imported translation of '%s' doesn't have the same placeholders, marking as fuzzy\n", msgid)
+			result.needsReview[key] = true
+		}
+		result.singular[key] = translation
+		result.imported++
+	}
+	result.count += result.imported
+	return remaining
+}
+
+// samePlaceholders reports whether the translation has the placeholders of
+// the style of the msgid, in any order.
+func samePlaceholders(msgid, translation, style string) bool {
+	_, expected := protectPlaceholders(msgid, style)
+	_, actual := protectPlaceholders(translation, style)
+	slices.Sort(expected)
+	slices.Sort(actual)
+	return slices.Equal(expected, actual)
+}
@@ -0,0 +1,172 @@
+package catalog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	p.PlaceholderStyle = "c"
+	defer func() { os.Stderr = previousStderr }()
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+
+msgid "Save %s"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "Other"
+msgstr ""
+
+msgid "Done"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+
+#, fuzzy
+msgid "Goodbye"
+msgstr "Adios"
+
+msgid "Save %s"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "Other"
+msgstr ""
+
+msgid "Done"
+msgstr "Hecho"
+`
+	// Open without context doesn't match the menu entry, and the translated
+	// Done entry is left alone
+	csvContent := `msgctxt,msgid,msgstr
+,Hello,Hola
+,Goodbye,Adiós
+,Save %s,Guardar
+menu,Open,Abrir
+,Open,Abierto
+,Done,Terminado
+`
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	poFile := filepath.Join(tempDir, "test_es.po")
+	importFile := filepath.Join(tempDir, "done_es.csv")
+	for path, content := range map[string]string{potFile: potContent, poFile: poContent, importFile: csvContent} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+	stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+	if err != nil {
+		t.Fatalf("Failed to create stderr file: %v", err)
+	}
+	defer stderr.Close()
+	os.Stderr = stderr
+
+	if err := p.LoadImport(importFile); err != nil {
+		t.Fatalf("LoadImport() error = %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		return "", errors.New("the backend must not be used")
+	}}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+	if err != nil {
+		t.Fatalf("TranslatePoFile() error = %v", err)
+	}
+	if translator.calls() != 0 {
+		t.Errorf("Expected no backend requests, got %d", translator.calls())
+	}
+	if result.Imported != 4 || result.Translated != 0 || result.Skipped != 1 {
+		t.Errorf("Expected 4 imported and 1 skipped, got %+v", result)
+	}
+
+	entries, _, err := p.ParsePotFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to parse PO file: %v", err)
+	}
+	for key, want := range map[string]string{
+		"Hello":                  "Hola",
+		"Goodbye":                "Adiós",
+		"Save %s":                "Guardar",
+		entryKey("menu", "Open"): "Abrir",
+		"Other":                  "",
+		"Done":                   "Hecho",
+	} {
+		if entries[key].Msgstr != want {
+			t.Errorf("Expected %q for %q, got %q", want, key, entries[key].Msgstr)
+		}
+	}
+	if hasFlag(entries["Goodbye"].Flags, "fuzzy") || !hasFlag(entries["Save %s"].Flags, "fuzzy") {
+		t.Errorf("Expected only the entry with lost placeholders to be fuzzy, got %v and %v", entries["Goodbye"].Flags, entries["Save %s"].Flags)
+	}
+	if written, _ := os.ReadFile(stderr.Name()); !strings.Contains(string(written), "'Save %s' doesn't have the same placeholders") {
+		t.Errorf("Expected a placeholder warning, got %q", written)
+	}
+}
+
+func TestLoadImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected map[string]string
+	}{
+		{"csv without header", "a.csv", "Hello,Hola\nEmpty,\n", map[string]string{"Hello": "Hola"}},
+		{"json object", "a.json", `{"Hello": "Hola", "Empty": ""}`, map[string]string{"Hello": "Hola"}},
+		{"json list", "a.json", `[{"msgctxt": "menu", "msgid": "Open", "msgstr": "Abrir"}]`, map[string]string{entryKey("menu", "Open"): "Abrir"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create import file: %v", err)
+			}
+			translations, err := loadImport(path)
+			if err != nil {
+				t.Fatalf("loadImport() error = %v", err)
+			}
+			if len(translations) != len(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, translations)
+			}
+			for key, want := range tt.expected {
+				if translations[key] != want {
+					t.Errorf("Expected %q for %q, got %q", want, key, translations[key])
+				}
+			}
+		})
+	}
+
+	if _, err := loadImport("a.txt"); err == nil || !strings.Contains(err.Error(), "unsupported file type") {
+		t.Errorf("Expected an error for an unsupported file type, got %v", err)
+	}
+}
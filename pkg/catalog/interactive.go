@@ -0,0 +1,146 @@
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// promptInput is where answers to prompts are read from. Tests replace it.
+var promptInput = bufio.NewReader(os.Stdin)
+
+// readAnswer reads a line of the user's answer without its line ending. It
+// returns false when stdin is closed or the context is done while waiting, as
+// the read itself can't be aborted.
+func readAnswer(ctx context.Context) (string, bool) {
+	type answer struct {
+		line string
+		err  error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		line, err := promptInput.ReadString('\n')
+		answers <- answer{line, err}
+	}()
+
+	select {
+	case a := <-answers:
+		if a.err != nil && a.line == "" {
+			return "", false
+		}
+		return strings.TrimRight(a.line, "\r\n"), true
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// reviewTranslations lets the user accept, edit or skip each translation for
+// --interactive, in the order of the keys. Edited translations are used
+// instead and no longer need review, skipped ones are left untranslated.
+// When stdin is closed or the run is interrupted, the translations that
+// weren't reviewed yet are skipped.
+func (p *Processor) reviewTranslations(keys []string, result *entryTranslations) {
+	for i, key := range keys {
+		if !p.reviewTranslation(key, result) {
+			fmt.Fprintln(os.Stderr, "\nReview stopped, skipping the remaining translations")
+			for _, rest := range keys[i:] {
+				result.skip(rest)
+			}
+			return
+		}
+	}
+}
+
+// reviewTranslation shows the translation of an entry and applies the
+// user's answer. It returns false when the review stopped.
+func (p *Processor) reviewTranslation(key string, result *entryTranslations) bool {
+	translated, isSingular := result.singular[key]
+	forms, isPlural := result.plural[key]
+	if !isSingular && !isPlural {
+		return true
+	}
+
+	_, msgid := splitEntryKey(key)
+	fmt.Fprintf(os.Stderr, "\nmsgid:  %q\n", msgid)
+	if isPlural {
+		for n, form := range forms {
+			fmt.Fprintf(os.Stderr, "msgstr[%d]: %q\n", n, form)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "msgstr: %q\n", translated)
+	}
+
+	action, ok := p.askAction()
+	if !ok {
+		return false
+	}
+	switch action {
+	case "s":
+		result.skip(key)
+	case "e":
+		if isPlural {
+			edited := make([]string, len(forms))
+			for n, form := range forms {
+				if edited[n], ok = p.askEdit(fmt.Sprintf("msgstr[%d]", n), form); !ok {
+					return false
+				}
+			}
+			result.plural[key] = edited
+		} else {
+			if translated, ok = p.askEdit("msgstr", translated); !ok {
+				return false
+			}
+			result.singular[key] = translated
+		}
+		delete(result.needsReview, key)
+	}
+	return true
+}
+
+// skip drops the translation of an entry, leaving it untranslated.
+func (r *entryTranslations) skip(key string) {
+	_, isSingular := r.singular[key]
+	_, isPlural := r.plural[key]
+	if isSingular || isPlural {
+		r.count--
+	}
+	delete(r.singular, key)
+	delete(r.plural, key)
+	delete(r.needsReview, key)
+}
+
+// askAction prompts for accepting, editing or skipping a translation until it
+// gets a valid answer, accepting on an empty one.
+func (p *Processor) askAction() (string, bool) {
+	for {
+		fmt.Fprint(os.Stderr, "[a]ccept, [e]dit or [s]kip? [a] ")
+		answer, ok := readAnswer(p.ctx)
+		if !ok {
+			return "", false
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "a", "accept":
+			return "a", true
+		case "e", "edit":
+			return "e", true
+		case "s", "skip":
+			return "s", true
+		}
+	}
+}
+
+// askEdit prompts for the new text of a msgstr, keeping the proposed text on
+// an empty answer.
+func (p *Processor) askEdit(keyword, proposed string) (string, bool) {
+	fmt.Fprintf(os.Stderr, "%s (empty keeps %q): ", keyword, proposed)
+	answer, ok := readAnswer(p.ctx)
+	if !ok {
+		return "", false
+	}
+	if answer == "" {
+		return proposed, true
+	}
+	return answer, true
+}
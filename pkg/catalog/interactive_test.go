@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInteractiveReview(t *testing.T) {
+	p := New(DefaultOptions())
+	previousInput := promptInput
+	p.Interactive = true
+	defer func() { promptInput = previousInput }()
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "One"
+msgstr ""
+
+msgid "Two"
+msgstr ""
+
+msgid "Three"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	tests := []struct {
+		name        string
+		answers     string
+		want        map[string]string
+		wantSkipped int
+	}{
+		{
+			name:        "accept, edit and skip",
+			answers:     "a\ne\nDos editado\ns\n",
+			want:        map[string]string{"One": "es:One", "Two": "Dos editado", "Three": ""},
+			wantSkipped: 1,
+		},
+		{
+			name:        "defaults and retries",
+			answers:     "\nx\nE\n\nskip\n",
+			want:        map[string]string{"One": "es:One", "Two": "es:Two", "Three": ""},
+			wantSkipped: 1,
+		},
+		{
+			name:        "stdin closed",
+			answers:     "accept\n",
+			want:        map[string]string{"One": "es:One", "Two": "", "Three": ""},
+			wantSkipped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			potFile := filepath.Join(dir, "default.pot")
+			poFile := filepath.Join(dir, "default_es.po")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			promptInput = bufio.NewReader(strings.NewReader(tt.answers))
+			result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			if err != nil {
+				t.Fatalf("TranslatePoFile() error = %v", err)
+			}
+			if result.Skipped != tt.wantSkipped || result.Translated != 3-tt.wantSkipped {
+				t.Errorf("Expected %d skipped entries, got %+v", tt.wantSkipped, result)
+			}
+
+			entries, _, err := p.ParsePotFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to parse PO file: %v", err)
+			}
+			for msgid, msgstr := range tt.want {
+				if entries[msgid].Msgstr != msgstr {
+					t.Errorf("Expected %q for %q, got %q", msgstr, msgid, entries[msgid].Msgstr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// localeRegexp matches locale codes with a 2-3 letter language, an optional
+// 4 letter script and an optional region, separated by "_" or "-" (e.g., es,
+// pt_BR, zh-Hans, sr_Latn_RS).
+var localeRegexp = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[_-]([a-zA-Z]{4}))?(?:[_-]([a-zA-Z]{2}|[0-9]{3}))?$`)
+
+// googleLanguages maps locales to the codes Google Translate expects, where
+// these differ from the BCP 47 form.
+var googleLanguages = map[string]string{
+	"zh-Hans": "zh-CN",
+	"zh-Hant": "zh-TW",
+	"zh-HK":   "zh-TW",
+	"zh-SG":   "zh-CN",
+}
+
+// ValidLocale reports whether code is a valid locale code.
+func ValidLocale(code string) bool {
+	return localeRegexp.MatchString(code)
+}
+
+// formatLocale joins the language, optional script and optional region of a
+// locale code with the separator, using their conventional casing.
+func formatLocale(code, separator string) string {
+	match := localeRegexp.FindStringSubmatch(code)
+	if match == nil {
+		return code
+	}
+	parts := []string{strings.ToLower(match[1])}
+	if script := match[2]; script != "" {
+		parts = append(parts, strings.ToUpper(script[:1])+strings.ToLower(script[1:]))
+	}
+	if region := match[3]; region != "" {
+		parts = append(parts, strings.ToUpper(region))
+	}
+	return strings.Join(parts, separator)
+}
+
+// NormalizeLocale returns the gettext form of a locale code, as used in file
+// names and the Language header (e.g., pt-br -> pt_BR).
+func NormalizeLocale(code string) string {
+	return formatLocale(code, "_")
+}
+
+// translatorLanguage returns the BCP 47 form of a locale code, as expected by
+// translation backends (e.g., pt_BR -> pt-BR).
+func translatorLanguage(code string) string {
+	return formatLocale(code, "-")
+}
+
+// googleLanguage returns the Google Translate code for a BCP 47 language.
+func googleLanguage(code string) string {
+	if mapped, exists := googleLanguages[code]; exists {
+		return mapped
+	}
+	return code
+}
@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocaleCodes(t *testing.T) {
+	tests := []struct {
+		code       string
+		valid      bool
+		gettext    string
+		translator string
+		google     string
+	}{
+		{code: "es", valid: true, gettext: "es", translator: "es", google: "es"},
+		{code: "pt_BR", valid: true, gettext: "pt_BR", translator: "pt-BR", google: "pt-BR"},
+		{code: "pt-br", valid: true, gettext: "pt_BR", translator: "pt-BR", google: "pt-BR"},
+		{code: "zh_Hans", valid: true, gettext: "zh_Hans", translator: "zh-Hans", google: "zh-CN"},
+		{code: "zh-hant", valid: true, gettext: "zh_Hant", translator: "zh-Hant", google: "zh-TW"},
+		{code: "sr_Latn", valid: true, gettext: "sr_Latn", translator: "sr-Latn", google: "sr-Latn"},
+		{code: "sr_Latn_RS", valid: true, gettext: "sr_Latn_RS", translator: "sr-Latn-RS", google: "sr-Latn-RS"},
+		{code: "fil", valid: true, gettext: "fil", translator: "fil", google: "fil"},
+		{code: "e", valid: false},
+		{code: "pt_BRA", valid: false},
+		{code: "../es", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if valid := ValidLocale(tt.code); valid != tt.valid {
+				t.Fatalf("ValidLocale(%q) = %v, want %v", tt.code, valid, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if got := NormalizeLocale(tt.code); got != tt.gettext {
+				t.Errorf("NormalizeLocale(%q) = %q, want %q", tt.code, got, tt.gettext)
+			}
+			if got := translatorLanguage(tt.code); got != tt.translator {
+				t.Errorf("translatorLanguage(%q) = %q, want %q", tt.code, got, tt.translator)
+			}
+			if got := googleLanguage(translatorLanguage(tt.code)); got != tt.google {
+				t.Errorf("googleLanguage(%q) = %q, want %q", tt.translator, got, tt.google)
+			}
+		})
+	}
+}
+
+func TestGetTargetLanguageRegionFilename(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	content := "msgid \"\"\nmsgstr \"\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+
+	tests := []struct {
+		domain   string
+		filename string
+		expected string
+	}{
+		{domain: "default", filename: "default_pt_BR.po", expected: "pt_BR"},
+		{domain: "default", filename: "default_zh_Hans.po", expected: "zh_Hans"},
+		{domain: "my_app", filename: "my_app_sr_Latn.po", expected: "sr_Latn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			poFile := filepath.Join(tempDir, tt.filename)
+			if err := os.WriteFile(poFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			lang, err := p.GetTargetLanguage(poFile, tt.domain)
+			if err != nil {
+				t.Fatalf("GetTargetLanguage() error = %v", err)
+			}
+			if lang != tt.expected {
+				t.Errorf("GetTargetLanguage(%q) = %q, want %q", tt.filename, lang, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTrustLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+
+	content := "msgid \"\"\nmsgstr \"\"\n\"Language: es_MX\\n\"\n"
+	tests := []struct {
+		trust    string
+		filename string
+		expected string
+		warning  string
+	}{
+		{trust: "header", filename: "default_es.po", expected: "es_MX", warning: "using es_MX (--trust header)"},
+		{trust: "filename", filename: "default_es.po", expected: "es", warning: "using es (--trust filename)"},
+		// Only the spelling of the locale differs
+		{trust: "filename", filename: "default_es-mx.po", expected: "es-mx"},
+		// Without a language in the filename the header is used either way
+		{trust: "filename", filename: "other.po", expected: "es_MX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.trust+"/"+tt.filename, func(t *testing.T) {
+			tempDir := t.TempDir()
+			poFile := filepath.Join(tempDir, tt.filename)
+			if err := os.WriteFile(poFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+			if err != nil {
+				t.Fatalf("Failed to create stderr file: %v", err)
+			}
+			defer stderr.Close()
+			p.Trust, os.Stderr = tt.trust, stderr
+
+			// The language is looked up twice, but only warned about once
+			for range 2 {
+				lang, err := p.GetTargetLanguage(poFile, "default")
+				if err != nil {
+					t.Fatalf("GetTargetLanguage() error = %v", err)
+				}
+				if lang != tt.expected {
+					t.Errorf("GetTargetLanguage(%q) = %q, want %q", tt.filename, lang, tt.expected)
+				}
+			}
+
+			written, _ := os.ReadFile(stderr.Name())
+			if tt.warning == "" && len(written) != 0 {
+				t.Errorf("Expected no warning, got %q", written)
+			}
+			if tt.warning != "" && (strings.Count(string(written), "Warning:") != 1 || !strings.Contains(string(written), tt.warning)) {
+				t.Errorf("Expected a single warning with %q, got %q", tt.warning, written)
+			}
+		})
+	}
+}
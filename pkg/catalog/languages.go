@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LanguageLister is implemented by translators that can list the languages
+// they translate to, so unsupported target languages are caught up front.
+type LanguageLister interface {
+	Languages(ctx context.Context) ([]string, error)
+}
+
+// languageLists holds the base languages of the translators, fetched once per
+// translator.
+type languageLists struct {
+	mu    sync.Mutex
+	lists map[Translator]map[string]bool // Nil when unknown
+}
+
+// baseLanguage returns the language of a locale code without its script and
+// region, like "pt" for "pt-BR".
+func baseLanguage(code string) string {
+	base, _, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	return strings.ToLower(base)
+}
+
+// supportsLanguage reports whether the translator translates to the language.
+// Only the base language is compared, as backends differ in the regions and
+// scripts they list (zh-CN for zh-Hans). The languages are fetched once per
+// translator; translators that can't list them are assumed to support any.
+// A --fallback chain supports the languages of any of its backends.
+func (p *Processor) supportsLanguage(translator Translator, language string) bool {
+	ctx, cancel := p.requestContext()
+	defer cancel()
+	if chain, ok := translator.(*fallbackTranslator); ok {
+		return chain.supports(ctx, language)
+	}
+	return p.languages.supports(ctx, translator, p.Backend, language)
+}
+
+// supports reports whether the translator, used as the named backend,
+// translates to the language like supportsLanguage.
+func (l *languageLists) supports(ctx context.Context, translator Translator, name, language string) bool {
+	lister, ok := translator.(LanguageLister)
+	if !ok {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	supported, fetched := l.lists[translator]
+	if !fetched {
+		languages, err := lister.Languages(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not list the languages of the %s backend: %v\n", name, err)
+		} else {
+			supported = make(map[string]bool)
+			for _, code := range languages {
+				supported[baseLanguage(code)] = true
+			}
+		}
+		if l.lists == nil {
+			l.lists = make(map[Translator]map[string]bool)
+		}
+		l.lists[translator] = supported
+	}
+	return supported == nil || supported[baseLanguage(language)]
+}
+
+// WriteLanguages writes the sorted language codes of the translator, one per
+// line, for --list-languages.
+func (p *Processor) WriteLanguages(w io.Writer, translator Translator) error {
+	lister, ok := translator.(LanguageLister)
+	if !ok {
+		return fmt.Errorf("the %s backend can't list its languages", p.Backend)
+	}
+	ctx, cancel := p.requestContext()
+	defer cancel()
+	languages, err := lister.Languages(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Strings(languages)
+	for _, code := range languages {
+		fmt.Fprintln(w, code)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeLister is a fakeTranslator that rejects the languages it doesn't list.
+type fakeLister struct {
+	fakeTranslator
+	languages []string
+	listed    int
+}
+
+func (f *fakeLister) Languages(ctx context.Context) ([]string, error) {
+	f.listed++
+	return f.languages, nil
+}
+
+func TestUnsupportedTargetLanguage(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"default.pot":      "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"World\"\nmsgstr \"\"\n",
+		"default_pt_BR.po": "msgid \"\"\nmsgstr \"\"\n\"Language: pt_BR\\n\"\n",
+		"default_xx.po":    "msgid \"\"\nmsgstr \"\"\n\"Language: xx\\n\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	translator := &fakeLister{languages: []string{"en", "pt"}}
+	translator.translate = func(text, from, to string) (string, error) {
+		if to == "xx" {
+			return "", fmt.Errorf("xx is not supported")
+		}
+		return to + ":" + text, nil
+	}
+
+	result, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	// pt-BR is supported through pt, xx is skipped without a request per string
+	if result.Translated != 2 || result.Failed != 0 {
+		t.Errorf("Expected 2 translated and no failed strings, got %+v", result)
+	}
+	if translator.calls() != 2 || translator.listed != 1 {
+		t.Errorf("Expected 2 translations and a single listing, got %d and %d", translator.calls(), translator.listed)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "default_xx.po")); string(content) != files["default_xx.po"] {
+		t.Errorf("Expected the unsupported PO file to be unchanged, got:\n%s", content)
+	}
+
+	// Adding an unsupported language fails before creating the file
+	p.AddLang = "xx"
+	if _, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil); err == nil {
+		t.Error("Expected an error adding an unsupported language")
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteLanguages(&buf, &fakeLister{languages: []string{"pt", "en", "de"}}); err != nil {
+		t.Fatalf("WriteLanguages() error = %v", err)
+	}
+	if buf.String() != "de\nen\npt\n" {
+		t.Errorf("Expected sorted languages, got %q", buf.String())
+	}
+	if err := p.WriteLanguages(&buf, &fakeTranslator{}); err == nil {
+		t.Error("Expected an error for a translator that can't list languages")
+	}
+}
+
+func TestLibreTranslatorLanguages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/languages" || r.URL.Query().Get("api_key") != "secret" {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode([]libreLanguage{{Code: "en", Name: "English"}, {Code: "nl", Name: "Dutch"}})
+	}))
+	defer server.Close()
+
+	languages, err := newLibreTranslator(server.URL, "secret").Languages(context.Background())
+	if err != nil {
+		t.Fatalf("Languages() error = %v", err)
+	}
+	if len(languages) != 2 || languages[0] != "en" || languages[1] != "nl" {
+		t.Errorf("Languages() = %v, want [en nl]", languages)
+	}
+}
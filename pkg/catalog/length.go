@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// entryMaxLength returns the maximum length of the translations of the POT
+// entry in characters: the number of its "#. max-length:" comment, or else
+// --max-length. It returns 0 when there is no maximum.
+func (p *Processor) entryMaxLength(entry POEntry) int {
+	for _, line := range entry.Comments.Extracted {
+		comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#."))
+		if len(comment) < len("max-length:") || !strings.EqualFold(comment[:len("max-length:")], "max-length:") {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(comment[len("max-length:"):])); err == nil && n > 0 {
+			return n
+		}
+	}
+	return p.MaxLength
+}
+
+// checkLengths warns about the translations that are longer than the maximum
+// length of their entry, counted in characters rather than bytes. With
+// --max-length-fuzzy they are marked for review.
+func (p *Processor) checkLengths(name string, potEntries map[string]POEntry, result *entryTranslations) {
+	check := func(key, translation string) {
+		limit := p.entryMaxLength(potEntries[key])
+		length := utf8.RuneCountInString(translation)
+		if limit == 0 || length <= limit {
+			return
+		}
+		_, msgid := splitEntryKey(key)
+		marking := ""
+		if p.MaxLengthFuzzy {
+			marking = ", marking as fuzzy"
+			result.needsReview[key] = true
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s: translation of '%s' has %d characters, more than the maximum of %d%s\n", name, msgid, length, limit, marking)
+	}
+
+	// In the order of the POT file, using the longest form of plurals
+	for _, key := range p.entryOrder(potEntries) {
+		if translation, exists := result.singular[key]; exists {
+			check(key, translation)
+		}
+		longest := ""
+		for _, form := range result.plural[key] {
+			if utf8.RuneCountInString(form) > utf8.RuneCountInString(longest) {
+				longest = form
+			}
+		}
+		if longest != "" {
+			check(key, longest)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxLength(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Save"
+msgstr ""
+
+#. max-length: 8
+msgid "Cancel"
+msgstr ""
+
+msgid "Delete"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: de\n"
+`
+	// The translations are counted in characters, so "ÜÜÜÜÜÜÜÜÜÜ" is 10 long
+	translations := map[string]string{"Save": "ÜÜÜÜÜÜÜÜÜÜ", "Cancel": "Abbrechen", "Delete": "Löschen"}
+
+	tests := []struct {
+		maxLength int
+		fuzzy     bool
+		warned    []string
+	}{
+		{0, false, []string{"Cancel"}},
+		{10, false, []string{"Cancel"}},
+		{7, true, []string{"Save", "Cancel"}},
+	}
+
+	for _, tt := range tests {
+		p.MaxLength, p.MaxLengthFuzzy = tt.maxLength, tt.fuzzy
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_de.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+			return translations[text], nil
+		}}
+		stderrFile := filepath.Join(tempDir, "stderr")
+		stderr, err := os.Create(stderrFile)
+		if err != nil {
+			t.Fatalf("Failed to create stderr file: %v", err)
+		}
+		os.Stderr = stderr
+		_, err = p.TranslatePoFile(poFile, potEntries, "en", "de", 0, translator)
+		os.Stderr = previousStderr
+		stderr.Close()
+		if err != nil {
+			t.Fatalf("TranslatePoFile() error = %v", err)
+		}
+		warnings, _ := os.ReadFile(stderrFile)
+
+		for msgid := range translations {
+			warned := strings.Contains(string(warnings), "translation of '"+msgid+"'")
+			if expected := strings.Contains(strings.Join(tt.warned, " "), msgid); warned != expected {
+				t.Errorf("max-length %d: expected a warning for '%s' %v, got:\n%s", tt.maxLength, msgid, expected, warnings)
+			}
+		}
+		if tt.maxLength == 7 && !strings.Contains(string(warnings), "translation of 'Save' has 10 characters, more than the maximum of 7, marking as fuzzy") {
+			t.Errorf("Expected the length in characters, got:\n%s", warnings)
+		}
+
+		// Only with --max-length-fuzzy the long translations are marked
+		expected := 0
+		if tt.fuzzy {
+			expected = len(tt.warned)
+		}
+		if content, _ := os.ReadFile(poFile); strings.Count(string(content), "#, fuzzy") != expected {
+			t.Errorf("max-length %d: expected %d fuzzy entries, got:\n%s", tt.maxLength, expected, content)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package catalog
+
+// claimLimit reserves one translation of the --limit, reporting false when
+// the limit is used up. Reserved translations that fail are given back with
+// releaseLimit.
+func (p *Processor) claimLimit() bool {
+	if p.Limit <= 0 {
+		return true
+	}
+	if p.limitUsed.Add(1) > int64(p.Limit) {
+		p.limitUsed.Add(-1)
+		p.limitHit.Store(true)
+		return false
+	}
+	return true
+}
+
+// releaseLimit gives back a translation reserved with claimLimit.
+func (p *Processor) releaseLimit() {
+	if p.Limit > 0 {
+		p.limitUsed.Add(-1)
+	}
+}
+
+// limitReached reports whether no more translations fit in the --limit.
+func (p *Processor) limitReached() bool {
+	return p.Limit > 0 && p.limitUsed.Load() >= int64(p.Limit)
+}
+
+// startLimitFile resets the --limit for the next PO file with
+// --limit-per-file.
+func (p *Processor) startLimitFile() {
+	if p.LimitPerFile {
+		p.limitUsed.Store(0)
+	}
+}
+
+// LimitHit reports whether entries were left untranslated because of the
+// --limit.
+func (p *Processor) LimitHit() bool {
+	return p.limitHit.Load()
+}
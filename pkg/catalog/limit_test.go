@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLimit(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	tests := []struct {
+		name       string
+		limit      int
+		perFile    bool
+		translated int
+		failed     int
+		skipped    int
+	}{
+		{name: "no limit", limit: 0, translated: 8, failed: 2},
+		// The second file isn't sent to the backend at all
+		{name: "whole run", limit: 3, translated: 3, failed: 1, skipped: 6},
+		{name: "per file", limit: 3, perFile: true, translated: 6, failed: 2, skipped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Limit, p.LimitPerFile = tt.limit, tt.perFile
+			p.limitUsed.Store(0)
+			p.limitHit.Store(false)
+
+			dir := t.TempDir()
+			files := map[string]string{
+				"default.pot":   "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Broken\"\nmsgstr \"\"\n\nmsgid \"One\"\nmsgstr \"\"\n\nmsgid \"Two\"\nmsgstr \"\"\n\nmsgid \"Three\"\nmsgstr \"\"\n\nmsgid \"Four\"\nmsgstr \"\"\n",
+				"default_de.po": "msgid \"\"\nmsgstr \"\"\n\"Language: de\\n\"\n",
+				"default_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n",
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			// Failed translations don't count against the limit
+			translator := &fakeTranslator{}
+			translator.translate = func(text, from, to string) (string, error) {
+				if text == "Broken" {
+					return "", fmt.Errorf("backend error")
+				}
+				return to + ":" + text, nil
+			}
+
+			result, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil)
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			if result.Translated != tt.translated || result.Failed != tt.failed || result.Skipped != tt.skipped {
+				t.Errorf("Expected %d translated, %d failed and %d skipped, got %+v", tt.translated, tt.failed, tt.skipped, result)
+			}
+			if p.limitHit.Load() != (tt.skipped > 0) {
+				t.Errorf("Expected limitHit %v", tt.skipped > 0)
+			}
+
+			// Only translated entries have a msgstr, the rest stay empty
+			translated := 0
+			for _, name := range []string{"default_de.po", "default_es.po"} {
+				entries, _, err := p.ParsePotFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("Failed to parse %s: %v", name, err)
+				}
+				if len(entries) != 5 {
+					t.Errorf("Expected all 5 entries in %s, got %d", name, len(entries))
+				}
+				for _, entry := range entries {
+					if entry.Msgstr != "" {
+						translated++
+					}
+				}
+			}
+			if translated != tt.translated {
+				t.Errorf("Expected %d translated entries in the PO files, got %d", tt.translated, translated)
+			}
+		})
+	}
+}
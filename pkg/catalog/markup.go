@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagRegexp matches HTML/XML opening, closing and self-closing tags.
+var tagRegexp = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9:-]*)(\s[^<>]*?)?(/?)>`)
+
+// voidElements are HTML elements that never have a closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true, "wbr": true,
+}
+
+// markupMatches reports whether the translation contains exactly the tags of
+// the source, attributes included, and nests them properly when the source
+// does.
+func markupMatches(source, translation string) bool {
+	sourceTags := tagRegexp.FindAllString(source, -1)
+	translationTags := tagRegexp.FindAllString(translation, -1)
+	if len(sourceTags) != len(translationTags) {
+		return false
+	}
+
+	sort.Strings(sourceTags)
+	sort.Strings(translationTags)
+	for i := range sourceTags {
+		if sourceTags[i] != translationTags[i] {
+			return false
+		}
+	}
+
+	return !balancedTags(source) || balancedTags(translation)
+}
+
+// balancedTags reports whether every opening tag in text is closed in the
+// right order.
+func balancedTags(text string) bool {
+	var open []string
+	for _, match := range tagRegexp.FindAllStringSubmatch(text, -1) {
+		closing, name, selfClosing := match[1] == "/", strings.ToLower(match[2]), match[4] == "/"
+		if selfClosing || (voidElements[name] && !closing) {
+			continue
+		}
+		if !closing {
+			open = append(open, name)
+			continue
+		}
+		if len(open) == 0 || open[len(open)-1] != name {
+			return false
+		}
+		open = open[:len(open)-1]
+	}
+	return len(open) == 0
+}
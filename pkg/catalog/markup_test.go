@@ -0,0 +1,102 @@
+package catalog
+
+import (
+	"testing"
+)
+
+func TestMarkupMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		translation string
+		expected    bool
+	}{
+		{
+			name:        "tags preserved",
+			source:      `Click <a href="%s">here</a>`,
+			translation: `Haga clic <a href="%s">aquí</a>`,
+			expected:    true,
+		},
+		{
+			name:        "independent tags swapped",
+			source:      `<b>Bold</b> and <i>italic</i>`,
+			translation: `<i>Cursiva</i> y <b>negrita</b>`,
+			expected:    true,
+		},
+		{
+			name:        "void and self-closing tags",
+			source:      `Line<br>break<img src="x.png"/>`,
+			translation: `Salto<br>de línea<img src="x.png"/>`,
+			expected:    true,
+		},
+		{
+			name:        "no markup",
+			source:      `Hello`,
+			translation: `Hola`,
+			expected:    true,
+		},
+		{
+			name:        "missing closing tag",
+			source:      `Click <a href="%s">here</a>`,
+			translation: `Haga clic <a href="%s">aquí`,
+			expected:    false,
+		},
+		{
+			name:        "duplicated tag",
+			source:      `<b>Warning</b>`,
+			translation: `<b>Aviso</b></b>`,
+			expected:    false,
+		},
+		{
+			name:        "reordered tags",
+			source:      `<b>Bold <i>italic</i></b>`,
+			translation: `<b>Negrita <i>cursiva</b></i>`,
+			expected:    false,
+		},
+		{
+			name:        "closing tag before opening tag",
+			source:      `<a href="/help">Help</a>`,
+			translation: `</a>Ayuda<a href="/help">`,
+			expected:    false,
+		},
+		{
+			name:        "translated attribute",
+			source:      `<a href="/" title="Home">Start</a>`,
+			translation: `<a href="/" title="Inicio">Comienzo</a>`,
+			expected:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := markupMatches(tt.source, tt.translation); result != tt.expected {
+				t.Errorf("markupMatches(%q, %q) = %v, want %v", tt.source, tt.translation, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckMarkupMarksFuzzy(t *testing.T) {
+	p := New(DefaultOptions())
+	p.CheckMarkup = true
+
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		return "<b>Aviso", nil
+	}}
+
+	translated, _, issue, err := p.translateString(translator, "<b>Warning</b>", "en", "es")
+	if err != nil {
+		t.Fatalf("translateString() error = %v", err)
+	}
+	if translated != "<b>Aviso" {
+		t.Errorf("Expected the translation to be kept, got %q", translated)
+	}
+	if issue == "" {
+		t.Error("Expected a markup issue")
+	}
+
+	p.CheckMarkup = false
+	if _, _, issue, _ := p.translateString(translator, "<b>Warning</b>", "en", "es"); issue != "" {
+		t.Errorf("Expected no issue without --check-markup, got %q", issue)
+	}
+}
@@ -0,0 +1,46 @@
+package catalog
+
+// loadMemory reads the translations of the --merge-from PO file, keyed like
+// the POT entries. Untranslated and fuzzy entries are left out, as they
+// aren't worth reusing.
+func (p *Processor) loadMemory(path string) (map[string]POEntry, error) {
+	entries, _, err := p.ParsePotFile(path)
+	if err != nil {
+		return nil, err
+	}
+	memory := make(map[string]POEntry)
+	for key, entry := range entries {
+		if key != "" && isTranslated(entry) && !hasFlag(entry.Flags, "fuzzy") {
+			memory[key] = entry
+		}
+	}
+	return memory, nil
+}
+
+// mergeMemory fills the entries found in the --merge-from translations into
+// the result and returns the keys that still need the backend. Plural
+// entries are only filled when the memory has the same number of forms.
+func (p *Processor) mergeMemory(keys []string, pluralSources map[string]string, nplurals int, result *entryTranslations) []string {
+	if len(p.memory) == 0 {
+		return keys
+	}
+
+	var remaining []string
+	for _, key := range keys {
+		entry, exists := p.memory[key]
+		if _, isPlural := pluralSources[key]; isPlural {
+			if exists && entry.MsgidPlural != "" && len(entry.Msgstrs) == nplurals {
+				result.plural[key] = append([]string(nil), entry.Msgstrs...)
+				result.merged++
+				continue
+			}
+		} else if exists && entry.MsgidPlural == "" {
+			result.singular[key] = entry.Msgstr
+			result.merged++
+			continue
+		}
+		remaining = append(remaining, key)
+	}
+	result.count += result.merged
+	return remaining
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeFrom(t *testing.T) {
+	p := New(DefaultOptions())
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"test.pot": `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+msgid "Open"
+msgstr ""
+
+msgctxt "menu"
+msgid "Quit"
+msgstr ""
+`,
+		"test_es.po": `msgid ""
+msgstr ""
+"Language: es\n"
+`,
+		// An older partial catalog, its fuzzy and untranslated entries and
+		// the Quit without context aren't reused
+		"old_es.po": `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+#, fuzzy
+msgid "World"
+msgstr "Mundial"
+
+msgid "Open"
+msgstr ""
+
+msgid "Quit"
+msgstr "Salir"
+
+msgctxt "menu"
+msgid "Quit"
+msgstr "Cerrar"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var err error
+	if p.memory, err = p.loadMemory(filepath.Join(tempDir, "old_es.po")); err != nil {
+		t.Fatalf("loadMemory() error = %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(filepath.Join(tempDir, "test.pot"))
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	poFile := filepath.Join(tempDir, "test_es.po")
+	translator := &fakeTranslator{}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+	if err != nil {
+		t.Fatalf("TranslatePoFile() error = %v", err)
+	}
+	expected := TranslationResult{Added: 4, Translated: 2, Merged: 2, Requests: RequestStats{Calls: 2}}
+	result.Requests.CallTime = 0
+	if result != expected {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+	if translator.calls() != 2 {
+		t.Errorf("Expected only the 2 remaining strings to reach the backend, got %v", translator.texts)
+	}
+
+	entries, _, err := p.ParsePotFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to parse PO file: %v", err)
+	}
+	for key, msgstr := range map[string]string{
+		"Hello":                  "Hola",
+		"World":                  "es:World",
+		"Open":                   "es:Open",
+		entryKey("menu", "Quit"): "Cerrar",
+	} {
+		if entries[key].Msgstr != msgstr {
+			t.Errorf("Expected %q for %q, got %q", msgstr, key, entries[key].Msgstr)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Options configures how catalogs are processed. Each field corresponds to
+// the potranslate command line option named in its comment.
+type Options struct {
+	Rewrite                bool             // --rewrite
+	Sort                   bool             // --sort
+	KeepTranslatorComments bool             // --keep-translator-comments
+	ClearPrevious          bool             // --clear-previous
+	NormalizeNewlines      bool             // --normalize-newlines
+	Canonical              bool             // --canonical
+	Compact                bool             // --compact
+	FlushEvery             int              // --flush-every, 0 to write each PO file once
+	ToUTF8                 bool             // --to-utf8
+	Dedupe                 bool             // --dedupe
+	PurgeObsolete          bool             // --purge-obsolete
+	Diff                   bool             // --diff
+	Backup                 bool             // --backup
+	BackupSuffix           string           // --backup-suffix
+	Force                  bool             // --force
+	LastTranslator         string           // --translator
+	Interactive            bool             // --interactive
+	Limit                  int              // --limit
+	LimitPerFile           bool             // --limit-per-file
+	ForceRetranslate       bool             // --force-retranslate
+	RetranslateOnly        string           // --retranslate-only
+	MarkFuzzy              bool             // --mark-fuzzy
+	AddedComment           string           // --added-comment
+	CheckMarkup            bool             // --check-markup
+	RejectFormatMismatch   bool             // --reject-format-mismatch
+	MaxLength              int              // --max-length
+	MaxLengthFuzzy         bool             // --max-length-fuzzy
+	PlaceholderStyle       string           // --placeholder-style
+	NoWrap                 bool             // --no-wrap
+	Width                  int              // --width
+	OnlyLang               string           // --only-lang
+	SkipLang               string           // --skip-lang
+	SourceLang             string           // --source-lang
+	ForceSource            bool             // --force-source
+	DetectSource           bool             // --detect-source
+	Pot                    string           // --pot, instead of <domain>.pot in the directory
+	Layout                 string           // --layout
+	Naming                 string           // --naming
+	Trust                  string           // --trust
+	OutDir                 string           // --out-dir
+	Backend                string           // --backend, the name used in messages
+	Timeout                time.Duration    // --timeout
+	Progress               string           // --progress
+	Verbose                bool             // --verbose
+	Concurrency            int              // --concurrency
+	AddLang                string           // --add-lang
+	SeedFrom               string           // --seed-from
+	FilterRef              string           // --filter-ref
+	Since                  time.Time        // --since, the zero time for all entries
+	SkipUndated            bool             // --skip-undated
+	IgnorePatterns         []*regexp.Regexp // --ignore-pattern
+	IgnoreEmpty            bool             // --ignore-empty
+	NoNetwork              bool             // --no-network
+	AllowIdentical         bool             // --allow-identical
+	Repair                 bool             // --repair
+	RateLimiter            *RateLimiter     // --rps, nil for no limit
+
+	// LanguageDelays holds the --lang-delay of the target languages, keyed by
+	// their NormalizeLocale code. They replace the delay of --delay or --fast.
+	LanguageDelays map[string]time.Duration
+
+	// Output receives the human readable progress, os.Stdout by default.
+	Output io.Writer
+
+	// Sleeper waits the delays between translations, time.Sleep by default.
+	Sleeper Sleeper
+}
+
+// DefaultOptions returns the options with the defaults of the command line.
+func DefaultOptions() Options {
+	return Options{
+		BackupSuffix:     ".bak",
+		LastTranslator:   "potranslate",
+		AddedComment:     "added from POT",
+		FlushEvery:       25,
+		PlaceholderStyle: "auto",
+		Width:            79,
+		Layout:           "flat",
+		Naming:           "underscore",
+		Trust:            "header",
+		Backend:          "google",
+		Timeout:          30 * time.Second,
+		Progress:         "auto",
+		Concurrency:      1,
+		Output:           os.Stdout,
+		Sleeper:          SleeperFunc(time.Sleep),
+	}
+}
+
+// ValidNaming reports whether the name is a --naming preset.
+func ValidNaming(name string) bool {
+	_, exists := namingSeparators[name]
+	return exists
+}
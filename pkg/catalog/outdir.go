@@ -0,0 +1,40 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outputFile returns the file a PO file is written to: the PO file itself, or
+// with --out-dir its path relative to the processed directory placed below
+// the output directory, so files of different directories don't collide.
+func (p *Processor) outputFile(root, poFile string) string {
+	if p.OutDir == "" {
+		return poFile
+	}
+	return filepath.Join(p.OutDir, relativePath(root, poFile))
+}
+
+// stagePoFile copies a PO file to its --out-dir location, creating the
+// directories as needed, and returns the copy to translate. Without
+// --out-dir, or with --diff as nothing is written, the PO file itself is
+// returned.
+func (p *Processor) stagePoFile(root, poFile string) (string, error) {
+	outFile := p.outputFile(root, poFile)
+	if outFile == poFile || p.Diff {
+		return poFile, nil
+	}
+
+	content, err := os.ReadFile(poFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return "", fmt.Errorf("creating output directory: %v", err)
+	}
+	if err := writeFileAtomic(outFile, content, fileMode(poFile)); err != nil {
+		return "", fmt.Errorf("writing output file: %v", err)
+	}
+	return outFile, nil
+}
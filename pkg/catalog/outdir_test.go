@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutDir(t *testing.T) {
+	p := New(DefaultOptions())
+	// The source language isn't written to the POT file either
+	p.Layout, p.SourceLang = "flat", "en"
+
+	potContent := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	tests := []struct {
+		name     string
+		rewrite  bool
+		addLang  string
+		outFile  string
+		language string
+	}{
+		{name: "translate", outFile: "default_es.po", language: "es"},
+		{name: "rewrite", rewrite: true, outFile: "default_es.po", language: "es"},
+		{name: "add language", addLang: "fr", outFile: "default_fr.po", language: "fr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p.OutDir = filepath.Join(t.TempDir(), "staging")
+			p.Rewrite, p.AddLang = tt.rewrite, tt.addLang
+			files := map[string]string{"default.pot": potContent, "default_es.po": poContent}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			result, err := p.ProcessDirectory(dir, dir, "default", 0, &fakeTranslator{}, nil)
+			if err != nil {
+				t.Fatalf("ProcessDirectory() error = %v", err)
+			}
+			if result.Translated != 1 {
+				t.Errorf("Expected 1 translated string, got %d", result.Translated)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("Failed to read directory: %v", err)
+			}
+			if len(entries) != len(files) {
+				t.Errorf("Expected no files to be added to the source directory, got %d", len(entries))
+			}
+			for name, content := range files {
+				current, _ := os.ReadFile(filepath.Join(dir, name))
+				if string(current) != content {
+					t.Errorf("Expected %s to be unchanged, got:\n%s", name, current)
+				}
+			}
+
+			content, err := os.ReadFile(filepath.Join(p.OutDir, tt.outFile))
+			if err != nil {
+				t.Fatalf("Expected the output file: %v", err)
+			}
+			if !strings.Contains(string(content), `msgstr "`+tt.language+`:Hello"`) {
+				t.Errorf("Expected a translated output file, got:\n%s", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package catalog
+
+// copyOwner does nothing on systems without Unix file ownership.
+func copyOwner(name, existing string) {}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package catalog
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives the file the owner and group of the existing file. Only
+// root can give a file away, so errors are ignored and the file keeps the
+// owner of the process then.
+func copyOwner(name, existing string) {
+	info, err := os.Stat(existing)
+	if err != nil {
+		return
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(name, int(stat.Uid), int(stat.Gid))
+	}
+}
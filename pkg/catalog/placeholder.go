@@ -0,0 +1,126 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// placeholderPatterns matches the format specifiers of each placeholder style.
+// The printf styles also match the %% escape first, so that it can't start a
+// specifier, and leave out the space flag, so that "100% sure" stays prose.
+var placeholderPatterns = map[string]*regexp.Regexp{
+	// C printf style: %s, %d, %5.2f, %ld, %1$s, and Python's %(name)s
+	"c": regexp.MustCompile(`%%|%(?:\d+\$|\([A-Za-z0-9_]+\))?[-+#0]*\d*(?:\.\d+)?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcsp]`),
+	// Positional printf style: %1$s, %2$d
+	"positional": regexp.MustCompile(`%%|%\d+\$[-+#0]*\d*(?:\.\d+)?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcsp]`),
+	// Python format style: {name}, {0}, {}, {value:>10}
+	"python": regexp.MustCompile(`\{[A-Za-z0-9_]*(?:![rsa])?(?::[^{}]*)?\}`),
+}
+
+// formatStyles maps the format flags of an entry, as xgettext writes them, to
+// the placeholder style of its format specifiers.
+var formatStyles = map[string]string{
+	"c-format":            "c",
+	"python-format":       "c",
+	"python-brace-format": "python",
+}
+
+// ValidPlaceholderStyle reports whether style is "none", "auto" or a known
+// style.
+func ValidPlaceholderStyle(style string) bool {
+	_, exists := placeholderPatterns[style]
+	return exists || style == "none" || style == "auto"
+}
+
+// entryStyle returns the placeholder style of an entry with the flags. A
+// format flag like "c-format" selects its style, and "no-c-format" none at
+// all. Entries without a format flag need no protection with the default
+// "auto", as xgettext flags the entries with format specifiers, and get
+// the --placeholder-style when one is set. With "none" nothing is protected,
+// whatever the flags.
+func (p *Processor) entryStyle(flags []string) string {
+	if p.PlaceholderStyle == "none" {
+		return p.PlaceholderStyle
+	}
+	for _, flag := range flags {
+		if style, exists := formatStyles[flag]; exists {
+			return style
+		}
+		if _, exists := formatStyles[strings.TrimPrefix(flag, "no-")]; exists {
+			return "none"
+		}
+	}
+	if p.PlaceholderStyle == "auto" || p.PlaceholderStyle == "" {
+		return "none"
+	}
+	return p.PlaceholderStyle
+}
+
+// placeholderToken returns the sentinel token that replaces placeholder n.
+func placeholderToken(n int) string {
+	return fmt.Sprintf("__PH%d__", n)
+}
+
+// protectPlaceholders replaces the placeholders of the given style with
+// sentinel tokens the translator leaves alone, returning the masked text and
+// the original placeholders in order.
+func protectPlaceholders(text, style string) (string, []string) {
+	pattern, exists := placeholderPatterns[style]
+	if !exists {
+		return text, nil
+	}
+
+	var placeholders []string
+	masked := pattern.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "%%" {
+			return match
+		}
+		placeholders = append(placeholders, match)
+		return placeholderToken(len(placeholders) - 1)
+	})
+	return masked, placeholders
+}
+
+// protectedRemainder returns text with the placeholders of the given style
+// removed, leaving the part the translator actually has to translate.
+func protectedRemainder(text, style string) string {
+	pattern, exists := placeholderPatterns[style]
+	if !exists {
+		return text
+	}
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "%%" {
+			return match
+		}
+		return ""
+	})
+}
+
+// formatMismatch describes how the format specifiers of the translation
+// differ from those of the source text in the placeholder style, like a "%s"
+// the source doesn't have, or returns an empty string when they match. Like
+// with msgfmt, reordering specifiers needs their positions, as in "%2$s".
+func formatMismatch(source, translation, style string) string {
+	if _, exists := placeholderPatterns[style]; !exists {
+		return ""
+	}
+	return compareSpecifiers(source, translation, style, false)
+}
+
+// restorePlaceholders puts the original placeholders back in place of their
+// tokens, reporting false when the tokens didn't survive translation intact.
+func restorePlaceholders(text string, placeholders []string) (string, bool) {
+	ok := true
+	for n, placeholder := range placeholders {
+		token := placeholderToken(n)
+		if strings.Count(text, token) != 1 {
+			ok = false
+		}
+		text = strings.ReplaceAll(text, token, placeholder)
+	}
+	if strings.Contains(text, "__PH") {
+		ok = false
+	}
+	return text, ok
+}
@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlaceholderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		style     string
+		input     string
+		masked    string
+		wantCount int
+	}{
+		{name: "c style", style: "c", input: "Found %d files in %s", masked: "Found __PH0__ files in __PH1__", wantCount: 2},
+		{name: "c style with width", style: "c", input: "Progress: %5.2f%%", masked: "Progress: __PH0__%%", wantCount: 1},
+		{name: "c style percent before word", style: "c", input: "100% sure", masked: "100% sure", wantCount: 0},
+		{name: "c style percent before space", style: "c", input: "Save 20% instantly", masked: "Save 20% instantly", wantCount: 0},
+		{name: "c style escaped percent", style: "c", input: "50%% done", masked: "50%% done", wantCount: 0},
+		{name: "positional", style: "positional", input: "%2$s owns %1$d items", masked: "__PH0__ owns __PH1__ items", wantCount: 2},
+		{name: "python named", style: "python", input: "Hello {name}, you have {count} messages", masked: "Hello __PH0__, you have __PH1__ messages", wantCount: 2},
+		{name: "python indexed", style: "python", input: "{0} of {1}", masked: "__PH0__ of __PH1__", wantCount: 2},
+		{name: "none", style: "none", input: "Found %d files", masked: "Found %d files", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, placeholders := protectPlaceholders(tt.input, tt.style)
+			if masked != tt.masked {
+				t.Errorf("protectPlaceholders() = %q, want %q", masked, tt.masked)
+			}
+			if len(placeholders) != tt.wantCount {
+				t.Errorf("Expected %d placeholders, got %d", tt.wantCount, len(placeholders))
+			}
+
+			restored, ok := restorePlaceholders(masked, placeholders)
+			if !ok {
+				t.Error("restorePlaceholders() reported a mismatch")
+			}
+			if restored != tt.input {
+				t.Errorf("restorePlaceholders() = %q, want %q", restored, tt.input)
+			}
+		})
+	}
+}
+
+func TestRestorePlaceholdersMismatch(t *testing.T) {
+	placeholders := []string{"%d", "%s"}
+
+	tests := []struct {
+		name       string
+		translated string
+	}{
+		{name: "token dropped", translated: "Encontrados __PH0__ archivos"},
+		{name: "token duplicated", translated: "__PH0__ __PH0__ en __PH1__"},
+		{name: "token mangled", translated: "__PH0__ archivos en __ PH1__"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := restorePlaceholders(tt.translated, placeholders); ok {
+				t.Errorf("Expected mismatch for %q", tt.translated)
+			}
+		})
+	}
+}
+
+func TestPlaceholderMismatchMarksFuzzy(t *testing.T) {
+	p := New(DefaultOptions())
+	p.PlaceholderStyle = "c"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Found %d files"
+msgstr ""
+
+msgid "Hello %s"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	// Drop the placeholder token of the first entry, as a translator might.
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		if strings.HasPrefix(text, "Found") {
+			return "Encontrados archivos", nil
+		}
+		return to + ":" + text, nil
+	}}
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		if rewrite {
+			_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+		} else {
+			_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("Processing failed: %v", err)
+		}
+
+		updatedContent, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read updated PO file: %v", err)
+		}
+		updatedStr := string(updatedContent)
+
+		if !strings.Contains(updatedStr, "#, fuzzy\nmsgid \"Found %d files\"\nmsgstr \"Encontrados archivos\"") {
+			t.Errorf("Expected mismatched entry to be marked fuzzy (rewrite=%v):\n%s", rewrite, updatedStr)
+		}
+		if !strings.Contains(updatedStr, `msgstr "es:Hello %s"`) {
+			t.Errorf("Expected placeholder to be restored (rewrite=%v):\n%s", rewrite, updatedStr)
+		}
+		if count := strings.Count(updatedStr, "#, fuzzy"); count != 1 {
+			t.Errorf("Expected 1 fuzzy flag, got %d (rewrite=%v):\n%s", count, rewrite, updatedStr)
+		}
+	}
+}
+
+func TestFormatMismatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		translation string
+		style       string
+		expected    string
+	}{
+		{"same", "Hello %s, %d new", "Hola %s, %d nuevos", "c", ""},
+		{"extra", "Hello", "Hola %s", "c", "has the format specifiers '%s' instead of ''"},
+		{"missing", "Found %d files in %s", "Encontrados en %s", "c", "has the format specifiers '%s' instead of '%d %s'"},
+		{"reordered", "%s has %d files", "%d archivos tiene %s", "c", "has the format specifiers '%d %s' instead of '%s %d'"},
+		{"reordered by position", "%s has %d files", "%2$d archivos tiene %1$s", "c", ""},
+		{"other width", "%5d files", "%d archivos", "c", ""},
+		{"escaped percent", "100%% done", "100%% hecho", "c", ""},
+		{"python reordered", "{name} has {count} files", "{count} archivos tiene {name}", "python", ""},
+		{"python renamed", "Hello {name}", "Hola {nombre}", "python", "has the format specifiers '{nombre}' instead of '{name}'"},
+		{"no style", "Hello", "Hola %s", "none", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if mismatch := formatMismatch(tt.source, tt.translation, tt.style); mismatch != tt.expected {
+				t.Errorf("formatMismatch(%q, %q) = %q, want %q", tt.source, tt.translation, mismatch, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatMismatchRejected(t *testing.T) {
+	p := New(DefaultOptions())
+	p.PlaceholderStyle = "c"
+
+	// The backend invents a specifier, or swaps the tokens of two different
+	// specifiers
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		if text == "Saved" {
+			return "Guardado %s", nil
+		}
+		return "__PH1__ archivos en __PH0__", nil
+	}}
+	tests := []struct {
+		text   string
+		reject bool
+		issue  string
+		err    string
+	}{
+		{"Saved", false, "The translation has the format specifiers '%s' instead of ''", ""},
+		{"Saved", true, "", "the translation has the format specifiers '%s' instead of ''"},
+		{"In %s: %d files", false, "The translation has the format specifiers '%d %s' instead of '%s %d'", ""},
+		{"In %s: %d files", true, "", "the translation has the format specifiers '%d %s' instead of '%s %d'"},
+	}
+	for _, tt := range tests {
+		p.RejectFormatMismatch = tt.reject
+		translated, _, issue, err := p.translateString(translator, tt.text, "en", "es")
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err || translated != "" {
+				t.Errorf("%q: expected the translation to be rejected with %q, got %q, %v", tt.text, tt.err, translated, err)
+			}
+			continue
+		}
+		if err != nil || issue != tt.issue {
+			t.Errorf("%q: expected issue %q, got %q, %v", tt.text, tt.issue, issue, err)
+		}
+	}
+}
+
+func TestFormatFlagStyles(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#, c-format
+msgid "Deleted %d files"
+msgstr ""
+
+#, python-format
+msgid "Welcome %(name)s"
+msgstr ""
+
+#, python-brace-format
+msgid "Goodbye {name}"
+msgstr ""
+
+#, no-c-format
+msgid "Save 50%d off"
+msgstr ""
+
+msgid "Rename {file} to %s"
+msgstr ""
+`
+	tests := []struct {
+		style    string
+		expected []string // Texts sent to the translator, in POT order
+	}{
+		{"auto", []string{"Deleted __PH0__ files", "Welcome __PH0__", "Goodbye __PH0__", "Save 50%d off", "Rename {file} to %s"}},
+		{"c", []string{"Deleted __PH0__ files", "Welcome __PH0__", "Goodbye __PH0__", "Save 50%d off", "Rename {file} to __PH0__"}},
+		{"python", []string{"Deleted __PH0__ files", "Welcome __PH0__", "Goodbye __PH0__", "Save 50%d off", "Rename __PH0__ to %s"}},
+		{"none", []string{"Deleted %d files", "Welcome %(name)s", "Goodbye {name}", "Save 50%d off", "Rename {file} to %s"}},
+	}
+	for _, tt := range tests {
+		p.PlaceholderStyle = tt.style
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		translator := &fakeTranslator{}
+		if _, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator); err != nil {
+			t.Fatalf("TranslatePoFile failed: %v", err)
+		}
+		if !slices.Equal(translator.texts, tt.expected) {
+			t.Errorf("%s: sent %q, want %q", tt.style, translator.texts, tt.expected)
+		}
+
+		// The placeholders are restored in the translations
+		content, _ := os.ReadFile(poFile)
+		if !strings.Contains(string(content), `msgstr "es:Welcome %(name)s"`) {
+			t.Errorf("%s: expected the placeholder to be restored:\n%s", tt.style, content)
+		}
+	}
+}
+
+func TestDefaultPlaceholderStyle(t *testing.T) {
+	p := New(DefaultOptions())
+
+	flagged := []string{"c-format"}
+	for _, style := range []string{DefaultOptions().PlaceholderStyle, Options{}.PlaceholderStyle} {
+		p.PlaceholderStyle = style
+		if got := p.entryStyle(nil); got != "none" {
+			t.Errorf("%q: expected no placeholders for an entry without a format flag, got %q", style, got)
+		}
+		if got := p.entryStyle(flagged); got != "c" {
+			t.Errorf("%q: expected the C style for a c-format entry, got %q", style, got)
+		}
+		translated, _, issue, err := p.translateString(&fakeTranslator{}, "Rename {file} to %s", "en", "es")
+		if err != nil || issue != "" || translated != "es:Rename {file} to %s" {
+			t.Errorf("%q: expected the text to be sent as is, got %q, %q, %v", style, translated, issue, err)
+		}
+	}
+}
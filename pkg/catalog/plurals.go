@@ -0,0 +1,96 @@
+package catalog
+
+import "strings"
+
+// pluralRules maps languages to the value of their Plural-Forms header, as
+// listed in the GNU gettext manual. Locales with a region only have their
+// own rule where it differs from the language (pt_BR).
+var pluralRules = map[string]string{
+	// A single form
+	"id": "nplurals=1; plural=0;",
+	"ja": "nplurals=1; plural=0;",
+	"km": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"lo": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+	"my": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+
+	// Singular for one only
+	"af": "nplurals=2; plural=(n != 1);",
+	"az": "nplurals=2; plural=(n != 1);",
+	"bg": "nplurals=2; plural=(n != 1);",
+	"bn": "nplurals=2; plural=(n != 1);",
+	"ca": "nplurals=2; plural=(n != 1);",
+	"da": "nplurals=2; plural=(n != 1);",
+	"de": "nplurals=2; plural=(n != 1);",
+	"el": "nplurals=2; plural=(n != 1);",
+	"en": "nplurals=2; plural=(n != 1);",
+	"eo": "nplurals=2; plural=(n != 1);",
+	"es": "nplurals=2; plural=(n != 1);",
+	"et": "nplurals=2; plural=(n != 1);",
+	"eu": "nplurals=2; plural=(n != 1);",
+	"fi": "nplurals=2; plural=(n != 1);",
+	"gl": "nplurals=2; plural=(n != 1);",
+	"gu": "nplurals=2; plural=(n != 1);",
+	"he": "nplurals=2; plural=(n != 1);",
+	"hi": "nplurals=2; plural=(n != 1);",
+	"hu": "nplurals=2; plural=(n != 1);",
+	"it": "nplurals=2; plural=(n != 1);",
+	"kn": "nplurals=2; plural=(n != 1);",
+	"ml": "nplurals=2; plural=(n != 1);",
+	"mr": "nplurals=2; plural=(n != 1);",
+	"nb": "nplurals=2; plural=(n != 1);",
+	"nl": "nplurals=2; plural=(n != 1);",
+	"nn": "nplurals=2; plural=(n != 1);",
+	"no": "nplurals=2; plural=(n != 1);",
+	"pt": "nplurals=2; plural=(n != 1);",
+	"sq": "nplurals=2; plural=(n != 1);",
+	"sv": "nplurals=2; plural=(n != 1);",
+	"sw": "nplurals=2; plural=(n != 1);",
+	"ta": "nplurals=2; plural=(n != 1);",
+	"te": "nplurals=2; plural=(n != 1);",
+	"tr": "nplurals=2; plural=(n != 1);",
+	"ur": "nplurals=2; plural=(n != 1);",
+
+	// Singular for zero and one
+	"fr":    "nplurals=2; plural=(n > 1);",
+	"oc":    "nplurals=2; plural=(n > 1);",
+	"pt_BR": "nplurals=2; plural=(n > 1);",
+
+	"is": "nplurals=2; plural=(n%10 != 1 || n%100 == 11);",
+
+	// Special forms for numbers ending in 2-4
+	"be": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"hr": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"sr": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"pl": "nplurals=3; plural=(n == 1 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"cs": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n == 1 ? 0 : n >= 2 && n <= 4 ? 1 : 2);",
+
+	// Other rules
+	"lt": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n%10 >= 2 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);",
+	"lv": "nplurals=3; plural=(n%10 == 1 && n%100 != 11 ? 0 : n != 0 ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n == 1 ? 0 : (n == 0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	"sl": "nplurals=4; plural=(n%100 == 1 ? 0 : n%100 == 2 ? 1 : n%100 == 3 || n%100 == 4 ? 2 : 3);",
+	"ga": "nplurals=5; plural=(n == 1 ? 0 : n == 2 ? 1 : n < 7 ? 2 : n < 11 ? 3 : 4);",
+	"ar": "nplurals=6; plural=(n == 0 ? 0 : n == 1 ? 1 : n == 2 ? 2 : n%100 >= 3 && n%100 <= 10 ? 3 : n%100 >= 11 ? 4 : 5);",
+}
+
+// pluralRule returns the Plural-Forms header value of a locale, from the
+// rule of the locale itself or else of its language, and false for locales
+// without a known rule.
+func pluralRule(locale string) (string, bool) {
+	locale = NormalizeLocale(locale)
+	if rule, exists := pluralRules[locale]; exists {
+		return rule, true
+	}
+	language, _, _ := strings.Cut(locale, "_")
+	rule, exists := pluralRules[language]
+	return rule, exists
+}
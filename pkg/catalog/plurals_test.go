@@ -0,0 +1,80 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyPotToPoPluralForms(t *testing.T) {
+	p := New(DefaultOptions())
+	polish := `"Plural-Forms: nplurals=3; plural=(n == 1 ? 0 : n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) ? 1 : 2);\n"`
+	template := `msgid ""
+msgstr ""
+"Language: en\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+msgid "File"
+msgid_plural "Files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	withoutRule := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "File"
+msgid_plural "Files"
+msgstr[0] ""
+msgstr[1] ""
+`
+
+	tests := []struct {
+		name       string
+		potContent string
+		targetLang string
+		expected   string
+	}{
+		{"template rule replaced", template, "pl", polish},
+		{"rule added", withoutRule, "pl", "\"Language: pl\\n\"\n" + polish + "\n\nmsgid \"File\""},
+		{"regional rule", template, "pt_BR", `"Plural-Forms: nplurals=2; plural=(n > 1);\n"`},
+		{"language rule for region", template, "de_AT", `"Plural-Forms: nplurals=2; plural=(n != 1);\n"`},
+		// Unknown languages keep the rule of the template
+		{"unknown language", template, "xx", `"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(tt.potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_"+tt.targetLang+".po")
+			if err := p.CopyPotToPo(potFile, poFile, tt.targetLang); err != nil {
+				t.Fatalf("CopyPotToPo failed: %v", err)
+			}
+			content, _ := os.ReadFile(poFile)
+			if !strings.Contains(string(content), tt.expected) || strings.Count(string(content), "Plural-Forms") != 1 {
+				t.Errorf("Expected %s in:\n%s", tt.expected, content)
+			}
+		})
+	}
+
+	// The new file gets the three Polish forms for its plural entries
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_pl.po")
+	if err := p.CopyPotToPo(potFile, poFile, "pl"); err != nil {
+		t.Fatalf("CopyPotToPo failed: %v", err)
+	}
+	content, _ := os.ReadFile(poFile)
+	lines, _ := splitLines(string(content))
+	if nplurals := parsePluralCount(lines); nplurals != 3 {
+		t.Errorf("Expected 3 plural forms, got %d", nplurals)
+	}
+}
@@ -0,0 +1,473 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// catalogEntry is a single block of a PO file as read by parsePoLines. Blocks
+// are either entries (msgid with its comments and msgstr) or runs of other
+// lines, like blank lines and obsolete #~ entries, that are kept verbatim.
+// Unmodified blocks are written back exactly as they were read.
+type catalogEntry struct {
+	comments    []string // Comment lines before the entry, including flags
+	keyLines    []string // msgctxt, msgid and msgid_plural lines
+	msgstrLines []string // msgstr or msgstr[N] lines
+	other       []string // Verbatim lines of a block that is not an entry
+
+	isEntry     bool
+	Msgctxt     string
+	Msgid       string
+	MsgidPlural string
+	Msgstr      string
+	Msgstrs     []string
+	Flags       []string
+	PrevMsgid   string // Previous msgid from the "#|" comments
+
+	modified      bool // Set when Msgstr, Msgstrs or Flags need to be written
+	missingMsgstr int  // Line of the msgid when it had no msgstr and got an empty one
+}
+
+// key returns the entry key of the block.
+func (e *catalogEntry) key() string {
+	return entryKey(e.Msgctxt, e.Msgid)
+}
+
+// isHeader reports whether the block is the header entry, the entry with an
+// empty msgid that holds the catalog metadata.
+func (e *catalogEntry) isHeader() bool {
+	return e.isEntry && e.Msgid == "" && e.Msgctxt == ""
+}
+
+// headerField returns the range of the msgstr lines of the header entry that
+// hold the named field, however its name and value are split over
+// continuation lines, or -1, -1 when the header doesn't have it. Names are
+// matched without regard to case, like POHeader.Get.
+func (e *catalogEntry) headerField(name string) (int, int) {
+	matches := func(text string) bool {
+		field, _, found := strings.Cut(text, ":")
+		return found && strings.EqualFold(strings.TrimSpace(field), name)
+	}
+	start, text := -1, "" // First line and text of the field so far
+	for i, line := range e.msgstrLines {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "msgstr"))
+		value := extractString(trimmed)
+		if !strings.HasPrefix(trimmed, "\"") || value == "" {
+			continue
+		}
+		if text == "" {
+			start = i
+		}
+		text += value
+		if strings.HasSuffix(value, "\n") {
+			if matches(text) {
+				return start, i + 1
+			}
+			text = ""
+		}
+	}
+	if text != "" && matches(text) {
+		return start, len(e.msgstrLines)
+	}
+	return -1, -1
+}
+
+// replaceHeaderField replaces a field of the header entry by a single line
+// in its place, reporting false when the header doesn't have the field.
+func (e *catalogEntry) replaceHeaderField(name, value string) bool {
+	start, end := e.headerField(name)
+	if start < 0 {
+		return false
+	}
+	field := fmt.Sprintf("\"%s: %s\\n\"", name, escapeString(value))
+	if strings.HasPrefix(strings.TrimSpace(e.msgstrLines[start]), "msgstr") {
+		field = "msgstr " + field
+	}
+	e.msgstrLines = slices.Replace(e.msgstrLines, start, end, field)
+	e.updateHeaderMsgstr()
+	return true
+}
+
+// setHeaderField sets a field of the header entry, keeping its position in
+// the msgstr lines, or appends the field when it is missing.
+func (e *catalogEntry) setHeaderField(name, value string) {
+	if !e.replaceHeaderField(name, value) {
+		e.insertHeaderField(len(e.msgstrLines), name, value)
+	}
+}
+
+// insertHeaderField inserts a field into the msgstr lines of the header
+// entry at index i.
+func (e *catalogEntry) insertHeaderField(i int, name, value string) {
+	e.msgstrLines = slices.Insert(e.msgstrLines, i, fmt.Sprintf("\"%s: %s\\n\"", name, escapeString(value)))
+	e.updateHeaderMsgstr()
+}
+
+// updateHeaderMsgstr sets the msgstr of the header entry from its lines, after
+// its fields were changed.
+func (e *catalogEntry) updateHeaderMsgstr() {
+	e.Msgstr = ""
+	for _, line := range e.msgstrLines {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "msgstr"))
+		if strings.HasPrefix(trimmed, "\"") {
+			e.Msgstr += extractString(trimmed)
+		}
+	}
+}
+
+// isPreviousComment reports whether a comment line is one of the "#|" lines
+// recording the previous source text of a fuzzy entry.
+func isPreviousComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#|")
+}
+
+// removePrevious drops the "#|" comments, once the entry is translated for
+// its current msgid.
+func (e *catalogEntry) removePrevious() {
+	var comments []string
+	for _, comment := range e.comments {
+		if !isPreviousComment(comment) {
+			comments = append(comments, comment)
+		}
+	}
+	e.comments = comments
+	e.PrevMsgid = ""
+}
+
+// previousMsgid collects the previous msgid from the "#|" comment lines that
+// gettext writes before fuzzy entries, like "#| msgid "Old text"".
+type previousMsgid struct {
+	value   string
+	keyword string // Keyword of the last "#|" line, continuation lines belong to it
+}
+
+// add parses a trimmed "#|" comment line, appending its string to the value
+// when it belongs to the msgid.
+func (p *previousMsgid) add(trimmed string) {
+	rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "#|"))
+	if strings.HasPrefix(rest, "\"") {
+		if p.keyword == "msgid" {
+			p.value += extractString(rest)
+		}
+		return
+	}
+	keyword, value, _ := strings.Cut(rest, " ")
+	p.keyword = keyword
+	if keyword == "msgid" {
+		p.value = extractString(strings.TrimSpace(value))
+	}
+}
+
+// setMsgid replaces the msgid of the entry for --normalize-newlines, with
+// the trailing newline of its translations matched to it. The keys are
+// wrapped at the width.
+func (e *catalogEntry) setMsgid(msgid string, width int) {
+	e.Msgid = msgid
+	e.keyLines = nil
+	if e.Msgctxt != "" {
+		e.keyLines = append(e.keyLines, formatPoString("msgctxt", e.Msgctxt, width)...)
+	}
+	e.keyLines = append(e.keyLines, formatPoString("msgid", msgid, width)...)
+	if e.MsgidPlural != "" {
+		e.keyLines = append(e.keyLines, formatPoString("msgid_plural", e.MsgidPlural, width)...)
+	}
+	e.Msgstr = matchTrailingNewline(msgid, e.Msgstr)
+	for n, form := range e.Msgstrs {
+		e.Msgstrs[n] = matchTrailingNewline(msgid, form)
+	}
+	e.modified = true
+}
+
+// entryComments holds the comments of an entry by type. The flags and the
+// previous msgid are kept apart, as they are parsed.
+type entryComments struct {
+	Translator []string // "# " comments written by translators
+	Extracted  []string // "#." comments extracted from the source code
+	References []string // "#:" source references
+}
+
+// add classifies a comment line, other than flags and previous msgid lines.
+// Unknown comment types count as translator comments.
+func (c *entryComments) add(line string) {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "#."):
+		c.Extracted = append(c.Extracted, line)
+	case strings.HasPrefix(trimmed, "#:"):
+		c.References = append(c.References, line)
+	default:
+		c.Translator = append(c.Translator, line)
+	}
+}
+
+// lines returns the comments in the order gettext writes them.
+func (c entryComments) lines() []string {
+	var lines []string
+	lines = append(lines, c.Translator...)
+	lines = append(lines, c.Extracted...)
+	return append(lines, c.References...)
+}
+
+// formatEntryComments returns the lines written before the msgctxt or msgid
+// of an entry, in the order gettext writes them: translator comments,
+// extracted comments, references, flags and the previous msgid.
+func (p *Processor) formatEntryComments(comments entryComments, flags []string, previous string) []string {
+	lines := comments.lines()
+	if len(flags) > 0 {
+		lines = append(lines, formatFlags(flags))
+	}
+	if previous != "" {
+		for _, previousLine := range formatPoString("msgid", previous, p.entryWidth(flags)) {
+			lines = append(lines, "#| "+previousLine)
+		}
+	}
+	return lines
+}
+
+// isPlural reports whether the entry has plural forms.
+func (e *catalogEntry) isPlural() bool {
+	return e.MsgidPlural != "" || len(e.Msgstrs) > 0
+}
+
+// needsTranslation reports whether the entry gets translated: when it has
+// no translation, all of its msgstr[N] forms being empty for a plural entry,
+// or when it is fuzzy or --force-retranslate applies to it. Only the PO
+// msgstr counts, a sample msgstr in the POT doesn't.
+func (p *Processor) needsTranslation(e *catalogEntry) bool {
+	if hasFlag(e.Flags, "fuzzy") || p.forceRetranslation(e.Flags, e.comments) {
+		return true
+	}
+	if e.isPlural() {
+		for _, form := range e.Msgstrs {
+			if form != "" {
+				return false
+			}
+		}
+		return true
+	}
+	return e.Msgstr == ""
+}
+
+// parsePoLines splits the lines of a PO file into blocks. Continuation lines
+// are attached to the keyword they follow, so multi-line strings always stay
+// within their own entry. It also returns the line numbers of continuation
+// lines that don't follow any keyword, which are kept as is. A msgid without
+// msgstr ends at the comments of the next entry and gets an empty msgstr.
+func parsePoLines(lines []string) ([]*catalogEntry, []int) {
+	var blocks []*catalogEntry
+	var stray []int
+	var current *catalogEntry
+	var target *string // Field that continuation lines are appended to
+	var targetLines *[]string
+	var previous previousMsgid
+	inMsgstr, hasMsgid := false, false
+	msgidLine := 0
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		if current.isEntry && hasMsgid && len(current.msgstrLines) == 0 {
+			current.missingMsgstr = msgidLine
+			if current.MsgidPlural != "" {
+				current.Msgstrs = []string{""}
+				current.msgstrLines = []string{`msgstr[0] ""`}
+			} else {
+				current.msgstrLines = []string{`msgstr ""`}
+			}
+		}
+		if current.isEntry {
+			blocks = append(blocks, current)
+		} else if len(current.comments) > 0 {
+			// Comments that aren't followed by an entry
+			blocks = append(blocks, &catalogEntry{other: current.comments})
+		}
+		current = nil
+		target = nil
+		targetLines = nil
+		previous = previousMsgid{}
+		inMsgstr, hasMsgid = false, false
+	}
+	start := func() {
+		if current == nil {
+			current = &catalogEntry{}
+		}
+	}
+	appendOther := func(line string) {
+		if len(blocks) > 0 && !blocks[len(blocks)-1].isEntry {
+			last := blocks[len(blocks)-1]
+			last.other = append(last.other, line)
+			return
+		}
+		blocks = append(blocks, &catalogEntry{other: []string{line}})
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#~"):
+			finish()
+			appendOther(line)
+		case strings.HasPrefix(trimmed, "#"):
+			if inMsgstr || (hasMsgid && !msgstrFollows(lines[i:])) {
+				finish()
+			}
+			start()
+			if current.isEntry {
+				// Comments between the keywords of an entry stay in place
+				current.keyLines = append(current.keyLines, line)
+				target = nil
+				continue
+			}
+			current.comments = append(current.comments, line)
+			if strings.HasPrefix(trimmed, "#,") {
+				current.Flags = mergeFlags(current.Flags, parseFlags(trimmed))
+			} else if strings.HasPrefix(trimmed, "#|") {
+				previous.add(trimmed)
+				current.PrevMsgid = previous.value
+			}
+			target = nil
+		case strings.HasPrefix(trimmed, "msgctxt "):
+			if inMsgstr || (current != nil && current.isEntry) {
+				finish()
+			}
+			start()
+			current.isEntry = true
+			current.Msgctxt = extractString(trimmed[8:])
+			current.keyLines = append(current.keyLines, line)
+			target, targetLines = &current.Msgctxt, &current.keyLines
+		case strings.HasPrefix(trimmed, "msgid "):
+			if inMsgstr || hasMsgid {
+				finish()
+			}
+			start()
+			current.isEntry = true
+			hasMsgid = true
+			msgidLine = i + 1
+			current.Msgid = extractString(trimmed[6:])
+			current.keyLines = append(current.keyLines, line)
+			target, targetLines = &current.Msgid, &current.keyLines
+		case strings.HasPrefix(trimmed, "msgid_plural ") && current != nil:
+			current.MsgidPlural = extractString(trimmed[13:])
+			current.keyLines = append(current.keyLines, line)
+			target, targetLines = &current.MsgidPlural, &current.keyLines
+		case strings.HasPrefix(trimmed, "msgstr ") && current != nil:
+			current.Msgstr = extractString(trimmed[7:])
+			current.msgstrLines = append(current.msgstrLines, line)
+			target, targetLines = &current.Msgstr, &current.msgstrLines
+			inMsgstr = true
+		case strings.HasPrefix(trimmed, "msgstr[") && current != nil:
+			_, str, _ := parsePluralMsgstr(trimmed)
+			current.Msgstrs = append(current.Msgstrs, str)
+			current.msgstrLines = append(current.msgstrLines, line)
+			target, targetLines = &current.Msgstrs[len(current.Msgstrs)-1], &current.msgstrLines
+			inMsgstr = true
+		case strings.HasPrefix(trimmed, "\"") && target != nil:
+			*target += extractString(trimmed)
+			*targetLines = append(*targetLines, line)
+		default:
+			if strings.HasPrefix(trimmed, "\"") {
+				stray = append(stray, i+1)
+			}
+			// Unknown lines are kept where they are
+			if current != nil && targetLines != nil {
+				*targetLines = append(*targetLines, line)
+			} else {
+				finish()
+				appendOther(line)
+			}
+		}
+	}
+	finish()
+
+	return blocks, stray
+}
+
+// msgstrFollows reports whether the comment lines are followed by a msgstr,
+// so they are in the middle of an entry instead of before the next one.
+func msgstrFollows(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "#~") {
+			return strings.HasPrefix(trimmed, "msgstr")
+		}
+	}
+	return false
+}
+
+// formatPoLines serializes blocks back into PO lines. Modified entries keep
+// their comments and msgid lines, but get their flags and msgstr rewritten.
+// Their "#|" comments are moved after the other comments and the flags, right
+// before the msgid, where gettext puts them.
+func (p *Processor) formatPoLines(blocks []*catalogEntry) []string {
+	var lines []string
+	for _, block := range blocks {
+		if !block.isEntry {
+			lines = append(lines, block.other...)
+			continue
+		}
+		if !block.modified {
+			lines = append(lines, block.comments...)
+			lines = append(lines, block.keyLines...)
+			lines = append(lines, block.msgstrLines...)
+			continue
+		}
+
+		// Replace the first flags line, dropping any others
+		flagsWritten := len(block.Flags) == 0
+		var previousLines []string
+		for _, comment := range block.comments {
+			if isPreviousComment(comment) {
+				previousLines = append(previousLines, comment)
+				continue
+			}
+			if strings.HasPrefix(strings.TrimSpace(comment), "#,") {
+				if !flagsWritten {
+					lines = append(lines, formatFlags(block.Flags))
+					flagsWritten = true
+				}
+				continue
+			}
+			lines = append(lines, comment)
+		}
+		if !flagsWritten {
+			lines = append(lines, formatFlags(block.Flags))
+		}
+		lines = append(lines, previousLines...)
+
+		lines = append(lines, block.keyLines...)
+		width := p.entryWidth(block.Flags)
+		if len(block.Msgstrs) > 0 {
+			for n, form := range block.Msgstrs {
+				lines = append(lines, formatPoString(fmt.Sprintf("msgstr[%d]", n), form, width)...)
+			}
+		} else {
+			lines = append(lines, formatPoString("msgstr", block.Msgstr, width)...)
+		}
+	}
+	return lines
+}
+
+// timeNow returns the time of the PO-Revision-Date, fixed by the tests that
+// compare whole files.
+var timeNow = time.Now
+
+// stampHeader sets the PO-Revision-Date and Last-Translator fields of the
+// header in the lines of a modified PO file, like the gettext tools do. An
+// empty --translator only leaves the Last-Translator untouched.
+func (p *Processor) stampHeader(lines []string) []string {
+	blocks, _ := parsePoLines(lines)
+	for _, block := range blocks {
+		if block.isHeader() {
+			block.setHeaderField("PO-Revision-Date", timeNow().Format("2006-01-02 15:04-0700"))
+			if p.LastTranslator != "" {
+				block.setHeaderField("Last-Translator", p.LastTranslator)
+			}
+			return p.formatPoLines(blocks)
+		}
+	}
+	return lines
+}
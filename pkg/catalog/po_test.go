@@ -0,0 +1,679 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePoLinesRoundTrip(t *testing.T) {
+	p := New(DefaultOptions())
+	content := `# Header comment
+msgid ""
+msgstr ""
+"Language: es\n"
+
+#: src/app.go:10
+#, fuzzy
+msgid ""
+"A long message "
+"over two lines"
+msgstr ""
+"Un mensaje largo "
+"en dos lineas"
+msgctxt "menu"
+msgid "Open"
+msgstr "Abrir"
+
+msgid "File"
+msgid_plural "Files"
+msgstr[0] "Archivo"
+msgstr[1] "Archivos"
+
+
+#~ msgid "Old"
+#~ msgstr "Viejo"
+`
+	lines, _ := splitLines(content)
+	blocks, stray := parsePoLines(lines)
+	if len(stray) != 0 {
+		t.Errorf("Expected no stray lines, got %v", stray)
+	}
+
+	var entries []*catalogEntry
+	for _, block := range blocks {
+		if block.isEntry {
+			entries = append(entries, block)
+		}
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+
+	multiline := entries[1]
+	if multiline.Msgid != "A long message over two lines" || multiline.Msgstr != "Un mensaje largo en dos lineas" {
+		t.Errorf("Unexpected multiline entry: %q -> %q", multiline.Msgid, multiline.Msgstr)
+	}
+	if !hasFlag(multiline.Flags, "fuzzy") {
+		t.Errorf("Expected fuzzy flag, got %v", multiline.Flags)
+	}
+	if entries[2].key() != entryKey("menu", "Open") || entries[2].Msgstr != "Abrir" {
+		t.Errorf("Unexpected context entry: %q -> %q", entries[2].key(), entries[2].Msgstr)
+	}
+	if !entries[3].isPlural() || len(entries[3].Msgstrs) != 2 {
+		t.Errorf("Expected plural entry with 2 forms, got %v", entries[3].Msgstrs)
+	}
+
+	if result := joinLines(p.formatPoLines(blocks), "\n"); result != content {
+		t.Errorf("Round trip changed the content:\n%s\nwant\n%s", result, content)
+	}
+}
+
+func TestParsePoLinesStrayContinuation(t *testing.T) {
+	p := New(DefaultOptions())
+	lines, _ := splitLines("msgid \"Hello\"\nmsgstr \"Hola\"\n\n\"stray\"\n")
+	blocks, stray := parsePoLines(lines)
+	if len(stray) != 1 || stray[0] != 4 {
+		t.Errorf("Expected stray line 4, got %v", stray)
+	}
+	if result := joinLines(p.formatPoLines(blocks), "\n"); !strings.Contains(result, "\"stray\"") {
+		t.Errorf("Stray line was dropped:\n%s", result)
+	}
+}
+
+func TestTranslatePoFileMultilineMsgid(t *testing.T) {
+	p := New(DefaultOptions())
+	// Header stamping is covered by TestStampHeader
+	p.LastTranslator = ""
+	previousNow := timeNow
+	timeNow = func() time.Time { return stampTime }
+	defer func() { timeNow = previousNow }()
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid ""
+"First part "
+"second part"
+msgstr ""
+
+msgid "Next"
+msgstr ""
+`
+	// The multiline msgid is followed immediately by another entry
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid ""
+"First part "
+"second part"
+msgstr ""
+msgid "Next"
+msgstr ""
+`
+	expected := `msgid ""
+msgstr ""
+"Language: es\n"
+"PO-Revision-Date: 2026-10-14 12:00+0000\n"
+
+msgid ""
+"First part "
+"second part"
+msgstr "es:First part second part"
+msgid "Next"
+msgstr "es:Next"
+`
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if result.Translated != 2 {
+		t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	if string(updatedContent) != expected {
+		t.Errorf("Unexpected output:\n%s\nwant\n%s", updatedContent, expected)
+	}
+}
+
+func TestTranslatePoFilePreservesHeader(t *testing.T) {
+	p := New(DefaultOptions())
+	// Header stamping is covered by TestStampHeader
+	p.LastTranslator = ""
+	previousNow := timeNow
+	timeNow = func() time.Time { return stampTime }
+	defer func() { timeNow = previousNow }()
+
+	header := `# Spanish translation of the application.
+# Copyright (C) 2024 Example Inc.
+# Jane Doe <jane@example.com>, 2024.
+#
+#, fuzzy
+msgid ""
+msgstr ""
+"Project-Id-Version: example 1.2.3\n"
+"Report-Msgid-Bugs-To: bugs@example.com\n"
+"POT-Creation-Date: 2024-01-01 12:00+0000\n"
+"PO-Revision-Date: 2024-01-02 13:00+0000\n"
+"Last-Translator: Jane Doe <jane@example.com>\n"
+"Language-Team: Spanish <es@example.com>\n"
+"Language: es\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+`
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "File"
+msgid_plural "Files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	// The translated entries follow the header without an empty line
+	poContent := header + `msgid "Hello"
+msgstr ""
+
+msgid "File"
+msgid_plural "Files"
+msgstr[0] ""
+msgstr[1] ""
+`
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	poFile := filepath.Join(tempDir, "test_es.po")
+	if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	translator := &fakeTranslator{}
+	result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+	if err != nil {
+		t.Fatalf("TranslatePoFile failed: %v", err)
+	}
+	if result.Translated != 2 {
+		t.Errorf("Expected 2 translated entries, got %d", result.Translated)
+	}
+	for _, text := range translator.texts {
+		if strings.Contains(text, "Project-Id-Version") {
+			t.Errorf("Header was sent for translation: %q", text)
+		}
+	}
+
+	updatedContent, err := os.ReadFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to read updated PO file: %v", err)
+	}
+	stamped := strings.Replace(header, "2024-01-02 13:00+0000", "2026-10-14 12:00+0000", 1)
+	if !strings.HasPrefix(string(updatedContent), stamped+"msgid \"Hello\"\nmsgstr \"es:Hello\"\n") {
+		t.Errorf("Header was not preserved:\n%s", updatedContent)
+	}
+}
+
+func TestStampHeader(t *testing.T) {
+	p := New(DefaultOptions())
+	p.LastTranslator = "Translation Bot <bot@example.com>"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Project-Id-Version: example 1.0\n"
+"PO-Revision-Date: 2020-01-01 00:00+0000\n"
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+
+		process := func() {
+			if rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			if err != nil {
+				t.Fatalf("Processing failed (rewrite=%v): %v", rewrite, err)
+			}
+		}
+
+		before := time.Now().Add(-time.Minute)
+		process()
+
+		updatedContent, err := os.ReadFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to read updated PO file: %v", err)
+		}
+		updatedStr := string(updatedContent)
+
+		match := regexp.MustCompile(`"PO-Revision-Date: ([^\\]+)\\n"`).FindStringSubmatch(updatedStr)
+		if match == nil {
+			t.Fatalf("PO-Revision-Date missing (rewrite=%v):\n%s", rewrite, updatedStr)
+		}
+		date, err := time.Parse("2006-01-02 15:04-0700", match[1])
+		if err != nil || date.Before(before) {
+			t.Errorf("PO-Revision-Date did not advance (rewrite=%v): %q", rewrite, match[1])
+		}
+		if strings.Count(updatedStr, "PO-Revision-Date") != 1 {
+			t.Errorf("Expected a single PO-Revision-Date (rewrite=%v):\n%s", rewrite, updatedStr)
+		}
+		if !strings.Contains(updatedStr, `"Last-Translator: Translation Bot <bot@example.com>\n"`) {
+			t.Errorf("Last-Translator not set (rewrite=%v):\n%s", rewrite, updatedStr)
+		}
+
+		// Unchanged files keep their header
+		unchanged := []byte(strings.ReplaceAll(updatedStr, match[1], "2020-01-01 00:00+0000"))
+		if err := os.WriteFile(poFile, unchanged, 0644); err != nil {
+			t.Fatalf("Failed to reset PO file: %v", err)
+		}
+		process()
+		if content, _ := os.ReadFile(poFile); string(content) != string(unchanged) {
+			t.Errorf("Unchanged file was modified (rewrite=%v):\n%s", rewrite, content)
+		}
+	}
+}
+
+func TestStampHeaderWithoutTranslator(t *testing.T) {
+	p := New(DefaultOptions())
+	p.LastTranslator = ""
+	previousNow := timeNow
+	timeNow = func() time.Time { return stampTime }
+	defer func() { timeNow = previousNow }()
+
+	lines := strings.Split(`msgid ""
+msgstr ""
+"PO-Revision-Date: 2020-01-01 00:00+0000\n"
+"Last-Translator: Jane Doe <jane@example.com>\n"
+"Language: es\n"`, "\n")
+	expected := strings.Replace(strings.Join(lines, "\n"), "2020-01-01 00:00+0000", "2026-10-14 12:00+0000", 1)
+	if stamped := strings.Join(p.stampHeader(lines), "\n"); stamped != expected {
+		t.Errorf("Expected only the PO-Revision-Date to change, got:\n%s\nwant:\n%s", stamped, expected)
+	}
+}
+
+// stampTime is the time stamped into the headers by the tests comparing whole
+// files.
+var stampTime = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+func TestPreviousMsgid(t *testing.T) {
+	p := New(DefaultOptions())
+	p.LastTranslator = ""
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#: main.go:10
+#, c-format
+msgid "Open the file"
+msgstr ""
+
+msgid "Close"
+msgstr ""
+`
+	// The #| lines come before the flags, gettext puts them right before msgid
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+#: main.go:10
+#| msgid "Open a file"
+#, c-format, fuzzy
+msgid "Open the file"
+msgstr "Abrir un archivo"
+
+msgid "Close"
+msgstr ""
+`
+
+	tests := []struct {
+		name     string
+		rewrite  bool
+		clear    bool
+		expected string
+	}{
+		{"translate", false, false, "#: main.go:10\n#, c-format\n#| msgid \"Open a file\"\nmsgid \"Open the file\"\nmsgstr \"es:Open the file\""},
+		{"translate and clear", false, true, "#: main.go:10\n#, c-format\nmsgid \"Open the file\"\nmsgstr \"es:Open the file\""},
+		{"rewrite", true, false, "#: main.go:10\n#, c-format\n#| msgid \"Open a file\"\nmsgid \"Open the file\"\nmsgstr \"es:Open the file\""},
+		{"rewrite and clear", true, true, "#: main.go:10\n#, c-format\nmsgid \"Open the file\"\nmsgstr \"es:Open the file\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.ClearPrevious = tt.clear
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			entries, _, err := p.ParsePotFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to parse PO file: %v", err)
+			}
+			if entry := entries["Open the file"]; entry.PrevMsgid != "Open a file" || len(entry.Comments.lines()) != 1 {
+				t.Errorf("Expected the previous msgid apart from the comments, got %+v", entry)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+			if tt.rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			if err != nil {
+				t.Fatalf("Processing failed: %v", err)
+			}
+
+			updatedContent, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read updated PO file: %v", err)
+			}
+			if !strings.Contains(string(updatedContent), tt.expected) {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, updatedContent)
+			}
+		})
+	}
+}
+
+func TestCommentOrder(t *testing.T) {
+	p := New(DefaultOptions())
+
+	// The POT comments are out of order, and the flags come first
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#, c-format
+#: main.go:10
+#. Shown in the title bar
+msgid "Open %s"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+# Checked by the Spanish team
+#. Shown in the title bar
+#: main.go:12
+#, fuzzy, c-format
+#| msgid "Open a %s"
+msgid "Open %s"
+msgstr "Abrir un %s"
+`
+	entry := `#. Shown in the title bar
+#: main.go:10
+#, c-format
+msgid "Open %s"
+msgstr "es:Open %s"`
+	previous := "#| msgid \"Open a %s\"\n"
+
+	tests := []struct {
+		name     string
+		po       string
+		rewrite  bool
+		keep     bool
+		expected string
+	}{
+		{"added", "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n", false, false, entry},
+		{"rewrite", poContent, true, false, strings.Replace(entry, "msgid", previous+"msgid", 1)},
+		{"rewrite keeping translator comments", poContent, true, true, "# Checked by the Spanish team\n" + strings.Replace(entry, "msgid", previous+"msgid", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.KeepTranslatorComments = tt.keep
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(poFile, []byte(tt.po), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+			comments := potEntries["Open %s"].Comments
+			if len(comments.Extracted) != 1 || len(comments.References) != 1 || len(comments.Translator) != 0 {
+				t.Fatalf("Expected the comments by type, got %+v", comments)
+			}
+
+			if tt.rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			if err != nil {
+				t.Fatalf("Processing failed: %v", err)
+			}
+			content, err := os.ReadFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to read PO file: %v", err)
+			}
+			if !strings.Contains(string(content), "\n\n"+tt.expected) {
+				t.Errorf("Expected entry:\n%s\ngot:\n%s", tt.expected, content)
+			}
+		})
+	}
+
+	// A fully commented entry in gettext order round-trips unchanged
+	tempDir := t.TempDir()
+	poFile := filepath.Join(tempDir, "test_es.po")
+	translated := strings.Replace(poContent, "#, fuzzy, c-format", "#, c-format", 1)
+	if err := os.WriteFile(poFile, []byte(translated), 0644); err != nil {
+		t.Fatalf("Failed to create PO file: %v", err)
+	}
+	entries, _, err := p.ParsePotFile(poFile)
+	if err != nil {
+		t.Fatalf("Failed to parse PO file: %v", err)
+	}
+	e := entries["Open %s"]
+	lines := p.formatEntryComments(e.Comments, e.Flags, e.PrevMsgid)
+	expected := "# Checked by the Spanish team\n#. Shown in the title bar\n#: main.go:12\n#, c-format\n#| msgid \"Open a %s\""
+	if strings.Join(lines, "\n") != expected {
+		t.Errorf("Expected comments:\n%s\ngot:\n%s", expected, strings.Join(lines, "\n"))
+	}
+}
+
+func TestMissingMsgstr(t *testing.T) {
+	p := New(DefaultOptions())
+	previousStderr := os.Stderr
+	defer func() { os.Stderr = previousStderr }()
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Dangling"
+msgstr ""
+
+#: src/app.go:2
+#, c-format
+msgid "Next %s"
+msgstr ""
+`
+	// The msgstr of Dangling is missing, right before the next entry
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Dangling"
+#: src/app.go:2
+#, c-format
+msgid "Next %s"
+msgstr "Siguiente %s"
+`
+
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		stderr, err := os.Create(filepath.Join(tempDir, "stderr"))
+		if err != nil {
+			t.Fatalf("Failed to create stderr file: %v", err)
+		}
+		defer stderr.Close()
+		os.Stderr = stderr
+
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+		if result.Translated != 1 || result.Added != 0 {
+			t.Errorf("rewrite=%v: expected only Dangling to be translated, got %+v", rewrite, result)
+		}
+
+		content, _ := os.ReadFile(poFile)
+		for _, want := range []string{
+			"msgid \"Dangling\"\nmsgstr \"es:Dangling\"\n",
+			"#: src/app.go:2\n#, c-format\nmsgid \"Next %s\"\nmsgstr \"Siguiente %s\"",
+		} {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("rewrite=%v: expected %q in PO file:\n%s", rewrite, want, content)
+			}
+		}
+
+		written, _ := os.ReadFile(stderr.Name())
+		if !strings.Contains(string(written), "test_es.po:5: msgid without msgstr") {
+			t.Errorf("rewrite=%v: expected a warning for line 5, got %q", rewrite, written)
+		}
+	}
+}
+
+func TestMultilineGolden(t *testing.T) {
+	p := New(DefaultOptions())
+	input, err := os.ReadFile(filepath.Join("testdata", "multiline_input.po"))
+	if err != nil {
+		t.Fatalf("Failed to read input: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join("testdata", "multiline_expected.po"))
+	if err != nil {
+		t.Fatalf("Failed to read expected output: %v", err)
+	}
+
+	// The header only gets the PO-Revision-Date
+	p.LastTranslator = ""
+	previousNow := timeNow
+	timeNow = func() time.Time { return stampTime }
+	defer func() { timeNow = previousNow }()
+
+	// Strings split where there is no newline keep their lines, only the
+	// translated entry is written
+	for _, rewrite := range []bool{false, true} {
+		tempDir := t.TempDir()
+		potFile := filepath.Join(tempDir, "test.pot")
+		poFile := filepath.Join(tempDir, "test_es.po")
+		if err := os.WriteFile(potFile, input, 0644); err != nil {
+			t.Fatalf("Failed to create POT file: %v", err)
+		}
+		if err := os.WriteFile(poFile, input, 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		potEntries, _, err := p.ParsePotFile(potFile)
+		if err != nil {
+			t.Fatalf("Failed to parse POT file: %v", err)
+		}
+		var result TranslationResult
+		if rewrite {
+			result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+		}
+		if err != nil {
+			t.Fatalf("rewrite=%v: unexpected error: %v", rewrite, err)
+		}
+		if result.Translated != 1 {
+			t.Errorf("rewrite=%v: expected 1 translated entry, got %+v", rewrite, result)
+		}
+		if written, _ := os.ReadFile(poFile); string(written) != string(expected) {
+			t.Errorf("rewrite=%v: mismatch\ngot:\n%s\nwant:\n%s", rewrite, written, expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Processor processes catalogs with the options it was created with. It also
+// holds the state of a run: the translation cache, the --merge-from and
+// --import translations, the --limit count, the request counts and whether
+// the run was interrupted. The options shouldn't be changed while catalogs
+// are being processed.
+type Processor struct {
+	Options
+
+	ctx         context.Context // Canceled when the run is interrupted
+	cancel      context.CancelFunc
+	interrupted atomic.Bool
+
+	cache    *translationCache
+	memory   map[string]POEntry // Translations of the --merge-from file
+	imported map[string]string  // Translations of the --import file
+
+	// limitUsed counts the successful translations against --limit, for the
+	// whole run or, with --limit-per-file, for the current PO file. limitHit
+	// is set once an entry was left untranslated because of --limit.
+	limitUsed atomic.Int64
+	limitHit  atomic.Bool
+
+	// stats holds the counts of the whole run. The counts of a file are the
+	// difference between before and after translating its entries.
+	statsMu sync.Mutex
+	stats   RequestStats
+
+	languages        languageLists // Languages of the --backend
+	languageWarnings sync.Map      // PO files already warned about their language
+	charsetWarnings  sync.Map      // Unknown charsets already warned about
+	progress         progressOutput
+}
+
+// New creates a Processor with the options. Without an Output the progress is
+// written to os.Stdout, and without a Sleeper the delays use time.Sleep.
+func New(options Options) *Processor {
+	if options.Output == nil {
+		options.Output = os.Stdout
+	}
+	if options.Sleeper == nil {
+		options.Sleeper = SleeperFunc(time.Sleep)
+	}
+	p := &Processor{Options: options}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+// LoadCache enables the translation cache stored in the JSON file, which is
+// written by SaveCache.
+func (p *Processor) LoadCache(path string) error {
+	loaded, err := loadCache(path)
+	if err != nil {
+		return err
+	}
+	p.cache = loaded
+	return nil
+}
+
+// LoadMemory reuses the translations of the PO file for matching
+// untranslated entries, for --merge-from.
+func (p *Processor) LoadMemory(path string) error {
+	loaded, err := p.loadMemory(path)
+	if err != nil {
+		return err
+	}
+	p.memory = loaded
+	return nil
+}
+
+// LoadImport fills the entries found in the CSV or JSON file, for --import,
+// and leaves the others untranslated instead of using the backend.
+func (p *Processor) LoadImport(path string) error {
+	loaded, err := loadImport(path)
+	if err != nil {
+		return err
+	}
+	p.imported = loaded
+	return nil
+}
+
+// Interrupt stops the translations, aborting the requests in flight. The
+// translations done so far are still written.
+func (p *Processor) Interrupt() {
+	p.interrupted.Store(true)
+	p.cancel()
+}
+
+// Interrupted reports whether Interrupt was called.
+func (p *Processor) Interrupted() bool {
+	return p.interrupted.Load()
+}
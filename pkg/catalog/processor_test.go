@@ -0,0 +1,34 @@
+package catalog
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNewDefaults(t *testing.T) {
+	p := New(Options{})
+	if p.Output != os.Stdout {
+		t.Errorf("Expected the output to default to os.Stdout, got %v", p.Output)
+	}
+	if p.Sleeper == nil {
+		t.Error("Expected a default sleeper")
+	}
+}
+
+func TestProcessorsSeparateState(t *testing.T) {
+	first, second := New(DefaultOptions()), New(DefaultOptions())
+	first.Limit = 1
+
+	first.Interrupt()
+	if !first.Interrupted() || first.ctx.Err() == nil {
+		t.Error("Expected the first processor to be interrupted")
+	}
+	if second.Interrupted() || second.ctx.Err() != nil {
+		t.Error("Expected the second processor not to be interrupted")
+	}
+
+	first.limitHit.Store(true)
+	if second.LimitHit() || second.Limit != 0 {
+		t.Error("Expected the limit of the first processor not to apply to the second")
+	}
+}
@@ -0,0 +1,188 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// plainProgressSteps is the number of progress lines written per file by the
+// plain progress output, besides the final one.
+const plainProgressSteps = 10
+
+// progressReporter shows the progress of translating the entries of a file.
+// Add is called concurrently by the translation workers.
+type progressReporter interface {
+	Add(n int)
+	Finish()
+}
+
+// ValidProgressMode reports whether mode is a known --progress mode.
+func ValidProgressMode(mode string) bool {
+	switch mode {
+	case "auto", "bar", "plain", "none":
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether the writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// progressOutput serializes the progress output of files translated at the
+// same time. active counts the files being translated and bar is the progress
+// bar shown, if any.
+type progressOutput struct {
+	mu     sync.Mutex
+	active int
+	bar    *barProgress
+}
+
+// newProgress creates the --progress reporter for total entries, writing to
+// the human readable output. In auto mode the animated bar is only used on a
+// terminal, redirected output gets plain lines instead. The bar can only show
+// a single file, so while several files are translated at the same time they
+// all get plain lines.
+func (p *Processor) newProgress(name string, total int) progressReporter {
+	tty := isTerminal(p.Output)
+	mode := p.Progress
+	if mode == "auto" {
+		mode = "plain"
+		if tty {
+			mode = "bar"
+		}
+	}
+	if mode == "none" {
+		return noProgress{}
+	}
+
+	out := &p.progress
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	out.active++
+	plain := &plainProgress{out: out, w: p.Output, name: name, total: total, step: max(total/plainProgressSteps, 1)}
+	if out.bar != nil {
+		out.bar.switchToPlain()
+	}
+	if mode == "plain" || out.active > 1 {
+		return plain
+	}
+	out.bar = &barProgress{bar: newProgressBar(p.Output, name, total, tty), plain: plain}
+	return out.bar
+}
+
+// newProgressBar creates the animated progress bar writing to w, with colors
+// only on a terminal.
+func newProgressBar(w io.Writer, name string, total int, colors bool) *progressbar.ProgressBar {
+	description, theme := name, progressbar.Theme{
+		Saucer:        "=",
+		SaucerHead:    ">",
+		SaucerPadding: " ",
+		BarStart:      "[",
+		BarEnd:        "]",
+	}
+	if colors {
+		description = fmt.Sprintf("[cyan]%s[reset]", name)
+		theme.Saucer, theme.SaucerHead = "[green]=[reset]", "[green]>[reset]"
+	}
+	return progressbar.NewOptions(total,
+		progressbar.OptionEnableColorCodes(colors),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionSetWriter(w),
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetTheme(theme))
+}
+
+// barProgress shows the animated progress bar, until another file starts
+// being translated. From then on it continues with plain lines.
+type barProgress struct {
+	bar      *progressbar.ProgressBar
+	plain    *plainProgress // Counts along, to take over from the bar
+	switched bool
+}
+
+func (p *barProgress) Add(n int) {
+	p.plain.out.mu.Lock()
+	defer p.plain.out.mu.Unlock()
+	if p.switched {
+		p.plain.add(n)
+		return
+	}
+	p.plain.done += n
+	p.bar.Add(n)
+}
+
+func (p *barProgress) Finish() {
+	p.plain.out.mu.Lock()
+	defer p.plain.out.mu.Unlock()
+	p.plain.out.active--
+	if p.switched {
+		p.plain.finish()
+		return
+	}
+	p.plain.out.bar = nil
+	fmt.Fprintln(p.plain.w) // New line after progress bar
+}
+
+// switchToPlain ends the line of the progress bar and continues with plain
+// lines, with the lock of the progress output held.
+func (p *barProgress) switchToPlain() {
+	p.switched = true
+	p.plain.out.bar = nil
+	fmt.Fprintln(p.plain.w)
+}
+
+// plainProgress writes a line like "messages_es.po: Translated 10/50" every
+// step entries and once all entries are done, for logs and CI output.
+type plainProgress struct {
+	out   *progressOutput
+	w     io.Writer
+	name  string
+	total int
+	step  int
+	done  int
+}
+
+func (p *plainProgress) Add(n int) {
+	p.out.mu.Lock()
+	defer p.out.mu.Unlock()
+	p.add(n)
+}
+
+func (p *plainProgress) Finish() {
+	p.out.mu.Lock()
+	defer p.out.mu.Unlock()
+	p.out.active--
+	p.finish()
+}
+
+// add counts the entries and writes the line when a step is completed, with
+// the lock of the progress output held.
+func (p *plainProgress) add(n int) {
+	p.done += n
+	if p.done%p.step == 0 || p.done == p.total {
+		fmt.Fprintf(p.w, "%s: Translated %d/%d\n", p.name, p.done, p.total)
+	}
+}
+
+// finish writes the last count of interrupted runs, which end before the last
+// step, with the lock of the progress output held.
+func (p *plainProgress) finish() {
+	if p.done%p.step != 0 && p.done != p.total {
+		fmt.Fprintf(p.w, "%s: Translated %d/%d\n", p.name, p.done, p.total)
+	}
+}
+
+// noProgress shows nothing, for --progress none.
+type noProgress struct{}
+
+func (noProgress) Add(n int) {}
+func (noProgress) Finish()   {}
@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgressOutput(t *testing.T) {
+	p := New(DefaultOptions())
+
+	keys := []string{"One", "Two", "Three"}
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		// A buffer isn't a terminal, so auto falls back to plain lines
+		{"auto", "test_es.po: Translated 1/3\ntest_es.po: Translated 2/3\ntest_es.po: Translated 3/3\n"},
+		{"plain", "test_es.po: Translated 1/3\ntest_es.po: Translated 2/3\ntest_es.po: Translated 3/3\n"},
+		{"none", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			p.Output, p.Progress = &buf, tt.mode
+			result := p.translateEntries("test_es.po", keys, nil, nil, nil, 2, "en", "es", 0, &fakeTranslator{}, nil)
+			if result.count != 3 {
+				t.Fatalf("Expected 3 translations, got %d", result.count)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+
+	// The bar without a terminal has no color codes
+	var buf bytes.Buffer
+	p.Output, p.Progress = &buf, "bar"
+	p.translateEntries("test_es.po", keys, nil, nil, nil, 2, "en", "es", 0, &fakeTranslator{}, nil)
+	if strings.Contains(buf.String(), "\x1b") || !strings.Contains(buf.String(), "3/3") {
+		t.Errorf("Expected an uncolored progress bar, got %q", buf.String())
+	}
+
+	// Large files only get a line every tenth of the entries
+	buf.Reset()
+	p.Output, p.Progress = &buf, "plain"
+	progress := p.newProgress("big_es.po", 50)
+	for range 50 {
+		progress.Add(1)
+	}
+	progress.Finish()
+	if lines := strings.Count(buf.String(), "\n"); lines != 10 {
+		t.Errorf("Expected 10 lines, got %d:\n%s", lines, buf.String())
+	}
+	if !strings.HasSuffix(buf.String(), "big_es.po: Translated 50/50\n") {
+		t.Errorf("Expected the final count last, got:\n%s", buf.String())
+	}
+}
+
+func TestConcurrentProgress(t *testing.T) {
+	p := New(DefaultOptions())
+
+	// Two files translated at the same time each report their own total
+	keys := []string{"One", "Two", "Three", "Four", "Five"}
+	for _, mode := range []string{"plain", "bar"} {
+		t.Run(mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			p.Output, p.Progress = &buf, mode
+			var wg sync.WaitGroup
+			for _, name := range []string{"first_es.po", "second_es.po"} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result := p.translateEntries(name, keys, nil, nil, nil, 3, "en", "es", 0, &fakeTranslator{}, nil)
+					if result.count != len(keys) {
+						t.Errorf("Expected %d translations for %s, got %d", len(keys), name, result.count)
+					}
+				}()
+			}
+			wg.Wait()
+			if p.progress.active != 0 || p.progress.bar != nil {
+				t.Errorf("Expected no active progress, got %d", p.progress.active)
+			}
+			if mode != "plain" {
+				return
+			}
+			for _, line := range []string{"first_es.po: Translated 5/5\n", "second_es.po: Translated 5/5\n"} {
+				if strings.Count(buf.String(), line) != 1 {
+					t.Errorf("Expected %q once in:\n%s", line, buf.String())
+				}
+			}
+		})
+	}
+
+	// Once a second file starts, the bar of the first continues with lines
+	var buf bytes.Buffer
+	p.Output, p.Progress = &buf, "bar"
+	first := p.newProgress("first_es.po", 3)
+	first.Add(1)
+	second := p.newProgress("second_es.po", 2)
+	var wg sync.WaitGroup
+	for _, progress := range []progressReporter{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2 {
+				progress.Add(1)
+			}
+			progress.Finish()
+		}()
+	}
+	wg.Wait()
+	for _, line := range []string{"\nfirst_es.po: Translated 2/3\n", "first_es.po: Translated 3/3\n", "second_es.po: Translated 1/2\n", "second_es.po: Translated 2/2\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, buf.String())
+		}
+	}
+	if strings.Count(buf.String(), "Translated") != 4 {
+		t.Errorf("Expected 4 progress lines, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// pseudoTransforms are the transforms of --pseudo, applied in this order.
+var pseudoTransforms = []string{"double", "accent", "bracket"}
+
+// pseudoAccents maps letters to an accented look-alike.
+var pseudoAccents = map[rune]rune{
+	'a': 'å', 'c': 'ç', 'e': 'é', 'i': 'î', 'n': 'ñ', 'o': 'ö', 's': 'š', 'u': 'ü', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'C': 'Ç', 'E': 'É', 'I': 'Î', 'N': 'Ñ', 'O': 'Ö', 'S': 'Š', 'U': 'Ü', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// pseudoKept matches the parts of a text the transforms leave alone: the
+// tokens of protected placeholders, markup tags and entities, and the
+// placeholders of entries without a format flag, which aren't protected.
+var pseudoKept = regexp.MustCompile(`__PH\d+__|<[^<>]*>|&#?\w+;|` + placeholderPatterns["c"].String() + `|` + placeholderPatterns["python"].String())
+
+// pseudoTranslator fills the translations with a transformed copy of the
+// source text for --pseudo, to test layouts without a backend.
+type pseudoTranslator struct {
+	transforms []string
+}
+
+// NewPseudoTranslator returns the translator of --pseudo, with a comma
+// separated list of the transforms "double" (doubling the vowels to make the
+// text longer), "accent" (replacing letters by accented ones) and "bracket"
+// (wrapping the text in brackets, to spot cut off strings).
+func NewPseudoTranslator(transforms string) (Translator, error) {
+	var selected []string
+	for _, transform := range strings.Split(transforms, ",") {
+		transform = strings.TrimSpace(transform)
+		if !slices.Contains(pseudoTransforms, transform) {
+			return nil, fmt.Errorf("unknown pseudo transform '%s' (use 'double', 'accent' or 'bracket')", transform)
+		}
+		selected = append(selected, transform)
+	}
+	return pseudoTranslator{transforms: selected}, nil
+}
+
+func (p pseudoTranslator) Translate(text, from, to string) (string, error) {
+	for _, transform := range pseudoTransforms {
+		if !slices.Contains(p.transforms, transform) {
+			continue
+		}
+		switch transform {
+		case "double":
+			text = transformLetters(text, doubleVowel)
+		case "accent":
+			text = transformLetters(text, accentLetter)
+		case "bracket":
+			text = "[" + text + "]"
+		}
+	}
+	return text, nil
+}
+
+// transformLetters applies the transform to every letter of the text, except
+// those of the placeholder tokens and the markup.
+func transformLetters(text string, transform func(rune) string) string {
+	var b strings.Builder
+	last := 0
+	apply := func(part string) {
+		for _, r := range part {
+			b.WriteString(transform(r))
+		}
+	}
+	for _, kept := range pseudoKept.FindAllStringIndex(text, -1) {
+		apply(text[last:kept[0]])
+		b.WriteString(text[kept[0]:kept[1]])
+		last = kept[1]
+	}
+	apply(text[last:])
+	return b.String()
+}
+
+// doubleVowel writes vowels twice.
+func doubleVowel(r rune) string {
+	if strings.ContainsRune("aeiouAEIOU", r) {
+		return string(r) + string(r)
+	}
+	return string(r)
+}
+
+// accentLetter replaces a letter by its accented look-alike.
+func accentLetter(r rune) string {
+	if accented, exists := pseudoAccents[r]; exists {
+		return string(accented)
+	}
+	return string(r)
+}
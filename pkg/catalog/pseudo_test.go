@@ -0,0 +1,48 @@
+package catalog
+
+import "testing"
+
+func TestPseudoTranslator(t *testing.T) {
+	p := New(DefaultOptions())
+	p.PlaceholderStyle = "c"
+
+	tests := []struct {
+		transforms string
+		text       string
+		want       string
+	}{
+		{"accent,bracket", "Save", "[Šåvé]"},
+		{"bracket,accent", "Save", "[Šåvé]"},
+		{"double", "Save", "Saavee"},
+		{"double,accent,bracket", "Save", "[Šååvéé]"},
+		{"accent", "Save %s", "Šåvé %s"},
+		{"accent,bracket", "%d files in %1$s", "[%d fîléš îñ %1$s]"},
+		{"accent", "<b>Save</b> &amp; close", "<b>Šåvé</b> &amp; çlöšé"},
+		{"bracket", "  Save\n", "  [Save]\n"},
+	}
+	for _, tt := range tests {
+		translator, err := NewPseudoTranslator(tt.transforms)
+		if err != nil {
+			t.Fatalf("NewPseudoTranslator(%q) error = %v", tt.transforms, err)
+		}
+		// The placeholders are protected like for the other backends
+		got, _, issue, err := p.translateString(translator, tt.text, "en", "es")
+		if err != nil || issue != "" {
+			t.Fatalf("translateString(%q) error = %v, issue = %q", tt.text, err, issue)
+		}
+		if got != tt.want {
+			t.Errorf("%s: translateString(%q) = %q, want %q", tt.transforms, tt.text, got, tt.want)
+		}
+	}
+
+	// Without a format flag the placeholders aren't protected, but still kept
+	p.PlaceholderStyle = "auto"
+	accent, _ := NewPseudoTranslator("accent")
+	if got, _, _, _ := p.translateString(accent, "Save %s as {name}", "en", "es"); got != "Šåvé %s åš {name}" {
+		t.Errorf("Expected the unprotected placeholders to be kept, got %q", got)
+	}
+
+	if _, err := NewPseudoTranslator("accent,mirror"); err == nil {
+		t.Error("Expected an error for an unknown transform")
+	}
+}
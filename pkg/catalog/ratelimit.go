@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows a request every interval, shared by all files and
+// --concurrency workers. Unlike the delay between translations it doesn't
+// reset between files, so many small files can't burst the backend.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // Earliest start of the next request
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second.
+func NewRateLimiter(rps float64) *RateLimiter {
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / rps),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Wait blocks until the next request may start, or until the context is done.
+// Concurrent callers each reserve their own turn.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		return l.sleep(ctx, wait)
+	}
+	return ctx.Err()
+}
+
+// sleepContext sleeps for the duration, returning early with the error of the
+// context when it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitRateLimit waits for the turn of the next backend request with --rps.
+// It fails when the run is interrupted while waiting.
+func (p *Processor) waitRateLimit() error {
+	if p.RateLimiter == nil {
+		return nil
+	}
+	return p.RateLimiter.Wait(p.ctx)
+}
@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for the rate limiter whose sleeps advance the time
+// instead of waiting.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+// newFakeRateLimiter returns a limiter allowing rps requests per second on
+// the clock.
+func newFakeRateLimiter(rps float64, clock *fakeClock) *RateLimiter {
+	limiter := NewRateLimiter(rps)
+	limiter.now, limiter.sleep = clock.Now, clock.Sleep
+	return limiter
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newFakeRateLimiter(5, clock)
+	start := clock.Now()
+
+	var offsets []time.Duration
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+		offsets = append(offsets, clock.Now().Sub(start))
+	}
+	for i, offset := range offsets {
+		if expected := time.Duration(i) * 200 * time.Millisecond; offset != expected {
+			t.Errorf("Request %d started at %v, want %v", i, offset, expected)
+		}
+	}
+
+	// An idle limiter doesn't save up requests for a burst
+	clock.Sleep(context.Background(), 5*time.Second)
+	idle := clock.Now()
+	limiter.Wait(context.Background())
+	limiter.Wait(context.Background())
+	if waited := clock.Now().Sub(idle); waited != 200*time.Millisecond {
+		t.Errorf("Expected the second request after 200ms, got %v", waited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected an error for a canceled context")
+	}
+}
+
+func TestRateLimiterAcrossFiles(t *testing.T) {
+	p := New(DefaultOptions())
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p.RateLimiter = newFakeRateLimiter(2, clock)
+
+	var calls []time.Time
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		calls = append(calls, clock.Now())
+		return to + ":" + text, nil
+	}}
+
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"One\"\nmsgstr \"\"\n\nmsgid \"Two\"\nmsgstr \"\"\n\nmsgid \"Three\"\nmsgstr \"\"\n"
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// Without a delay only the shared limiter spaces the requests
+	for _, lang := range []string{"de", "es", "fr"} {
+		poFile := filepath.Join(tempDir, "test_"+lang+".po")
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: "+lang+"\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		if _, err := p.TranslatePoFile(poFile, potEntries, "en", lang, 0, translator); err != nil {
+			t.Fatalf("TranslatePoFile() error = %v", err)
+		}
+	}
+
+	if len(calls) != 9 {
+		t.Fatalf("Expected 9 translations, got %d", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if spacing := calls[i].Sub(calls[i-1]); spacing != 500*time.Millisecond {
+			t.Errorf("Request %d started %v after the previous one, want 500ms", i, spacing)
+		}
+	}
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newFakeRateLimiter(10, clock)
+
+	// The clock stands still, so the waits show the reserved turns
+	var mu sync.Mutex
+	waits := make(map[time.Duration]bool)
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		waits[d] = true
+		mu.Unlock()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i := 1; i < 5; i++ {
+		if wait := time.Duration(i) * 100 * time.Millisecond; !waits[wait] {
+			t.Errorf("Expected a request to wait %v, got %v", wait, waits)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package catalog
+
+import (
+	"path"
+	"strings"
+)
+
+// filteredByRef reports whether --filter-ref leaves the POT entry out of the
+// translation, as none of its "#:" source references match the glob. A
+// reference matches with or without its line number, so both
+// "templates/email.html:*" and "templates/*.html" select
+// "templates/email.html:12". Entries without references are left out too.
+func (p *Processor) filteredByRef(entry POEntry) bool {
+	if p.FilterRef == "" {
+		return false
+	}
+	for _, line := range entry.Comments.References {
+		for _, reference := range strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "#:")) {
+			file := reference
+			if i := strings.LastIndex(reference, ":"); i >= 0 && isLineNumber(reference[i+1:]) {
+				file = reference[:i]
+			}
+			if matched, _ := path.Match(p.FilterRef, reference); matched {
+				return false
+			}
+			if matched, _ := path.Match(p.FilterRef, file); matched {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isLineNumber reports whether s is the line number of a source reference.
+func isLineNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
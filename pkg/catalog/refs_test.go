@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFilterRef(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#: templates/email.html:12
+msgid "Welcome"
+msgstr ""
+
+#: src/app.go:10 templates/email.html:40
+msgid "Sign in"
+msgstr ""
+
+#: templates/page.html:3
+msgid "Home"
+msgstr ""
+
+msgid "Unreferenced"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	tests := []struct {
+		glob     string
+		expected []string
+	}{
+		{"templates/email.html:*", []string{"Sign in", "Welcome"}},
+		{"templates/email.html", []string{"Sign in", "Welcome"}},
+		{"templates/*.html", []string{"Home", "Sign in", "Welcome"}},
+		{"src/app.go:10", []string{"Sign in"}},
+		{"docs/*", nil},
+	}
+
+	for _, tt := range tests {
+		for _, rewrite := range []bool{false, true} {
+			p.FilterRef = tt.glob
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			translator := &fakeTranslator{}
+			var result TranslationResult
+			if rewrite {
+				result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+			} else {
+				result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			}
+			if err != nil {
+				t.Fatalf("%s, rewrite=%v: unexpected error: %v", tt.glob, rewrite, err)
+			}
+
+			sort.Strings(translator.texts)
+			if strings.Join(translator.texts, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("%s, rewrite=%v: expected %q to be translated, got %q", tt.glob, rewrite, tt.expected, translator.texts)
+			}
+			// The other entries are still added, untranslated
+			if result.Added != 4 || result.Translated != len(tt.expected) || result.Skipped != 0 {
+				t.Errorf("%s, rewrite=%v: expected 4 added and %d translated, got %+v", tt.glob, rewrite, len(tt.expected), result)
+			}
+		}
+	}
+}
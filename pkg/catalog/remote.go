@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IsURL reports whether the POT file given with --pot is an http or https
+// URL, which has to be downloaded first.
+func IsURL(potFile string) bool {
+	return strings.HasPrefix(potFile, "http://") || strings.HasPrefix(potFile, "https://")
+}
+
+// DownloadPot downloads the POT file at the URL into the directory and
+// returns the path of the copy. It keeps the file name of the URL, so that
+// the domain can be taken from it and a .pot.gz file is still decompressed.
+// The headers, like "Authorization: Bearer ...", are sent with the request,
+// which fails after the timeout unless that is 0.
+func DownloadPot(rawURL, directory string, headers []string, timeout time.Duration) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, header := range headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return "", fmt.Errorf("invalid header '%s', use 'Name: value'", header)
+		}
+		request.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", rawURL, response.Status)
+	}
+
+	name := path.Base(parsed.Path)
+	if !strings.HasSuffix(catalogName(name), ".pot") {
+		name = "template.pot"
+	}
+	potFile := filepath.Join(directory, name)
+	file, err := os.Create(potFile)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, response.Body); err != nil {
+		file.Close()
+		return "", fmt.Errorf("downloading %s: %v", rawURL, err)
+	}
+	return potFile, file.Close()
+}
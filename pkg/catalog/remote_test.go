@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadPot(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(potContent))
+	}))
+	defer server.Close()
+
+	if !IsURL(server.URL) || IsURL("locales/messages.pot") {
+		t.Error("Expected only the server address to be a URL")
+	}
+
+	dir := t.TempDir()
+	potFile, err := DownloadPot(server.URL+"/templates/messages.pot", dir, []string{"Authorization: Bearer secret"}, 0)
+	if err != nil {
+		t.Fatalf("DownloadPot() error = %v", err)
+	}
+	// The file name is kept, for the domain of a single PO file
+	if potFile != filepath.Join(dir, "messages.pot") {
+		t.Errorf("Expected the copy to be named messages.pot, got %s", potFile)
+	}
+	entries, language, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("ParsePotFile() error = %v", err)
+	}
+	if len(entries) != 2 || language != "en" {
+		t.Errorf("Expected 2 entries in en, got %d in '%s'", len(entries), language)
+	}
+
+	if _, err := DownloadPot(server.URL+"/messages.pot", t.TempDir(), nil, 0); err == nil {
+		t.Error("Expected an error without the authorization header")
+	}
+	if potFile, err := DownloadPot(server.URL+"/export?domain=messages", t.TempDir(), []string{"Authorization: Bearer secret"}, 0); err != nil || filepath.Base(potFile) != "template.pot" {
+		t.Errorf("Expected a URL without a POT name to be saved as template.pot, got %s, %v", potFile, err)
+	}
+}
@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkPoStrings checks the quoted strings of the lines of a PO file, before
+// anything is written based on them. With --repair the obvious mistakes of
+// hand-edited files are fixed: quotes inside a string that aren't escaped and
+// a missing closing quote. It returns the lines, with the number of repaired
+// ones, or the error of the first string that is broken, naming its entry.
+func (p *Processor) checkPoStrings(poFile string, lines []string) ([]string, int, error) {
+	var checked []string
+	repaired := 0
+	msgid := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "msgid ") {
+			msgid = trimmed
+		}
+		err := checkString(trimmed)
+		if err == nil {
+			continue
+		}
+		if fixed, ok := repairString(line); p.Repair && ok {
+			if checked == nil {
+				checked = append([]string(nil), lines...)
+			}
+			checked[i] = fixed
+			repaired++
+			fmt.Fprintf(os.Stderr, "Warning: %s:%d: repaired the quotes of the string, now %s\n", filepath.Base(poFile), i+1, strings.TrimSpace(fixed))
+			continue
+		}
+
+		entry := ""
+		if msgid != "" && msgid != trimmed {
+			entry = fmt.Sprintf(" in the entry of %s", msgid)
+		}
+		hint := ""
+		if !p.Repair {
+			hint = ", use --repair to fix the quotes"
+		}
+		return nil, 0, fmt.Errorf("%s:%d: %v%s%s", filepath.Base(poFile), i+1, err, entry, hint)
+	}
+	if checked == nil {
+		return lines, 0, nil
+	}
+	return checked, repaired, nil
+}
+
+// repairString fixes the quoted string of a keyword or continuation line by
+// escaping the quotes inside it and adding the closing quote when missing.
+// It reports false when the line doesn't start a string, like a keyword
+// without any quote, so there is nothing obvious to repair.
+func repairString(line string) (string, bool) {
+	start := strings.Index(line, "\"")
+	if start < 0 {
+		return "", false
+	}
+	prefix, value := line[:start], strings.TrimRightFunc(line[start+1:], func(r rune) bool {
+		return r == ' ' || r == '\t'
+	})
+	if keyword := strings.TrimSpace(prefix); keyword != "" && !isStringKeyword(keyword) {
+		return "", false
+	}
+
+	// The last quote closes the string, unless it is escaped
+	if strings.HasSuffix(value, "\"") && !escapedAt(value, len(value)-1) {
+		value = value[:len(value)-1]
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' && !escapedAt(value, i) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(value[i])
+	}
+
+	fixed := prefix + "\"" + b.String() + "\""
+	if checkString(strings.TrimSpace(fixed)) != nil {
+		return "", false
+	}
+	return fixed, true
+}
+
+// isStringKeyword reports whether the keyword is followed by a quoted string.
+func isStringKeyword(keyword string) bool {
+	switch keyword {
+	case "msgctxt", "msgid", "msgid_plural", "msgstr":
+		return true
+	}
+	return strings.HasPrefix(keyword, "msgstr[")
+}
+
+// escapedAt reports whether the character at i is escaped by an odd number of
+// backslashes before it.
+func escapedAt(s string, i int) bool {
+	backslashes := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepairQuotes(t *testing.T) {
+	p := New(DefaultOptions())
+
+	tests := []struct {
+		line     string
+		expected string // Empty when it can't be repaired
+	}{
+		{`msgstr "Dijo "hola""`, `msgstr "Dijo \"hola\""`},
+		{`msgstr "Sin cerrar`, `msgstr "Sin cerrar"`},
+		{`"Dijo "hola" a todos"`, `"Dijo \"hola\" a todos"`},
+		{`"C:\ruta\"`, `"C:\ruta\""`},
+		{`msgstr[1] "%d "archivos"" `, `msgstr[1] "%d \"archivos\""`},
+		{`msgstr Hola`, ""},
+	}
+	for _, tt := range tests {
+		fixed, ok := repairString(tt.line)
+		if ok != (tt.expected != "") || fixed != tt.expected {
+			t.Errorf("repairString(%q) = %q, %v, want %q", tt.line, fixed, ok, tt.expected)
+		}
+	}
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "He said hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "He said hello"
+msgstr "Dijo "hola""
+
+msgid "World"
+msgstr ""
+`
+	dir := t.TempDir()
+	potFile := filepath.Join(dir, "test.pot")
+	poFile := filepath.Join(dir, "test_es.po")
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	for _, rewrite := range []bool{false, true} {
+		if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		process := func() error {
+			var err error
+			if rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			return err
+		}
+
+		// Without --repair the file is left alone, naming the entry
+		p.Repair = false
+		err := process()
+		if err == nil || !strings.Contains(err.Error(), `test_es.po:6: unexpected text after the end of the string in the entry of msgid "He said hello"`) {
+			t.Errorf("rewrite=%v: expected the broken string to be reported, got %v", rewrite, err)
+		}
+		if content, _ := os.ReadFile(poFile); string(content) != poContent {
+			t.Errorf("rewrite=%v: expected the PO file to be unchanged, got:\n%s", rewrite, content)
+		}
+
+		p.Repair = true
+		if err := process(); err != nil {
+			t.Fatalf("rewrite=%v: repairing failed: %v", rewrite, err)
+		}
+		content, _ := os.ReadFile(poFile)
+		if !strings.Contains(string(content), `msgstr "Dijo \"hola\""`) || !strings.Contains(string(content), `msgstr "es:World"`) {
+			t.Errorf("rewrite=%v: expected the repaired and translated file, got:\n%s", rewrite, content)
+		}
+		if _, _, err := p.ParsePotFile(poFile); err != nil {
+			t.Errorf("rewrite=%v: expected a valid PO file, got %v", rewrite, err)
+		}
+	}
+}
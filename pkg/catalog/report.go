@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Report is the machine-readable summary written by --report json. Its field
+// names are part of the command line interface and must remain stable.
+type Report struct {
+	Domain         string       `json:"domain"`
+	SourceLanguage string       `json:"source_language"`
+	Interrupted    bool         `json:"interrupted"`
+	Files          []FileReport `json:"files"`
+}
+
+// FileReport holds the entry counts of a single PO file after processing.
+type FileReport struct {
+	File              string `json:"file"`               // Path relative to the processed directory
+	Domain            string `json:"domain"`             // Domain of the POT file
+	Language          string `json:"language"`           // Target language of the PO file
+	Total             int    `json:"total"`              // Entries in the POT file
+	Translated        int    `json:"translated"`         // Entries translated during this run
+	AlreadyTranslated int    `json:"already_translated"` // Entries that had a translation before this run
+	Missing           int    `json:"missing"`            // Entries still without a translation
+	Removed           int    `json:"removed"`            // Obsolete entries removed (rewrite mode)
+}
+
+// summarizePoFile counts the entries of a processed PO file. The previous
+// entries are those of the PO file before processing, and are used to count
+// the removed entries.
+func (p *Processor) summarizePoFile(poFile string, potEntries, previous map[string]POEntry, translated int) (FileReport, error) {
+	current, _, err := p.ParsePotFile(poFile)
+	if err != nil {
+		return FileReport{}, err
+	}
+
+	report := FileReport{Total: len(potEntries), Translated: translated}
+	for key := range potEntries {
+		entry, exists := current[key]
+		if !exists || !isTranslated(entry) {
+			report.Missing++
+		}
+	}
+	for key := range previous {
+		if _, exists := current[key]; !exists {
+			report.Removed++
+		}
+	}
+	report.AlreadyTranslated = report.Total - report.Translated - report.Missing
+	return report, nil
+}
+
+// isTranslated reports whether an entry has a translation, requiring all
+// forms of a plural entry to be filled.
+func isTranslated(entry POEntry) bool {
+	if entry.MsgidPlural == "" {
+		return entry.Msgstr != ""
+	}
+	if len(entry.Msgstrs) == 0 {
+		return false
+	}
+	for _, form := range entry.Msgstrs {
+		if form == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteReport writes the report as indented JSON.
+func WriteReport(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
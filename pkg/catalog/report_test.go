@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONReport(t *testing.T) {
+	p := New(DefaultOptions())
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	files := map[string]string{
+		"default.pot": potContent,
+		"default_es.po": `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+msgid "Obsolete"
+msgstr "Obsoleto"
+`,
+		"default_fr.po": `msgid ""
+msgstr ""
+"Language: fr\n"
+
+msgid "Hello"
+msgstr "Bonjour"
+`,
+	}
+
+	tempDir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	potEntries, sourceLang, err := p.ParsePotFile(filepath.Join(tempDir, "default.pot"))
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	// The backend fails on one string, leaving it missing
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		if text == "World" {
+			return "", errors.New("backend unavailable")
+		}
+		return to + ":" + text, nil
+	}}
+
+	report := &Report{Domain: "default", SourceLanguage: sourceLang}
+	for _, lang := range []string{"es", "fr"} {
+		poFile := filepath.Join(tempDir, "default_"+lang+".po")
+		previous, _, err := p.ParsePotFile(poFile)
+		if err != nil {
+			t.Fatalf("Failed to parse PO file: %v", err)
+		}
+
+		var result TranslationResult
+		if lang == "es" {
+			result, err = p.RewritePoFile(poFile, potEntries, sourceLang, lang, 0, translator)
+		} else {
+			result, err = p.TranslatePoFile(poFile, potEntries, sourceLang, lang, 0, translator)
+		}
+		if err != nil {
+			t.Fatalf("Processing %s failed: %v", lang, err)
+		}
+		p.addFileReport(report, tempDir, poFile, "default", lang, potEntries, previous, result.Translated)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, report); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal report: %v\n%s", err, buf.String())
+	}
+
+	if decoded.Domain != "default" || decoded.SourceLanguage != "en" {
+		t.Errorf("Unexpected report header: %+v", decoded)
+	}
+
+	want := []FileReport{
+		{File: "default_es.po", Domain: "default", Language: "es", Total: 3, Translated: 1, AlreadyTranslated: 1, Missing: 1, Removed: 1},
+		{File: "default_fr.po", Domain: "default", Language: "fr", Total: 3, Translated: 1, AlreadyTranslated: 1, Missing: 1, Removed: 0},
+	}
+	if len(decoded.Files) != len(want) {
+		t.Fatalf("Expected %d file reports, got %d", len(want), len(decoded.Files))
+	}
+	for i, fileReport := range decoded.Files {
+		if fileReport != want[i] {
+			t.Errorf("File report %d = %+v, want %+v", i, fileReport, want[i])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package catalog
+
+import "strings"
+
+// manualFlag marks a human translation that --force-retranslate never
+// overwrites.
+const manualFlag = "manual"
+
+// forceRetranslation reports whether --force-retranslate applies to an
+// already translated entry with the given flags and comment lines. With
+// --retranslate-only it is limited to entries with that flag or a comment
+// containing the text.
+func (p *Processor) forceRetranslation(flags []string, comments []string) bool {
+	if !p.ForceRetranslate || hasFlag(flags, manualFlag) {
+		return false
+	}
+	if p.RetranslateOnly == "" || hasFlag(flags, p.RetranslateOnly) {
+		return true
+	}
+	for _, comment := range comments {
+		trimmed := strings.TrimSpace(comment)
+		if strings.HasPrefix(trimmed, "#,") || strings.HasPrefix(trimmed, "#|") {
+			continue
+		}
+		if strings.Contains(trimmed, p.RetranslateOnly) {
+			return true
+		}
+	}
+	return false
+}
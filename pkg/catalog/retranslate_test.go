@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForceRetranslate(t *testing.T) {
+	p := New(DefaultOptions())
+
+	pot := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "World"
+msgstr ""
+
+#. Button label
+msgid "Open"
+msgstr ""
+`
+	po := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr "Hola"
+
+#, manual
+msgid "World"
+msgstr "Mundo"
+
+# machine
+#. Button label
+msgid "Open"
+msgstr "Abrir"
+`
+
+	tests := []struct {
+		name     string
+		force    bool
+		only     string
+		rewrite  bool
+		expected map[string]string
+	}{
+		{"without flag", false, "", false, map[string]string{"Hello": "Hola", "World": "Mundo", "Open": "Abrir"}},
+		{"force", true, "", false, map[string]string{"Hello": "es:Hello", "World": "Mundo", "Open": "es:Open"}},
+		{"translator comment", true, "machine", false, map[string]string{"Hello": "Hola", "World": "Mundo", "Open": "es:Open"}},
+		{"manual flag", true, "manual", false, map[string]string{"Hello": "Hola", "World": "Mundo", "Open": "Abrir"}},
+		{"rewrite without flag", false, "", true, map[string]string{"Hello": "Hola", "World": "Mundo", "Open": "Abrir"}},
+		{"rewrite force", true, "", true, map[string]string{"Hello": "es:Hello", "World": "Mundo", "Open": "es:Open"}},
+		{"rewrite extracted comment", true, "Button", true, map[string]string{"Hello": "Hola", "World": "Mundo", "Open": "es:Open"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.ForceRetranslate, p.RetranslateOnly = tt.force, tt.only
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(potFile, []byte(pot), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := os.WriteFile(poFile, []byte(po), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			if tt.rewrite {
+				_, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			} else {
+				_, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, &fakeTranslator{})
+			}
+			if err != nil {
+				t.Fatalf("Failed to translate: %v", err)
+			}
+
+			entries, _, err := p.ParsePotFile(poFile)
+			if err != nil {
+				t.Fatalf("Failed to parse PO file: %v", err)
+			}
+			for msgid, msgstr := range tt.expected {
+				if entries[msgid].Msgstr != msgstr {
+					t.Errorf("Expected %q for %q, got %q", msgstr, msgid, entries[msgid].Msgstr)
+				}
+			}
+			if !hasFlag(entries["World"].Flags, manualFlag) {
+				t.Errorf("Expected the manual flag to be kept, got %v", entries["World"].Flags)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+)
+
+// seedPoFilePath returns the PO file of the seed language next to the other
+// PO files of the domain, compressed or not.
+func (p *Processor) seedPoFilePath(directory, domain, lang string) (string, error) {
+	seedFile := p.poFilePath(directory, domain, lang, p.Layout)
+	for _, candidate := range []string{seedFile, seedFile + gzipExt} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("seed PO file '%s' not found", seedFile)
+}
+
+// seedPoFile copies the translations of the seed PO file into the entries of
+// the new PO file with the same msgctxt and msgid, for --seed-from. Like
+// with --merge-from, untranslated and fuzzy entries aren't copied, and plural
+// entries only when they have the same number of forms. It returns the number
+// of entries that got a translation.
+func (p *Processor) seedPoFile(poFile, seedFile string) (int, error) {
+	seed, err := p.loadMemory(seedFile)
+	if err != nil {
+		return 0, fmt.Errorf("reading seed PO file: %v", err)
+	}
+	content, err := p.readCatalog(poFile)
+	if err != nil {
+		return 0, err
+	}
+
+	lines, lineEnding := splitLines(string(content))
+	blocks, _ := parsePoLines(lines)
+	seeded := 0
+	for _, block := range blocks {
+		if !block.isEntry || block.isHeader() {
+			continue
+		}
+		entry, exists := seed[block.key()]
+		switch {
+		case !exists:
+			continue
+		case block.isPlural():
+			if entry.MsgidPlural == "" || len(entry.Msgstrs) != len(block.Msgstrs) {
+				continue
+			}
+			block.Msgstrs = append([]string(nil), entry.Msgstrs...)
+		case entry.MsgidPlural != "":
+			continue
+		default:
+			block.Msgstr = entry.Msgstr
+		}
+		block.modified = true
+		seeded++
+	}
+	if seeded == 0 {
+		return 0, nil
+	}
+	return seeded, p.writeCatalog(poFile, []byte(joinLines(p.formatPoLines(blocks), lineEnding)))
+}
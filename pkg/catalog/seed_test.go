@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeedFrom(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout, p.AddLang, p.SeedFrom = "flat", "pt_BR", "pt"
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+
+msgid "Save"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	// Goodbye is fuzzy and Save is untranslated, so they aren't copied
+	seedContent := `msgid ""
+msgstr ""
+"Language: pt\n"
+
+msgid "Hello"
+msgstr "Olá"
+
+#, fuzzy
+msgid "Goodbye"
+msgstr "Adeus"
+
+msgid "Save"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d ficheiro"
+msgstr[1] "%d ficheiros"
+`
+
+	dir := t.TempDir()
+	files := map[string]string{"default.pot": potContent, "default_pt.po": seedContent}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	translator := &fakeTranslator{}
+	result, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil)
+	if err != nil {
+		t.Fatalf("ProcessDirectory() error = %v", err)
+	}
+	if got := strings.Join(translator.texts, ", "); got != "Goodbye, Save" && got != "Save, Goodbye" {
+		t.Errorf("Expected only Goodbye and Save to be translated, got %s", got)
+	}
+	if result.Translated != 2 {
+		t.Errorf("Expected 2 translated strings, got %d", result.Translated)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "default_pt_BR.po"))
+	if err != nil {
+		t.Fatalf("Expected the new PO file: %v", err)
+	}
+	for _, want := range []string{
+		"msgid \"Hello\"\nmsgstr \"Olá\"\n",
+		"msgstr[0] \"%d ficheiro\"\nmsgstr[1] \"%d ficheiros\"\n",
+		"msgid \"Goodbye\"\nmsgstr \"pt-BR:Goodbye\"\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the new PO file:\n%s", want, content)
+		}
+	}
+
+	// A missing seed language fails before the new file is created
+	p.SeedFrom = "es"
+	p.AddLang = "es_MX"
+	if _, err := p.ProcessDirectory(dir, dir, "default", 0, translator, nil); err == nil || !strings.Contains(err.Error(), "default_es.po") {
+		t.Errorf("Expected an error about the missing seed file, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "default_es_MX.po")); !os.IsNotExist(err) {
+		t.Errorf("Expected no new PO file without the seed file, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the accepted formats of --since and of the dates of the
+// "#. date:" comments.
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+// ParseDate parses a date like 2026-01-15, 2026-01-15 14:30 or an RFC 3339
+// timestamp, in UTC unless it has a time zone.
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s', use YYYY-MM-DD", value)
+}
+
+// entryDate returns the date of the "#. date:" comment of the POT entry, and
+// false when it has no such comment or its date can't be parsed.
+func entryDate(entry POEntry) (time.Time, bool) {
+	for _, line := range entry.Comments.Extracted {
+		comment := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#."))
+		if len(comment) < len("date:") || !strings.EqualFold(comment[:len("date:")], "date:") {
+			continue
+		}
+		if date, err := ParseDate(comment[len("date:"):]); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filteredBySince reports whether --since leaves the POT entry out, as its
+// "#. date:" comment is before the date. Entries without a date are only
+// left out with --skip-undated.
+func (p *Processor) filteredBySince(entry POEntry) bool {
+	if p.Since.IsZero() {
+		return false
+	}
+	date, dated := entryDate(entry)
+	if !dated {
+		return p.SkipUndated
+	}
+	return date.Before(p.Since)
+}
@@ -0,0 +1,110 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	p := New(DefaultOptions())
+
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+#. date: 2026-01-10
+msgid "Old"
+msgstr ""
+
+#. Shown on the home page
+#. date: 2026-02-01
+msgid "Cutoff"
+msgstr ""
+
+#. DATE: 2026-03-05 14:30
+msgid "New"
+msgstr ""
+
+#. date: soon
+msgid "Unparseable"
+msgstr ""
+
+msgid "Undated"
+msgstr ""
+`
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+`
+
+	tests := []struct {
+		since       string
+		skipUndated bool
+		expected    []string
+	}{
+		{"", false, []string{"Cutoff", "New", "Old", "Undated", "Unparseable"}},
+		{"2026-02-01", false, []string{"Cutoff", "New", "Undated", "Unparseable"}},
+		{"2026-02-01", true, []string{"Cutoff", "New"}},
+		{"2026-03-05T15:00:00Z", true, nil},
+	}
+
+	for _, tt := range tests {
+		for _, rewrite := range []bool{false, true} {
+			p.Since = time.Time{}
+			if tt.since != "" {
+				date, err := ParseDate(tt.since)
+				if err != nil {
+					t.Fatalf("ParseDate(%q) error = %v", tt.since, err)
+				}
+				p.Since = date
+			}
+			p.SkipUndated = tt.skipUndated
+			tempDir := t.TempDir()
+			potFile := filepath.Join(tempDir, "test.pot")
+			poFile := filepath.Join(tempDir, "test_es.po")
+			if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+				t.Fatalf("Failed to create POT file: %v", err)
+			}
+			if err := os.WriteFile(poFile, []byte(poContent), 0644); err != nil {
+				t.Fatalf("Failed to create PO file: %v", err)
+			}
+			potEntries, _, err := p.ParsePotFile(potFile)
+			if err != nil {
+				t.Fatalf("Failed to parse POT file: %v", err)
+			}
+
+			translator := &fakeTranslator{}
+			var result TranslationResult
+			if rewrite {
+				result, err = p.RewritePoFile(poFile, potEntries, "en", "es", 0, translator)
+			} else {
+				result, err = p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+			}
+			if err != nil {
+				t.Fatalf("%s, rewrite=%v: unexpected error: %v", tt.since, rewrite, err)
+			}
+
+			sort.Strings(translator.texts)
+			if strings.Join(translator.texts, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("%s, rewrite=%v: expected %q to be translated, got %q", tt.since, rewrite, tt.expected, translator.texts)
+			}
+			// Older entries aren't added, except by a rewrite that follows
+			// the POT file
+			added := len(tt.expected)
+			if rewrite {
+				added = 5
+			}
+			if result.Added != added || result.Translated != len(tt.expected) {
+				t.Errorf("%s, rewrite=%v: expected %d added and %d translated, got %+v", tt.since, rewrite, added, len(tt.expected), result)
+			}
+		}
+	}
+
+	if _, err := ParseDate("15/01/2026"); err == nil {
+		t.Error("Expected an error for a date in another format")
+	}
+}
@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// FileStats holds the translation coverage of a PO file, as shown by --stats.
+type FileStats struct {
+	File         string // Path relative to the processed directory
+	Language     string // Target language of the PO file
+	Total        int    // Entries in the POT file
+	Translated   int    // Entries with a translation that isn't fuzzy
+	Untranslated int    // Entries without a translation
+	Fuzzy        int    // Entries with a fuzzy translation
+}
+
+// coverage returns the percentage of translated entries.
+func (s FileStats) coverage() float64 {
+	if s.Total == 0 {
+		return 100
+	}
+	return float64(s.Translated) * 100 / float64(s.Total)
+}
+
+// collectStats counts the translated, untranslated and fuzzy entries of a PO
+// file for the entries of the POT file. Entries missing from the PO file
+// count as untranslated.
+func (p *Processor) collectStats(poFile string, potEntries map[string]POEntry) (FileStats, error) {
+	current, _, err := p.ParsePotFile(poFile)
+	if err != nil {
+		return FileStats{}, err
+	}
+
+	stats := FileStats{Total: len(potEntries)}
+	for key := range potEntries {
+		entry, exists := current[key]
+		switch {
+		case !exists || !isTranslated(entry):
+			stats.Untranslated++
+		case hasFlag(entry.Flags, "fuzzy"):
+			stats.Fuzzy++
+		default:
+			stats.Translated++
+		}
+	}
+	return stats, nil
+}
+
+// DirectoryStats collects the stats of the PO files of the domain in a
+// directory, without translating or writing anything.
+func (p *Processor) DirectoryStats(directory, root, domain string) ([]FileStats, error) {
+	potFile := p.potFilePath(directory, domain)
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("POT file '%s' not found", potFile)
+		}
+		return nil, fmt.Errorf("parsing POT file: %v", err)
+	}
+
+	poFiles, err := p.FindPoFiles(directory, domain, p.Layout)
+	if err != nil {
+		return nil, fmt.Errorf("finding PO files: %v", err)
+	}
+
+	var result []FileStats
+	for _, poFile := range p.filterByLanguage(poFiles, domain) {
+		stats, err := p.collectStats(poFile, potEntries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not read %s: %v\n", relativePath(root, poFile), err)
+			continue
+		}
+		stats.File = relativePath(root, poFile)
+		stats.Language, _ = p.GetTargetLanguage(poFile, domain)
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// WriteStats writes the stats as a table with a row per PO file, followed by
+// the totals of all files.
+func WriteStats(w io.Writer, files []FileStats) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "File\tLanguage\tTotal\tTranslated\tUntranslated\tFuzzy\tCoverage")
+
+	total := FileStats{File: "Total"}
+	for _, stats := range files {
+		writeStatsRow(table, stats)
+		total.Total += stats.Total
+		total.Translated += stats.Translated
+		total.Untranslated += stats.Untranslated
+		total.Fuzzy += stats.Fuzzy
+	}
+	writeStatsRow(table, total)
+	return table.Flush()
+}
+
+// writeStatsRow writes a single row of the stats table.
+func writeStatsRow(w io.Writer, stats FileStats) {
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%.1f%%\n",
+		stats.File, stats.Language, stats.Total, stats.Translated, stats.Untranslated, stats.Fuzzy, stats.coverage())
+}
@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	tempDir := t.TempDir()
+	potContent := `msgid ""
+msgstr ""
+"Language: en\n"
+
+msgid "One"
+msgstr ""
+
+msgid "Two"
+msgstr ""
+
+msgid "Three"
+msgstr ""
+
+msgid "Four"
+msgstr ""
+
+msgid "Five"
+msgstr ""
+`
+	// Three of five translated, one fuzzy and one missing entry
+	poContent := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "One"
+msgstr "Uno"
+
+msgid "Two"
+msgstr "Dos"
+
+msgid "Three"
+msgstr "Tres"
+
+#, fuzzy
+msgid "Four"
+msgstr "Cuarto"
+`
+	files := map[string]string{
+		"default.pot":   potContent,
+		"default_es.po": poContent,
+		"default_fr.po": "msgid \"\"\nmsgstr \"\"\n\"Language: fr\\n\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	stats, err := p.DirectoryStats(tempDir, tempDir, "default")
+	if err != nil {
+		t.Fatalf("DirectoryStats() error = %v", err)
+	}
+	want := []FileStats{
+		{File: "default_es.po", Language: "es", Total: 5, Translated: 3, Untranslated: 1, Fuzzy: 1},
+		{File: "default_fr.po", Language: "fr", Total: 5, Translated: 0, Untranslated: 5, Fuzzy: 0},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("Expected %d file stats, got %+v", len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("File stats %d = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+	if coverage := stats[0].coverage(); coverage != 60 {
+		t.Errorf("Expected 60%% coverage, got %v", coverage)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStats(&buf, stats); err != nil {
+		t.Fatalf("WriteStats() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header, 2 files and a total, got:\n%s", buf.String())
+	}
+	for i, expected := range []string{"60.0%", "0.0%", "30.0%"} {
+		if !strings.HasSuffix(lines[i+1], expected) {
+			t.Errorf("Expected line %q to end with %q", lines[i+1], expected)
+		}
+	}
+	if fields := strings.Fields(lines[3]); fields[0] != "Total" || fields[1] != "10" || fields[2] != "3" {
+		t.Errorf("Unexpected total line %q", lines[3])
+	}
+
+	// Nothing is written
+	content, err := os.ReadFile(filepath.Join(tempDir, "default_es.po"))
+	if err != nil {
+		t.Fatalf("Failed to read PO file: %v", err)
+	}
+	if string(content) != poContent {
+		t.Error("PO file was modified")
+	}
+}
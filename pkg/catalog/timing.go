@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"fmt"
+	"time"
+)
+
+// RequestStats counts the backend requests and the translation cache
+// lookups, for the --timing summary.
+type RequestStats struct {
+	Calls       int           // Requests sent to the backend, with retries
+	CallTime    time.Duration // Total duration of the backend requests
+	CacheHits   int           // Texts found in the translation cache
+	CacheMisses int           // Texts not found in the translation cache
+}
+
+// recordCall counts a backend request that took the duration.
+func (p *Processor) recordCall(duration time.Duration) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	p.stats.Calls++
+	p.stats.CallTime += duration
+}
+
+// recordCacheLookup counts a lookup of the translation cache.
+func (p *Processor) recordCacheLookup(hit bool) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	if hit {
+		p.stats.CacheHits++
+	} else {
+		p.stats.CacheMisses++
+	}
+}
+
+// currentStats returns the counts of the run so far.
+func (p *Processor) currentStats() RequestStats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	return p.stats
+}
+
+// add adds the counts of other.
+func (s *RequestStats) add(other RequestStats) {
+	s.Calls += other.Calls
+	s.CallTime += other.CallTime
+	s.CacheHits += other.CacheHits
+	s.CacheMisses += other.CacheMisses
+}
+
+// since returns the counts added after the earlier counts.
+func (s RequestStats) since(earlier RequestStats) RequestStats {
+	return RequestStats{
+		Calls:       s.Calls - earlier.Calls,
+		CallTime:    s.CallTime - earlier.CallTime,
+		CacheHits:   s.CacheHits - earlier.CacheHits,
+		CacheMisses: s.CacheMisses - earlier.CacheMisses,
+	}
+}
+
+// Timing describes the throughput of the result for --timing, like "Timing:
+// 12 string(s) in 4.1s, 10 backend call(s) averaging 380ms, cache hit rate 17%
+// (2 of 12)", with the wall time of the run.
+func (r TranslationResult) Timing(wall time.Duration) string {
+	calls := "no backend calls"
+	if r.Requests.Calls > 0 {
+		average := r.Requests.CallTime / time.Duration(r.Requests.Calls)
+		calls = fmt.Sprintf("%d backend call(s) averaging %dms", r.Requests.Calls, average.Milliseconds())
+	}
+	cacheRate := "no cache lookups"
+	if lookups := r.Requests.CacheHits + r.Requests.CacheMisses; lookups > 0 {
+		cacheRate = fmt.Sprintf("cache hit rate %d%% (%d of %d)", r.Requests.CacheHits*100/lookups, r.Requests.CacheHits, lookups)
+	}
+	return fmt.Sprintf("Timing: %d string(s) in %s, %s, %s", r.Translated, wall.Round(time.Millisecond), calls, cacheRate)
+}
@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTiming(t *testing.T) {
+	p := New(DefaultOptions())
+	tempDir := t.TempDir()
+	potFile := filepath.Join(tempDir, "test.pot")
+	potContent := "msgid \"\"\nmsgstr \"\"\n\"Language: en\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n\nmsgid \"World\"\nmsgstr \"\"\n\nmsgid \"Again\"\nmsgstr \"\"\n"
+	if err := os.WriteFile(potFile, []byte(potContent), 0644); err != nil {
+		t.Fatalf("Failed to create POT file: %v", err)
+	}
+	potEntries, _, err := p.ParsePotFile(potFile)
+	if err != nil {
+		t.Fatalf("Failed to parse POT file: %v", err)
+	}
+
+	if p.cache, err = loadCache(filepath.Join(tempDir, "cache.json")); err != nil {
+		t.Fatalf("loadCache() error = %v", err)
+	}
+
+	// The second file gets all its translations from the cache
+	var total TranslationResult
+	translator := &fakeTranslator{}
+	for _, name := range []string{"test_es.po", "other_es.po"} {
+		poFile := filepath.Join(tempDir, name)
+		if err := os.WriteFile(poFile, []byte("msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create PO file: %v", err)
+		}
+		result, err := p.TranslatePoFile(poFile, potEntries, "en", "es", 0, translator)
+		if err != nil {
+			t.Fatalf("TranslatePoFile failed: %v", err)
+		}
+		total.Add(result)
+	}
+
+	requests := total.Requests
+	if requests.Calls != 3 || translator.calls() != 3 || requests.CacheHits != 3 || requests.CacheMisses != 3 {
+		t.Errorf("Expected 3 calls, 3 cache hits and 3 misses, got %+v", requests)
+	}
+	if requests.CallTime < 0 || requests.CallTime > time.Second {
+		t.Errorf("Expected the calls of the fake translator to be fast, got %s", requests.CallTime)
+	}
+
+	timing := total.Timing(1500 * time.Millisecond)
+	if !strings.HasPrefix(timing, "Timing: 6 string(s) in 1.5s, 3 backend call(s) averaging ") || !strings.HasSuffix(timing, "ms, cache hit rate 50% (3 of 6)") {
+		t.Errorf("Unexpected timing summary %q", timing)
+	}
+	if timing := (TranslationResult{}).Timing(0); timing != "Timing: 0 string(s) in 0s, no backend calls, no cache lookups" {
+		t.Errorf("Unexpected timing summary without requests %q", timing)
+	}
+}
@@ -0,0 +1,393 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bregydoc/gtranslate"
+)
+
+// Translator translates a text from one language to another.
+type Translator interface {
+	Translate(text, from, to string) (string, error)
+}
+
+// ContextTranslator is implemented by translators that can abort a request
+// when its context is done.
+type ContextTranslator interface {
+	TranslateContext(ctx context.Context, text, from, to string) (string, error)
+}
+
+// errTimeout is returned for translations that took longer than --timeout.
+var errTimeout = errors.New("translation timed out")
+
+// timeoutRetries is the number of times a timed out translation is retried.
+const timeoutRetries = 1
+
+// translateTimeout translates a text, giving up after --timeout or when the
+// run is interrupted. Timed out translations are retried.
+func (p *Processor) translateTimeout(translator Translator, text, from, to string) (string, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.waitRateLimit(); err != nil {
+			return "", err
+		}
+		ctx, cancel := p.requestContext()
+		start := time.Now()
+		translation, err := translateContext(ctx, translator, text, from, to)
+		p.recordCall(time.Since(start))
+		cancel()
+		if errors.Is(err, errTimeout) && attempt < timeoutRetries && p.ctx.Err() == nil {
+			continue
+		}
+		if errors.Is(err, errTimeout) {
+			err = fmt.Errorf("%w after %s", err, p.Timeout)
+		}
+		return translation, err
+	}
+}
+
+// requestContext returns the context of a backend request, which is done
+// after --timeout or when the run is interrupted.
+func (p *Processor) requestContext() (context.Context, context.CancelFunc) {
+	if p.Timeout > 0 {
+		return context.WithTimeout(p.ctx, p.Timeout)
+	}
+	return context.WithCancel(p.ctx)
+}
+
+// Errors for responses of the backend that aren't a translation.
+var (
+	errEmptyTranslation = errors.New("the backend returned an empty translation")
+	errSameTranslation  = errors.New("the backend returned the text untranslated")
+)
+
+// untranslatedRetries is the number of times a text is retried after such a
+// response.
+const untranslatedRetries = 1
+
+// translateChecked translates a text like translateTimeout, retrying once
+// when the backend returns an empty translation or, unless --allow-identical,
+// the text itself for another language. Such responses are errors when they
+// persist, so the entry is left untranslated.
+func (p *Processor) translateChecked(translator Translator, text, from, to string) (string, error) {
+	for attempt := 0; ; attempt++ {
+		translation, err := p.translateTimeout(translator, text, from, to)
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimSpace(translation)
+		switch {
+		case trimmed == "":
+			err = errEmptyTranslation
+		case !p.AllowIdentical && trimmed == strings.TrimSpace(text) && !sameLanguage(from, to):
+			err = errSameTranslation
+		default:
+			return translation, nil
+		}
+		if attempt == untranslatedRetries || p.ctx.Err() != nil {
+			return "", err
+		}
+	}
+}
+
+// sameLanguage reports whether the locales share their language, like en and
+// en-GB, where an unchanged text is a valid translation.
+func sameLanguage(from, to string) bool {
+	fromLanguage, _, _ := strings.Cut(translatorLanguage(from), "-")
+	toLanguage, _, _ := strings.Cut(translatorLanguage(to), "-")
+	return fromLanguage == toLanguage
+}
+
+// translateContext translates a text until the context is done. Translators
+// without context support are left running in the background and their
+// result is discarded.
+func translateContext(ctx context.Context, translator Translator, text, from, to string) (string, error) {
+	type response struct {
+		translation string
+		err         error
+	}
+	done := make(chan response, 1)
+	go func() {
+		var r response
+		if contextTranslator, ok := translator.(ContextTranslator); ok {
+			r.translation, r.err = contextTranslator.TranslateContext(ctx, text, from, to)
+		} else {
+			r.translation, r.err = translator.Translate(text, from, to)
+		}
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", errTimeout
+		}
+		return r.translation, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", errTimeout
+		}
+		return "", fmt.Errorf("translation interrupted")
+	}
+}
+
+// usesBackend reports whether untranslated entries are sent to the backend.
+// With --import or --no-network the translator isn't used at all.
+func (p *Processor) usesBackend() bool {
+	return p.imported == nil && !p.NoNetwork
+}
+
+// NewTranslator creates the translator for the named backend.
+func NewTranslator(backend, endpoint, apiKey string) (Translator, error) {
+	switch backend {
+	case "google":
+		return googleTranslator{}, nil
+	case "libretranslate":
+		if endpoint == "" {
+			return nil, fmt.Errorf("the libretranslate backend requires --endpoint")
+		}
+		return newLibreTranslator(endpoint, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown backend '%s' (use 'google' or 'libretranslate')", backend)
+	}
+}
+
+// googleTranslator translates using the Google Translate web API.
+type googleTranslator struct{}
+
+func (googleTranslator) Translate(text, from, to string) (string, error) {
+	return gtranslate.TranslateWithParams(
+		text,
+		gtranslate.TranslationParams{
+			From: googleLanguage(from),
+			To:   googleLanguage(to),
+		},
+	)
+}
+
+// googleDetectURL is the Google Translate endpoint used for detection, which
+// gtranslate doesn't expose.
+var googleDetectURL = "https://translate.googleapis.com/translate_a/single"
+
+// Detect asks Google Translate to translate the text from "auto", reading the
+// detected language and its confidence from the response.
+func (googleTranslator) Detect(ctx context.Context, text string) (string, float64, error) {
+	query := url.Values{"client": {"gtx"}, "sl": {"auto"}, "tl": {"en"}, "dt": {"t"}, "q": {text}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleDetectURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("%s returned %s", googleDetectURL, resp.Status)
+	}
+
+	// The response is an array with the detected language at index 2 and
+	// the confidence at index 6
+	var result []any
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", 0, fmt.Errorf("invalid response from %s: %v", googleDetectURL, err)
+	}
+	if len(result) < 3 {
+		return "", 0, fmt.Errorf("invalid response from %s", googleDetectURL)
+	}
+	language, _ := result[2].(string)
+	confidence := 1.0
+	if len(result) > 6 {
+		if value, ok := result[6].(float64); ok {
+			confidence = value
+		}
+	}
+	return language, confidence, nil
+}
+
+// googleLanguagesURL is the Google Translate endpoint listing the supported
+// languages.
+var googleLanguagesURL = "https://translate.googleapis.com/translate_a/l"
+
+// Languages lists the target languages of Google Translate, the keys of the
+// "tl" object of the response.
+func (googleTranslator) Languages(ctx context.Context) ([]string, error) {
+	query := url.Values{"client": {"gtx"}, "hl": {"en"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleLanguagesURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", googleLanguagesURL, resp.Status)
+	}
+
+	var result struct {
+		Targets map[string]string `json:"tl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %v", googleLanguagesURL, err)
+	}
+	var languages []string
+	for code := range result.Targets {
+		languages = append(languages, code)
+	}
+	return languages, nil
+}
+
+// libreTranslator translates using a LibreTranslate compatible server.
+type libreTranslator struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func newLibreTranslator(endpoint, apiKey string) *libreTranslator {
+	return &libreTranslator{
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/translate",
+		apiKey:   apiKey,
+		client:   &http.Client{},
+	}
+}
+
+type libreRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+func (l *libreTranslator) Translate(text, from, to string) (string, error) {
+	return l.TranslateContext(context.Background(), text, from, to)
+}
+
+func (l *libreTranslator) TranslateContext(ctx context.Context, text, from, to string) (string, error) {
+	body, err := json.Marshal(libreRequest{
+		Q:      text,
+		Source: from,
+		Target: to,
+		Format: "text",
+		APIKey: l.apiKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("could not connect to %s: %v", l.endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	var result libreResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid response from %s: %v", l.endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return "", fmt.Errorf("%s returned %s: %s", l.endpoint, resp.Status, result.Error)
+		}
+		return "", fmt.Errorf("%s returned %s", l.endpoint, resp.Status)
+	}
+
+	return result.TranslatedText, nil
+}
+
+type libreDetection struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Detect uses the /detect endpoint of the LibreTranslate server, which
+// reports the confidence as a percentage.
+func (l *libreTranslator) Detect(ctx context.Context, text string) (string, float64, error) {
+	detectURL := strings.TrimSuffix(l.endpoint, "/translate") + "/detect"
+	form := url.Values{"q": {text}}
+	if l.apiKey != "" {
+		form.Set("api_key", l.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, detectURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not connect to %s: %v", detectURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("%s returned %s", detectURL, resp.Status)
+	}
+
+	var detections []libreDetection
+	if err := json.NewDecoder(resp.Body).Decode(&detections); err != nil {
+		return "", 0, fmt.Errorf("invalid response from %s: %v", detectURL, err)
+	}
+	if len(detections) == 0 {
+		return "", 0, nil
+	}
+	return detections[0].Language, detections[0].Confidence / 100, nil
+}
+
+type libreLanguage struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Languages uses the /languages endpoint of the LibreTranslate server.
+func (l *libreTranslator) Languages(ctx context.Context) ([]string, error) {
+	languagesURL := strings.TrimSuffix(l.endpoint, "/translate") + "/languages"
+	if l.apiKey != "" {
+		languagesURL += "?" + url.Values{"api_key": {l.apiKey}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, languagesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %v", languagesURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", languagesURL, resp.Status)
+	}
+
+	var result []libreLanguage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %v", languagesURL, err)
+	}
+	var languages []string
+	for _, language := range result {
+		languages = append(languages, language.Code)
+	}
+	return languages, nil
+}
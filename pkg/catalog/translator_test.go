@@ -0,0 +1,237 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLibreTranslator(t *testing.T) {
+	var received libreRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/translate" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		if received.Target == "xx" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"xx is not supported"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(libreResponse{TranslatedText: "Hola"})
+	}))
+	defer server.Close()
+
+	translator, err := NewTranslator("libretranslate", server.URL+"/", "secret")
+	if err != nil {
+		t.Fatalf("NewTranslator() error = %v", err)
+	}
+
+	translated, err := translator.Translate("Hello", "en", "es")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if translated != "Hola" {
+		t.Errorf("Expected 'Hola', got %q", translated)
+	}
+
+	expected := libreRequest{Q: "Hello", Source: "en", Target: "es", Format: "text", APIKey: "secret"}
+	if received != expected {
+		t.Errorf("Expected request %+v, got %+v", expected, received)
+	}
+
+	if _, err := translator.Translate("Hello", "en", "xx"); err == nil || !strings.Contains(err.Error(), "xx is not supported") {
+		t.Errorf("Expected server error to be reported, got %v", err)
+	}
+}
+
+func TestLibreTranslatorConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	translator := newLibreTranslator(endpoint, "")
+	_, err := translator.Translate("Hello", "en", "es")
+	if err == nil {
+		t.Fatal("Expected connection error, got nil")
+	}
+	if !strings.Contains(err.Error(), endpoint) {
+		t.Errorf("Expected error to include endpoint %q, got %v", endpoint, err)
+	}
+}
+
+func TestNewTranslator(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   string
+		endpoint  string
+		wantError bool
+	}{
+		{name: "google", backend: "google"},
+		{name: "libretranslate", backend: "libretranslate", endpoint: "http://localhost:5000"},
+		{name: "libretranslate without endpoint", backend: "libretranslate", wantError: true},
+		{name: "unknown backend", backend: "unknown", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTranslator(tt.backend, tt.endpoint, "")
+			if (err != nil) != tt.wantError {
+				t.Errorf("NewTranslator() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestTranslateTimeout(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Timeout = 50 * time.Millisecond
+
+	// Closing release ends the blocked translations and requests, so that
+	// neither the fake translator goroutines nor the server handlers leak
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer func() {
+		close(release)
+		server.Close()
+	}()
+
+	translator := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		<-release
+		return "too late", nil
+	}}
+
+	start := time.Now()
+	_, err := p.translateTimeout(translator, "Hello", "en", "es")
+	elapsed := time.Since(start)
+	if !errors.Is(err, errTimeout) {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	// The timed out translation is retried once
+	if elapsed < 2*p.Timeout || elapsed > 2*p.Timeout+time.Second {
+		t.Errorf("Expected to give up after %s, took %s", 2*p.Timeout, elapsed)
+	}
+	if translator.calls() != 1+timeoutRetries {
+		t.Errorf("Expected %d attempts, got %d", 1+timeoutRetries, translator.calls())
+	}
+
+	// Requests of context aware translators are aborted
+	start = time.Now()
+	if _, err := p.translateTimeout(newLibreTranslator(server.URL, ""), "Hello", "en", "es"); !errors.Is(err, errTimeout) {
+		t.Errorf("Expected a timeout error from the server, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*p.Timeout+time.Second {
+		t.Errorf("Expected the request to be aborted, took %s", elapsed)
+	}
+
+	// Interrupting cancels the request in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := translateContext(ctx, translator, "Hello", "en", "es"); err == nil || errors.Is(err, errTimeout) {
+		t.Errorf("Expected an interruption error, got %v", err)
+	}
+}
+
+func TestBackendRequestTimeout(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Timeout = 50 * time.Millisecond
+	previousDetectURL, previousLanguagesURL := googleDetectURL, googleLanguagesURL
+	defer func() { googleDetectURL, googleLanguagesURL = previousDetectURL, previousLanguagesURL }()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer func() {
+		close(release)
+		server.Close()
+	}()
+	googleDetectURL, googleLanguagesURL = server.URL+"/single", server.URL+"/l"
+
+	libre := newLibreTranslator(server.URL, "")
+	requests := map[string]func(ctx context.Context) error{
+		"libretranslate detect":    func(ctx context.Context) error { _, _, err := libre.Detect(ctx, "Hallo"); return err },
+		"libretranslate languages": func(ctx context.Context) error { _, err := libre.Languages(ctx); return err },
+		"google detect":            func(ctx context.Context) error { _, _, err := googleTranslator{}.Detect(ctx, "Hallo"); return err },
+		"google languages":         func(ctx context.Context) error { _, err := googleTranslator{}.Languages(ctx); return err },
+	}
+	for name, request := range requests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := p.requestContext()
+			defer cancel()
+			start := time.Now()
+			if err := request(ctx); err == nil {
+				t.Error("Expected the request to time out")
+			}
+			if elapsed := time.Since(start); elapsed > p.Timeout+time.Second {
+				t.Errorf("Expected the request to be aborted after %s, took %s", p.Timeout, elapsed)
+			}
+		})
+	}
+}
+
+func TestTranslateChecked(t *testing.T) {
+	p := New(DefaultOptions())
+
+	tests := []struct {
+		name           string
+		responses      []string // Returned in turn, the last one repeatedly
+		to             string
+		allowIdentical bool
+		expected       string
+		err            error
+		calls          int
+	}{
+		{"translated", []string{"Hola"}, "es", false, "Hola", nil, 1},
+		{"empty once", []string{"", "Hola"}, "es", false, "Hola", nil, 2},
+		{"empty", []string{" "}, "es", false, "", errEmptyTranslation, 2},
+		{"echoed once", []string{"Hello", "Hola"}, "es", false, "Hola", nil, 2},
+		{"echoed", []string{"Hello"}, "es", false, "", errSameTranslation, 2},
+		{"echoed allowed", []string{"Hello"}, "es", true, "Hello", nil, 1},
+		{"empty with identical allowed", []string{""}, "es", true, "", errEmptyTranslation, 2},
+		// The same language in another region often has the same text
+		{"same language", []string{"Hello"}, "en-GB", false, "Hello", nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.AllowIdentical = tt.allowIdentical
+			translator := &fakeTranslator{}
+			translator.translate = func(text, from, to string) (string, error) {
+				return tt.responses[min(translator.calls(), len(tt.responses))-1], nil
+			}
+			translation, err := p.translateChecked(translator, "Hello", "en", tt.to)
+			if translation != tt.expected || !errors.Is(err, tt.err) {
+				t.Errorf("Expected %q, %v, got %q, %v", tt.expected, tt.err, translation, err)
+			}
+			if translator.calls() != tt.calls {
+				t.Errorf("Expected %d calls, got %d", tt.calls, translator.calls())
+			}
+		})
+	}
+
+	// The entry is left untranslated and counted as failed
+	echo := &fakeTranslator{translate: func(text, from, to string) (string, error) {
+		return text, nil
+	}}
+	result := p.translateEntries("test_es.po", []string{"Hello"}, nil, nil, nil, 2, "en", "es", 0, echo, nil)
+	if _, exists := result.singular["Hello"]; exists || result.failed != 1 || result.count != 0 {
+		t.Errorf("Expected a failed translation, got %+v", result)
+	}
+}
@@ -0,0 +1,216 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ValidateDirectory checks the PO files of the domain in a directory like
+// msgfmt --check does, without translating or writing anything. It returns
+// the problems found as "file.po:line: problem", with the file relative to
+// root.
+func (p *Processor) ValidateDirectory(directory, root, domain string) ([]string, error) {
+	poFiles, err := p.FindPoFiles(directory, domain, p.Layout)
+	if err != nil {
+		return nil, fmt.Errorf("finding PO files: %v", err)
+	}
+
+	var problems []string
+	for _, poFile := range p.filterByLanguage(poFiles, domain) {
+		content, err := p.readCatalog(poFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not read %s: %v\n", relativePath(root, poFile), err)
+			continue
+		}
+		lines, _ := splitLines(string(content))
+		for _, problem := range validateLines(lines) {
+			problems = append(problems, fmt.Sprintf("%s:%s", relativePath(root, poFile), problem))
+		}
+	}
+	return problems, nil
+}
+
+// validateLines checks the lines of a PO file and returns the problems as
+// "line: problem": strings that aren't quoted or escaped properly, a msgid
+// without msgstr, plural entries without the forms of the Plural-Forms
+// header, and translations of formatted strings with other format specifiers.
+func validateLines(lines []string) []string {
+	type lineProblem struct {
+		line int
+		text string
+	}
+	var found []lineProblem
+	report := func(line int, format string, args ...any) {
+		found = append(found, lineProblem{line, fmt.Sprintf(format, args...)})
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if err := checkString(trimmed); err != nil {
+			report(i+1, "%v", err)
+		} else if sequence := invalidEscape(trimmed); sequence != "" {
+			report(i+1, "invalid escape sequence %s", sequence)
+		}
+	}
+
+	nplurals := parsePluralCount(lines)
+	blocks, _ := parsePoLines(lines)
+	start := 0 // Index of the first line of the block
+	for _, block := range blocks {
+		if !block.isEntry {
+			start += len(block.other)
+			continue
+		}
+		keyStart := start + len(block.comments)
+		msgstrStart := keyStart + len(block.keyLines)
+		start = msgstrStart + len(block.msgstrLines)
+		if block.missingMsgstr != 0 {
+			start-- // The empty msgstr isn't in the file
+			report(block.missingMsgstr, "msgid without msgstr")
+			continue
+		}
+		if block.isHeader() || hasFlag(block.Flags, "fuzzy") || block.Msgstr+strings.Join(block.Msgstrs, "") == "" {
+			continue
+		}
+
+		if block.MsgidPlural != "" {
+			if len(block.Msgstrs) != nplurals {
+				report(msgstrStart+1, "found %d plural form(s), the Plural-Forms header needs %d", len(block.Msgstrs), nplurals)
+			}
+			for n, form := range block.Msgstrs {
+				if form == "" {
+					report(formLine(block.msgstrLines, n, msgstrStart), "msgstr[%d] is empty", n)
+				}
+			}
+		}
+		style := ""
+		for _, flag := range block.Flags {
+			if flagStyle, exists := formatStyles[flag]; exists {
+				style = flagStyle
+			}
+		}
+		if style == "" {
+			continue
+		}
+		if len(block.Msgstrs) == 0 {
+			if problem := compareSpecifiers(block.Msgid, block.Msgstr, style, false); problem != "" {
+				report(msgstrStart+1, "msgstr %s", problem)
+			}
+			continue
+		}
+		for n, form := range block.Msgstrs {
+			source := block.MsgidPlural
+			if n == 0 {
+				source = block.Msgid
+			}
+			if problem := compareSpecifiers(source, form, style, true); form != "" && problem != "" {
+				report(formLine(block.msgstrLines, n, msgstrStart), "msgstr[%d] %s", n, problem)
+			}
+		}
+	}
+
+	// The strings are checked before the entries, list the problems by line
+	slices.SortStableFunc(found, func(a, b lineProblem) int { return a.line - b.line })
+	var problems []string
+	for _, problem := range found {
+		problems = append(problems, fmt.Sprintf("%d: %s", problem.line, problem.text))
+	}
+	return problems
+}
+
+// formLine returns the line number of msgstr[n] in the msgstr lines of an
+// entry, which start at index start of the file.
+func formLine(msgstrLines []string, n, start int) int {
+	prefix := fmt.Sprintf("msgstr[%d]", n)
+	for i, line := range msgstrLines {
+		if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+			return start + i + 1
+		}
+	}
+	return start + 1
+}
+
+// invalidEscape returns the first backslash sequence of the quoted string on
+// the line that isn't a C escape, or an empty string when there is none.
+func invalidEscape(trimmed string) string {
+	start := strings.Index(trimmed, "\"")
+	if start < 0 {
+		return ""
+	}
+	value := strings.TrimSuffix(trimmed[start+1:], "\"")
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			continue
+		}
+		if i+1 == len(value) {
+			return "\\"
+		}
+		i++
+		if _, known := unescapes[value[i]]; !known && value[i] != 'x' && !isDigit(value[i], 8) {
+			return "\\" + string(value[i])
+		}
+	}
+	return ""
+}
+
+// compareSpecifiers describes how the format specifiers of the translation
+// differ from those of the source text, or returns an empty string when they
+// match. Printf specifiers are compared by their position, so "%1$s" in the
+// source matches the first "%s" of the translation. With omit set, as for
+// plural forms, the translation may leave a specifier out, like "%d" in the
+// form for one item.
+func compareSpecifiers(source, translation, style string, omit bool) string {
+	expected, sourceSpecifiers := formatSpecifiers(source, style)
+	found, translationSpecifiers := formatSpecifiers(translation, style)
+	if slices.Equal(expected, found) {
+		return ""
+	}
+	if omit && len(found) <= len(expected) {
+		remaining := slices.Clone(expected)
+		subset := true
+		for _, specifier := range found {
+			n := slices.Index(remaining, specifier)
+			if n < 0 {
+				subset = false
+				break
+			}
+			remaining = slices.Delete(remaining, n, n+1)
+		}
+		if subset {
+			return ""
+		}
+	}
+	return fmt.Sprintf("has the format specifiers '%s' instead of '%s'",
+		strings.Join(translationSpecifiers, " "), strings.Join(sourceSpecifiers, " "))
+}
+
+// formatSpecifiers returns the format specifiers of the text, as they are
+// compared in a sorted order and as they appear. For comparing, printf
+// specifiers get their position, or else their place in the text, and keep
+// only their conversion, like "1$s"; those with a mapping key, like Python's
+// "%(name)s", can be reordered already.
+func formatSpecifiers(text, style string) ([]string, []string) {
+	var keys, specifiers []string
+	for _, match := range placeholderPatterns[style].FindAllString(text, -1) {
+		if match == "%%" {
+			continue
+		}
+		key := match
+		if strings.HasPrefix(match, "%") && !strings.HasPrefix(match, "%(") {
+			position, conversion, found := strings.Cut(match[1:], "$")
+			if !found {
+				position, conversion = fmt.Sprint(len(keys)+1), match[1:]
+			}
+			key = position + "$" + strings.TrimLeft(conversion, "-+#0123456789.")
+		}
+		keys = append(keys, key)
+		specifiers = append(specifiers, match)
+	}
+	slices.Sort(keys)
+	return keys, specifiers
+}
@@ -0,0 +1,149 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateLines(t *testing.T) {
+	header := `msgid ""
+msgstr ""
+"Language: ru\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : 1);\n"
+`
+	tests := []struct {
+		name     string
+		entries  string
+		expected []string
+	}{
+		{
+			"clean file",
+			`
+#, c-format
+msgid "Hello %s, you have %d messages"
+msgstr "Привет %s, у вас %d сообщений"
+
+#, c-format
+msgid "%s of %s"
+msgstr "%2$s из %1$s"
+
+#, c-format
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "Один файл"
+msgstr[1] "%d файла"
+msgstr[2] "%d файлов"
+
+msgid "Not formatted %s"
+msgstr "Без формата"
+
+#, fuzzy, c-format
+msgid "Fuzzy %s"
+msgstr "Неточно"
+
+msgid "Untranslated"
+msgstr ""
+`,
+			nil,
+		},
+		{
+			"format mismatch",
+			`
+#, c-format
+msgid "Hello %s, you have %d messages"
+msgstr "Привет %d, у вас %s сообщений"
+`,
+			[]string{"8: msgstr has the format specifiers '%d %s' instead of '%s %d'"},
+		},
+		{
+			"missing specifier",
+			`
+#, python-brace-format
+msgid "Hello {name}"
+msgstr "Привет"
+`,
+			[]string{"8: msgstr has the format specifiers '' instead of '{name}'"},
+		},
+		{
+			"extra specifier in a plural form",
+			`
+#, c-format
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "%s файл"
+msgstr[1] "%d файла"
+msgstr[2] "%d файлов"
+`,
+			[]string{"9: msgstr[0] has the format specifiers '%s' instead of ''"},
+		},
+		{
+			"missing plural forms",
+			`
+msgid "One file"
+msgid_plural "Files"
+msgstr[0] "Файл"
+msgstr[1] ""
+`,
+			[]string{"8: found 2 plural form(s), the Plural-Forms header needs 3", "9: msgstr[1] is empty"},
+		},
+		{
+			"unescaped characters",
+			`
+msgid "Say \"hi\""
+msgstr "Скажи "привет""
+
+msgid "Tab\q"
+msgstr "Таб\q"
+`,
+			[]string{"7: unexpected text after the end of the string", "9: invalid escape sequence \\q", "10: invalid escape sequence \\q"},
+		},
+		{
+			"msgid without msgstr",
+			`
+msgid "Lost"
+
+msgid "Found"
+msgstr "Найдено"
+`,
+			[]string{"6: msgid without msgstr"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(header+tt.entries, "\n")
+			if problems := validateLines(lines); !slices.Equal(problems, tt.expected) {
+				t.Errorf("validateLines() = %q, want %q", problems, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateDirectory(t *testing.T) {
+	p := New(DefaultOptions())
+	p.Layout = "flat"
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"default.pot":   "msgid \"\"\nmsgstr \"\"\n\n#, c-format\nmsgid \"%d items\"\nmsgstr \"\"\n",
+		"default_es.po": "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n\n#, c-format\nmsgid \"%d items\"\nmsgstr \"%d elementos\"\n",
+		"default_fr.po": "msgid \"\"\nmsgstr \"\"\n\"Language: fr\\n\"\n\n#, c-format\nmsgid \"%d items\"\nmsgstr \"%s éléments\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	problems, err := p.ValidateDirectory(tempDir, tempDir, "default")
+	if err != nil {
+		t.Fatalf("ValidateDirectory failed: %v", err)
+	}
+	expected := []string{"default_fr.po:7: msgstr has the format specifiers '%s' instead of '%d'"}
+	if !slices.Equal(problems, expected) {
+		t.Errorf("ValidateDirectory() = %q, want %q", problems, expected)
+	}
+}